@@ -0,0 +1,75 @@
+package cmd
+
+import (
+	"fmt"
+	"maps"
+	"path/filepath"
+	"slices"
+
+	"github.com/griffin/go-shellify/internal/lint"
+	"github.com/griffin/go-shellify/internal/registry"
+)
+
+// lintModule lints the code of a cached module, leaving out findings
+// allowlisted in the configuration. Security findings are returned first,
+// then warnings about aliases and functions shadowing existing commands.
+func lintModule(client *registry.Client, registryName string, entry registry.Module) ([]lint.Finding, []lint.Finding, error) {
+	repoPath := client.RepositoryPath(registryName)
+
+	mod, err := registry.LoadModule(repoPath, entry)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	findings, err := lint.CheckSecurity(mod, filepath.Join(repoPath, mod.Path))
+	if err != nil {
+		return nil, nil, err
+	}
+	allow := lint.Allowlist(ConfigManager.Get().Security.Allow)
+	return allow.Filter(findings), allow.Filter(lint.CheckShadowing(mod, "")), nil
+}
+
+// lintRegistry checks the code of every module in a registry checkout for
+// security findings and syntax errors in the shells it declares. Aliases and
+// functions shadowing existing commands are returned separately as warnings.
+func lintRegistry(repoPath string) ([]lint.Finding, []lint.Finding, error) {
+	modules, err := registry.LoadModules(repoPath)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	allow := lint.Allowlist(ConfigManager.Get().Security.Allow)
+	var findings, warnings []lint.Finding
+	for _, name := range slices.Sorted(maps.Keys(modules)) {
+		mod := modules[name]
+		security, err := lint.CheckSecurity(mod, filepath.Join(repoPath, mod.Path))
+		if err != nil {
+			return nil, nil, err
+		}
+		findings = append(findings, allow.Filter(security)...)
+		findings = append(findings, lint.CheckSyntax(mod, "")...)
+		warnings = append(warnings, allow.Filter(lint.CheckShadowing(mod, ""))...)
+	}
+	return findings, warnings, nil
+}
+
+// printShadowWarnings prints warnings about shadowed commands with a hint on
+// acknowledging them
+func printShadowWarnings(warnings []lint.Finding) {
+	if len(warnings) == 0 {
+		return
+	}
+	fmt.Printf("⚠️  %d aliases or functions shadow existing commands:\n", len(warnings))
+	printFindings(warnings)
+	fmt.Println("Set \"shadows\": true on items that replace a command on purpose.")
+}
+
+// printFindings prints lint findings with the offending code
+func printFindings(findings []lint.Finding) {
+	for _, f := range findings {
+		fmt.Printf("  - %s\n", f)
+		if f.Text != "" {
+			fmt.Printf("      %s\n", f.Text)
+		}
+	}
+}
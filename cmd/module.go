@@ -1,8 +1,15 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
+	"strings"
+	"time"
 
+	"github.com/griffin/go-shellify/internal/errors"
+	"github.com/griffin/go-shellify/internal/generator"
+	"github.com/griffin/go-shellify/internal/module"
+	"github.com/griffin/go-shellify/internal/registry"
 	"github.com/spf13/cobra"
 )
 
@@ -19,8 +26,11 @@ var moduleCmd = &cobra.Command{
 	Short: "Manage and discover shell modules",
 	Long: `Manage and discover shell modules from configured registries.
 
-Modules are shell configurations containing aliases, functions, 
-environment variables, and other shell enhancements.`,
+Modules are shell configurations containing aliases, functions,
+environment variables, and other shell enhancements.
+
+Modules are read from the local registry clones, so these commands
+also work with --offline.`,
 	Run: func(cmd *cobra.Command, args []string) {
 		// Show help when no subcommand is provided
 		cmd.Help()
@@ -32,21 +42,29 @@ var moduleListCmd = &cobra.Command{
 	Use:   "list",
 	Short: "List available modules",
 	Long:  `List all available modules from configured registries.`,
-	Run: func(cmd *cobra.Command, args []string) {
-		// TODO: Implement module list functionality (subtask 1.3.2)
-		fmt.Println("Available modules:")
-		
-		if categoryFlag != "" {
-			fmt.Printf("Filtering by category: %s\n", categoryFlag)
+	RunE: func(cmd *cobra.Command, args []string) error {
+		service, err := newModuleService(cmd.Context())
+		if err != nil {
+			return err
+		}
+
+		modules, err := service.ListAllModules()
+		if err != nil {
+			return errors.Wrap(err, errors.ErrTypeModule, "Failed to list modules")
 		}
-		if platformFlag != "" {
-			fmt.Printf("Filtering by platform: %s\n", platformFlag)
+		modules = service.FilterModules(modules, categoryFlag, platformFlag, shellFlag)
+
+		if len(modules) == 0 {
+			fmt.Println("No modules found")
+			return nil
 		}
-		if shellFlag != "" {
-			fmt.Printf("Filtering by shell: %s\n", shellFlag)
+
+		fmt.Println("Available modules:")
+		for _, mod := range modules {
+			printModuleSummary(mod)
 		}
-		
-		fmt.Println("Module list functionality not yet implemented")
+
+		return nil
 	},
 }
 
@@ -56,11 +74,74 @@ var moduleShowCmd = &cobra.Command{
 	Short: "Show module details",
 	Long:  `Display detailed information about a specific module.`,
 	Args:  cobra.ExactArgs(1),
-	Run: func(cmd *cobra.Command, args []string) {
+	RunE: func(cmd *cobra.Command, args []string) error {
 		moduleName := args[0]
-		// TODO: Implement module show functionality (subtask 1.3.4)
-		fmt.Printf("Module: %s\n", moduleName)
-		fmt.Println("Module show functionality not yet implemented")
+
+		service, err := newModuleService(cmd.Context())
+		if err != nil {
+			return err
+		}
+
+		mod, err := service.GetModuleDetails(moduleName)
+		if err != nil {
+			return errors.Wrap(err, errors.ErrTypeNotFound, "Module not found").
+				WithContext("module", moduleName)
+		}
+
+		fmt.Printf("Module: %s\n", mod.Name)
+		if mod.Description != "" {
+			fmt.Printf("Description: %s\n", mod.Description)
+		}
+		if mod.Version != "" {
+			fmt.Printf("Version: %s\n", mod.Version)
+		}
+		if mod.Author != "" {
+			fmt.Printf("Author: %s\n", mod.Author)
+		}
+		if mod.Category != "" {
+			fmt.Printf("Category: %s\n", mod.Category)
+		}
+		if len(mod.Tags) > 0 {
+			fmt.Printf("Tags: %s\n", strings.Join(mod.Tags, ", "))
+		}
+		if len(mod.Platforms) > 0 {
+			fmt.Printf("Platforms: %s\n", strings.Join(mod.Platforms, ", "))
+		}
+		if shells := moduleShells(*mod); shells != "" {
+			fmt.Printf("Shells: %s\n", shells)
+		}
+		if mod.When != "" {
+			fmt.Printf("When: %s\n", mod.When)
+		}
+		if len(mod.Dependencies) > 0 {
+			fmt.Printf("Dependencies: %s\n", strings.Join(mod.Dependencies, ", "))
+		}
+		if len(mod.Conflicts) > 0 {
+			fmt.Printf("Conflicts: %s\n", strings.Join(mod.Conflicts, ", "))
+		}
+		fmt.Printf("Registry: %s\n", mod.RegistryName)
+
+		if len(mod.Environment) > 0 {
+			fmt.Println("Environment:")
+			for _, env := range mod.Environment {
+				fmt.Printf("  %s=%s\n", env.Name, env.Value)
+			}
+		}
+		if len(mod.Aliases) > 0 {
+			fmt.Println("Aliases:")
+			for _, alias := range mod.Aliases {
+				fmt.Printf("  %s -> %s\n", alias.Name, alias.Command)
+			}
+		}
+		if len(mod.Functions) > 0 {
+			fmt.Println("Functions:")
+			for _, function := range mod.Functions {
+				fmt.Printf("  %s\n", function.Name)
+			}
+		}
+		printUnsupported(mod.Module)
+
+		return nil
 	},
 }
 
@@ -68,26 +149,108 @@ var moduleShowCmd = &cobra.Command{
 var moduleSearchCmd = &cobra.Command{
 	Use:   "search <query>",
 	Short: "Search for modules",
-	Long:  `Search for modules by name or description.`,
+	Long:  `Search for modules by name, description, category or tag.`,
 	Args:  cobra.ExactArgs(1),
-	Run: func(cmd *cobra.Command, args []string) {
+	RunE: func(cmd *cobra.Command, args []string) error {
 		query := args[0]
-		// TODO: Implement module search functionality (subtask 1.3.5)
-		fmt.Printf("Searching for: %s\n", query)
-		fmt.Println("Module search functionality not yet implemented")
+
+		service, err := newModuleService(cmd.Context())
+		if err != nil {
+			return err
+		}
+
+		modules, err := service.SearchModules(query)
+		if err != nil {
+			return errors.Wrap(err, errors.ErrTypeModule, "Failed to search modules").
+				WithContext("query", query)
+		}
+
+		if len(modules) == 0 {
+			fmt.Printf("No modules matching '%s'\n", query)
+			return nil
+		}
+
+		fmt.Printf("Modules matching '%s':\n", query)
+		for _, mod := range modules {
+			printModuleSummary(mod)
+		}
+
+		return nil
 	},
 }
 
+// newModuleService creates a module service over the cached registries,
+// syncing or reporting stale registries first
+func newModuleService(ctx context.Context) (*module.Service, error) {
+	client, err := newRegistryClient()
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrTypeConfig, "Failed to create registry client")
+	}
+
+	refreshStaleRegistries(ctx, client)
+
+	return module.NewService(client), nil
+}
+
+// printModuleSummary prints a one-line summary of a module
+func printModuleSummary(mod module.ModuleInfo) {
+	fmt.Printf("  - %s", mod.Name)
+	if mod.Version != "" {
+		fmt.Printf(" (%s)", mod.Version)
+	}
+	if mod.Description != "" {
+		fmt.Printf(": %s", mod.Description)
+	}
+	fmt.Printf(" [%s]\n", mod.RegistryName)
+}
+
+// moduleShells returns the shells a module supports as a display string
+func moduleShells(mod module.ModuleInfo) string {
+	if len(mod.Shells) > 0 {
+		return strings.Join(mod.Shells, ", ")
+	}
+	return mod.Shell
+}
+
+// printUnsupported lists the items of a module the shells it supports can't
+// express, per shell
+func printUnsupported(mod registry.Module) {
+	header := false
+	for _, shellType := range generator.Shells {
+		gen, err := generator.New(shellType)
+		if err != nil || !gen.Supports(mod) {
+			continue
+		}
+		reasons := gen.Unsupported(mod)
+		if len(reasons) == 0 {
+			continue
+		}
+		if !header {
+			fmt.Println("Unsupported:")
+			header = true
+		}
+		fmt.Printf("  %s: %s\n", shellType, strings.Join(reasons, "; "))
+	}
+}
+
+// formatAge formats a cache age in days, or hours when less than a day
+func formatAge(age time.Duration) string {
+	if age < 24*time.Hour {
+		return fmt.Sprintf("%d hours", int(age.Hours()))
+	}
+	return fmt.Sprintf("%d days", int(age.Hours()/24))
+}
+
 func init() {
 	rootCmd.AddCommand(moduleCmd)
-	
+
 	// Add subcommands to module
 	moduleCmd.AddCommand(moduleListCmd)
 	moduleCmd.AddCommand(moduleShowCmd)
 	moduleCmd.AddCommand(moduleSearchCmd)
-	
+
 	// Add flags to module list command
 	moduleListCmd.Flags().StringVarP(&categoryFlag, "category", "c", "", "Filter by category (development, devops, productivity, utilities, cloud, database, networking, security)")
 	moduleListCmd.Flags().StringVarP(&platformFlag, "platform", "p", "", "Filter by platform (darwin, linux, windows)")
-	moduleListCmd.Flags().StringVarP(&shellFlag, "shell", "s", "", "Filter by shell (bash, zsh, fish, powershell)")
-}
\ No newline at end of file
+	moduleListCmd.Flags().StringVarP(&shellFlag, "shell", "s", "", "Filter by shell (bash, zsh, fish, powershell, nu, elvish, xonsh, tcsh, csh, cmd)")
+}
@@ -0,0 +1,85 @@
+package cmd
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// captureStdout returns what fn prints to standard output
+func captureStdout(t *testing.T, fn func()) string {
+	t.Helper()
+
+	reader, writer, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout := os.Stdout
+	os.Stdout = writer
+	defer func() { os.Stdout = stdout }()
+
+	fn()
+
+	writer.Close()
+	output, _ := io.ReadAll(reader)
+	return string(output)
+}
+
+// writeCachedRegistry sets up a registry named test in the cache under home
+// whose index only lists git-helpers, the details are in its module.json
+func writeCachedRegistry(t *testing.T, home string) {
+	t.Helper()
+
+	configDir := filepath.Join(home, ".go-shellify")
+	repoDir := filepath.Join(configDir, "cache", "test")
+	files := map[string]string{
+		filepath.Join(configDir, "registries.json"): `[{"name": "test", "url": "https://example.com/test.git"}]`,
+		filepath.Join(repoDir, "index.json"): `{
+  "name": "test",
+  "modules": {
+    "git-helpers": {"name": "git-helpers", "path": "modules/git-helpers"}
+  }
+}`,
+		filepath.Join(repoDir, "modules", "git-helpers", "module.json"): `{
+  "name": "git-helpers",
+  "description": "Git helper functions",
+  "aliases": [{"name": "gs", "command": "git status -sb"}],
+  "functions": [{"name": "gclean", "commands": ["git clean -n"]}],
+  "environment": [{"name": "GIT_EDITOR", "value": "vim"}]
+}`,
+	}
+	for path, content := range files {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestModuleShowPrintsModuleJSON(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	writeCachedRegistry(t, home)
+
+	output := captureStdout(t, func() {
+		rootCmd.SetArgs([]string{"--offline", "module", "show", "git-helpers"})
+		if err := rootCmd.Execute(); err != nil {
+			t.Errorf("module show failed: %v", err)
+		}
+	})
+
+	for _, expected := range []string{
+		"Description: Git helper functions",
+		"GIT_EDITOR=vim",
+		"gs -> git status -sb",
+		"Functions:\n  gclean",
+	} {
+		if !strings.Contains(output, expected) {
+			t.Errorf("module show output does not contain %q:\n%s", expected, output)
+		}
+	}
+}
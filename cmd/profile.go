@@ -0,0 +1,658 @@
+package cmd
+
+import (
+	stdErrors "errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+
+	"github.com/griffin/go-shellify/internal/errors"
+	"github.com/griffin/go-shellify/internal/generator"
+	"github.com/griffin/go-shellify/internal/lint"
+	"github.com/griffin/go-shellify/internal/logger"
+	"github.com/griffin/go-shellify/internal/module"
+	"github.com/griffin/go-shellify/internal/profile"
+	"github.com/griffin/go-shellify/internal/registry"
+	"github.com/griffin/go-shellify/internal/shell"
+	"github.com/spf13/cobra"
+)
+
+var (
+	// Profile generate flags
+	generateShellFlag string
+
+	// Profile show flags
+	showResolvedFlag bool
+)
+
+// profileCmd represents the profile command
+var profileCmd = &cobra.Command{
+	Use:   "profile",
+	Short: "Manage your shell profile",
+	Long: `Manage the modules enabled in your shell profile and generate the
+shell script that loads them.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		// Show help when no subcommand is provided
+		cmd.Help()
+	},
+}
+
+// profileInitCmd represents the profile init command
+var profileInitCmd = &cobra.Command{
+	Use:   "init",
+	Short: "Create a profile",
+	Long:  `Create a profile configuration with default settings.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if profile.Exists() {
+			path, _ := profile.GetConfigPath()
+			fmt.Printf("Profile already exists at %s\n", path)
+			return nil
+		}
+
+		if err := profile.DefaultConfig().Save(); err != nil {
+			return errors.Wrap(err, errors.ErrTypeConfig, "Failed to create profile")
+		}
+
+		path, _ := profile.GetConfigPath()
+		fmt.Printf("Profile created at %s\n", path)
+		return nil
+	},
+}
+
+// profileShowCmd represents the profile show command
+var profileShowCmd = &cobra.Command{
+	Use:   "show",
+	Short: "Show the profile",
+	Long: `Show the profile settings and enabled modules.
+
+With --resolved, the host overrides matching this machine's hostname, OS and
+$SHELLIFY_ENV tag are applied first, showing the configuration 'profile
+generate' uses here.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		prof, err := profile.Load()
+		if err != nil {
+			return errors.Wrap(err, errors.ErrTypeConfig, "Failed to load profile")
+		}
+
+		if showResolvedFlag {
+			machine := profile.CurrentMachine()
+			resolved, matched := prof.Resolve(machine)
+			fmt.Printf("Machine: %s\n", machine)
+			if len(matched) == 0 {
+				fmt.Println("No host overrides match")
+			}
+			for _, o := range matched {
+				fmt.Printf("Override: %s\n", o)
+			}
+			prof = resolved
+		} else if len(prof.HostOverrides) > 0 {
+			fmt.Printf("Host overrides: %d (see --resolved for this machine)\n", len(prof.HostOverrides))
+		}
+
+		shellType := prof.Shell.Type
+		if shellType == "" {
+			shellType = "auto-detect"
+			if info, err := shell.DetectShell(); err == nil {
+				shellType += fmt.Sprintf(" (%s %s, %s)", info.Type, info.Version, info.Path)
+			}
+		}
+		fmt.Printf("Shell: %s\n", shellType)
+		fmt.Printf("Output: %s\n", filepath.Join(prof.Output.Directory, prof.Output.Filename))
+		fmt.Printf("Integration: %s\n", prof.Generation.IntegrationMode)
+		if len(prof.Modules.Registries) > 0 {
+			fmt.Printf("Registries: %s\n", strings.Join(prof.Modules.Registries, ", "))
+		}
+		if len(prof.Generation.Shells) > 0 {
+			fmt.Printf("Generates for: %s\n", strings.Join(prof.Generation.Shells, ", "))
+		}
+		if len(prof.Modules.Precedence) > 0 {
+			fmt.Printf("Precedence: %s\n", strings.Join(prof.Modules.Precedence, ", "))
+		}
+		if len(prof.Modules.Disabled) > 0 {
+			fmt.Printf("Disabled: %s\n", strings.Join(prof.Modules.Disabled, ", "))
+		}
+		if len(prof.Environment) > 0 {
+			names := make([]string, 0, len(prof.Environment))
+			for name := range prof.Environment {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			fmt.Println("Environment:")
+			for _, name := range names {
+				fmt.Printf("  %s=%s\n", name, prof.Environment[name])
+			}
+		}
+
+		if len(prof.Modules.Enabled) == 0 {
+			fmt.Println("No modules enabled")
+			return nil
+		}
+		fmt.Println("Enabled modules:")
+		for _, name := range prof.Modules.Enabled {
+			fmt.Printf("  - %s\n", name)
+		}
+
+		return nil
+	},
+}
+
+// profileEnableCmd represents the profile enable command
+var profileEnableCmd = &cobra.Command{
+	Use:   "enable <module>...",
+	Short: "Enable modules",
+	Long: `Add modules to the profile. Run 'profile generate' afterwards to update your shell.
+
+Module code is linted for risky patterns first and modules with findings
+are refused unless the rules are allowlisted for them in the configuration.
+Aliases and functions that shadow shell builtins or commands on $PATH are
+reported as warnings.`,
+	Args:  cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		prof, err := loadOrCreateProfile()
+		if err != nil {
+			return err
+		}
+
+		client, err := newRegistryClient()
+		if err != nil {
+			return errors.Wrap(err, errors.ErrTypeConfig, "Failed to create registry client")
+		}
+		refreshStaleRegistries(cmd.Context(), client)
+		service := module.NewService(client)
+
+		for _, name := range args {
+			info, err := service.GetModuleDetails(name)
+			if err != nil {
+				return errors.Wrap(err, errors.ErrTypeNotFound, "Module not found").
+					WithContext("module", name)
+			}
+
+			findings, warnings, err := lintModule(client, info.RegistryName, info.Module)
+			if err != nil {
+				return errors.Wrap(err, errors.ErrTypeModule, "Failed to check module").
+					WithContext("module", name)
+			}
+			if len(findings) > 0 {
+				fmt.Printf("Module '%s' has %d security findings:\n", name, len(findings))
+				printFindings(findings)
+				return errors.New(errors.ErrTypeValidation, "Refusing to enable module, review it and allowlist the rules under security.allow").
+					WithContext("module", name)
+			}
+			printShadowWarnings(warnings)
+
+			prof.AddModule(name)
+			fmt.Printf("Enabled module '%s'\n", name)
+		}
+
+		if err := prof.Save(); err != nil {
+			return errors.Wrap(err, errors.ErrTypeConfig, "Failed to save profile")
+		}
+		return nil
+	},
+}
+
+// profileDisableCmd represents the profile disable command
+var profileDisableCmd = &cobra.Command{
+	Use:   "disable <module>...",
+	Short: "Disable modules",
+	Long:  `Remove modules from the profile. Run 'profile generate' afterwards to update your shell.`,
+	Args:  cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		prof, err := profile.Load()
+		if err != nil {
+			return errors.Wrap(err, errors.ErrTypeConfig, "Failed to load profile")
+		}
+
+		for _, name := range args {
+			if !prof.IsModuleEnabled(name) {
+				fmt.Printf("Module '%s' is not enabled\n", name)
+				continue
+			}
+			prof.RemoveModule(name)
+			fmt.Printf("Disabled module '%s'\n", name)
+		}
+
+		if err := prof.Save(); err != nil {
+			return errors.Wrap(err, errors.ErrTypeConfig, "Failed to save profile")
+		}
+		return nil
+	},
+}
+
+// profileGenerateCmd represents the profile generate command
+var profileGenerateCmd = &cobra.Command{
+	Use:   "generate",
+	Short: "Generate the shell script",
+	Long: `Generate the shell script for the enabled modules from the cached
+registries. In trust mode, modules with unapproved changes are generated
+from their last approved version.
+
+Set generation.shells in the profile to generate for several shells at once,
+or to "all-installed" for every supported shell found in /etc/shells and on
+$PATH. Each shell gets its own script, named after the shell when several
+shells share an extension (go-shellify-bash.sh and go-shellify-zsh.sh), and
+its rc file loads that script.
+
+Host overrides in the profile matching this machine's hostname, OS or
+$SHELLIFY_ENV tag enable or disable modules and replace environment values
+first, see 'profile show --resolved'.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		prof, err := profile.Load()
+		if err != nil {
+			return errors.Wrap(err, errors.ErrTypeConfig, "Failed to load profile")
+		}
+		prof = resolveProfile(prof)
+
+		shells, err := generationShells(prof)
+		if err != nil {
+			return errors.Wrap(err, errors.ErrTypeSystem, "Failed to determine shell")
+		}
+
+		client, err := newRegistryClient()
+		if err != nil {
+			return errors.Wrap(err, errors.ErrTypeConfig, "Failed to create registry client")
+		}
+		refreshStaleRegistries(cmd.Context(), client)
+
+		// Shells sharing a script extension, like bash and zsh, get the shell
+		// in their file name
+		extensions := make(map[string]int)
+		for _, shellType := range shells {
+			extensions[shell.GetFileExtension(shellType)]++
+		}
+
+		for _, shellType := range shells {
+			base := prof.Output.Filename
+			if extensions[shell.GetFileExtension(shellType)] > 1 {
+				base += "-" + shellType
+			}
+			if err := generateForShell(client, prof, shellType, base); err != nil {
+				return err
+			}
+		}
+		return nil
+	},
+}
+
+// generationShells returns the shells to generate for: the --shell flag,
+// generation.shells from the profile, or the profile's single shell
+func generationShells(prof *profile.ProfileConfig) ([]string, error) {
+	if generateShellFlag != "" || len(prof.Generation.Shells) == 0 {
+		shellType, err := profileShell(prof)
+		if err != nil {
+			return nil, err
+		}
+		return []string{shellType}, nil
+	}
+
+	if prof.Generation.Shells[0] != profile.AllInstalled {
+		return prof.Generation.Shells, nil
+	}
+
+	var shells []string
+	for _, info := range shell.Installed() {
+		logger.Debug("Found %s at %s", info.Type, info.Path)
+		shells = append(shells, string(info.Type))
+	}
+	if len(shells) == 0 {
+		return nil, fmt.Errorf("no supported shells installed")
+	}
+	return shells, nil
+}
+
+// generateForShell generates the script of one shell as base plus the
+// shell's extension, records its lockfile and loads it from the shell's rc file
+func generateForShell(client *registry.Client, prof *profile.ProfileConfig, shellType, base string) error {
+	gen, err := generator.New(shellType)
+	if err != nil {
+		return errors.Wrap(err, errors.ErrTypeValidation, "Unsupported shell").
+			WithContext("shell", shellType)
+	}
+
+	modules, err := resolveEnabledModules(client, prof, gen)
+	if err != nil {
+		return errors.Wrap(err, errors.ErrTypeModule, "Failed to resolve enabled modules").
+			WithContext("shell", shellType)
+	}
+	modules, collisions := gen.ResolveCollisions(modules, profilePrecedence(prof))
+	for _, c := range collisions {
+		if !c.Chosen {
+			logger.Warn("%s (pick one under modules.precedence or modules.overrides)", describeCollision(c))
+		}
+	}
+	modules, unused := generator.OverrideEnvironment(modules, prof.Environment)
+	for _, name := range unused {
+		logger.Warn("No enabled module sets %s, ignoring its value from the profile", name)
+	}
+
+	script, err := gen.Generate(modules)
+	if err != nil {
+		return errors.Wrap(err, errors.ErrTypeModule, "Failed to generate script").
+			WithContext("shell", shellType)
+	}
+
+	outputPath := filepath.Join(prof.Output.Directory, gen.FileName(base))
+	lockPath := generator.LockfilePath(outputPath)
+	previous, err := generator.LoadLockfile(lockPath)
+	if err != nil {
+		logger.Warn("Ignoring unreadable lockfile: %v", err)
+	}
+	for _, mod := range modules {
+		if previous.Find(mod.Registry, mod.Name).ChangedWithoutVersion(mod) {
+			logger.Warn("Module '%s' %s changed since the last generate without a version change", mod.Name, mod.Version)
+		}
+	}
+
+	hasCode := gen.HasCode(script)
+	if !hasCode {
+		if _, err := os.Stat(outputPath); err != nil {
+			fmt.Printf("Nothing to generate for %s: no enabled module has code it can run, not writing %s or loading it from the shell configuration\n", shellType, outputPath)
+			return nil
+		}
+		// An existing script would keep loading modules that no longer apply
+		fmt.Printf("Nothing to generate for %s: no enabled module has code it can run, emptying %s\n", shellType, outputPath)
+	}
+
+	if err := generator.WriteScript(outputPath, script, prof.Generation.BackupExisting); err != nil {
+		return errors.Wrap(err, errors.ErrTypeSystem, "Failed to write script")
+	}
+	if err := gen.NewLockfile(modules).Save(lockPath); err != nil {
+		return errors.Wrap(err, errors.ErrTypeSystem, "Failed to write lockfile")
+	}
+	if !hasCode {
+		return nil
+	}
+	fmt.Printf("Generated %s with %d modules\n", outputPath, len(modules))
+
+	return integrateScript(gen, prof, shellType, outputPath)
+}
+
+// profileCollisionsCmd represents the profile collisions command
+var profileCollisionsCmd = &cobra.Command{
+	Use:   "collisions",
+	Short: "Show names defined by several enabled modules",
+	Long: `List the aliases, functions and environment variables that more than one
+enabled module defines, with the module whose definition is generated.
+
+By default the module generated last wins. Pick a winner for every name with
+modules.precedence in the profile, a list of module names with the highest
+precedence first, or per name with modules.overrides:
+
+  "overrides": {
+    "aliases": {"k": "kubectl-helpers"},
+    "environment": {"EDITOR": "editors"}
+  }`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		prof, err := profile.Load()
+		if err != nil {
+			return errors.Wrap(err, errors.ErrTypeConfig, "Failed to load profile")
+		}
+		prof = resolveProfile(prof)
+
+		shellType, err := profileShell(prof)
+		if err != nil {
+			return errors.Wrap(err, errors.ErrTypeSystem, "Failed to determine shell")
+		}
+
+		gen, err := generator.New(shellType)
+		if err != nil {
+			return errors.Wrap(err, errors.ErrTypeValidation, "Unsupported shell").
+				WithContext("shell", shellType)
+		}
+
+		client, err := newRegistryClient()
+		if err != nil {
+			return errors.Wrap(err, errors.ErrTypeConfig, "Failed to create registry client")
+		}
+
+		modules, err := resolveEnabledModules(client, prof, gen)
+		if err != nil {
+			return errors.Wrap(err, errors.ErrTypeModule, "Failed to resolve enabled modules")
+		}
+
+		_, collisions := gen.ResolveCollisions(modules, profilePrecedence(prof))
+		if len(collisions) == 0 {
+			fmt.Println("No collisions")
+			return nil
+		}
+		fmt.Printf("%d collisions:\n", len(collisions))
+		for _, c := range collisions {
+			fmt.Printf("  - %s\n", describeCollision(c))
+		}
+		return nil
+	},
+}
+
+// loadOrCreateProfile loads the profile, starting from the defaults when there is none yet
+func loadOrCreateProfile() (*profile.ProfileConfig, error) {
+	if !profile.Exists() {
+		return profile.DefaultConfig(), nil
+	}
+
+	prof, err := profile.Load()
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrTypeConfig, "Failed to load profile")
+	}
+	return prof, nil
+}
+
+// resolveProfile applies the host overrides matching this machine
+func resolveProfile(prof *profile.ProfileConfig) *profile.ProfileConfig {
+	machine := profile.CurrentMachine()
+	resolved, matched := prof.Resolve(machine)
+	for _, o := range matched {
+		logger.Debug("Applying host override for %s on %s", o, machine)
+	}
+	return resolved
+}
+
+// profileShell returns the shell to generate for
+func profileShell(prof *profile.ProfileConfig) (string, error) {
+	if generateShellFlag != "" {
+		return generateShellFlag, nil
+	}
+	if prof.Shell.Type != "" {
+		return prof.Shell.Type, nil
+	}
+
+	info, err := shell.DetectShell()
+	if err != nil {
+		return "", err
+	}
+	logger.Debug("Detected %s %s at %s", info.Type, info.Version, info.Path)
+	return string(info.Type), nil
+}
+
+// resolveEnabledModules loads the definitions of the enabled modules that
+// should be generated, using approved snapshots in trust mode
+func resolveEnabledModules(client *registry.Client, prof *profile.ProfileConfig, gen *generator.Generator) ([]generator.Module, error) {
+	available, err := module.NewService(client).ListAllModules()
+	if err != nil {
+		return nil, err
+	}
+
+	var modules []generator.Module
+	seen := make(map[string]bool)
+	version, versionChecked := "", false
+	for _, info := range available {
+		if seen[info.Name] || !prof.IsModuleEnabled(info.Name) {
+			continue
+		}
+		if len(prof.Modules.Registries) > 0 && !containsString(prof.Modules.Registries, info.RegistryName) {
+			continue
+		}
+		seen[info.Name] = true
+
+		mod, pending, err := client.ResolveModule(info.RegistryName, info.Key, info.Module)
+		if err != nil {
+			return nil, err
+		}
+		if pending {
+			logger.Warn("Module '%s' has unapproved changes, using the last approved version (see 'go-shellify registry approve %s')", info.Name, info.RegistryName)
+		}
+		if !gen.Supports(mod) {
+			logger.Warn("Skipping module '%s': not available for this shell", info.Name)
+			continue
+		}
+		applicable, ok, err := gen.Applicable(mod)
+		if err != nil {
+			logger.Warn("Skipping module '%s': %v", info.Name, err)
+			continue
+		}
+		if !ok {
+			logger.Info("Skipping module '%s': its platforms or when condition don't match this machine", info.Name)
+			continue
+		}
+		mod = applicable
+		if reasons := gen.Unsupported(mod); len(reasons) > 0 {
+			for _, reason := range reasons {
+				logger.Warn("%s: %s", info.Name, reason)
+			}
+			logger.Warn("Skipping module '%s': it uses features %s doesn't support", info.Name, gen.Shell())
+			continue
+		}
+		if len(mod.ShellVersions) > 0 {
+			if !versionChecked {
+				version, versionChecked = shell.Version(gen.Shell()), true
+			}
+			if err := registry.CheckShellVersion(mod, gen.Shell(), version); err != nil {
+				if !stdErrors.Is(err, registry.ErrShellVersionUnknown) {
+					logger.Warn("Skipping module '%s': %v", info.Name, err)
+					continue
+				}
+				logger.Warn("Could not check the %s version: %v", gen.Shell(), err)
+			}
+		}
+		if findings := lint.CheckSyntax(mod, gen.Shell()); len(findings) > 0 {
+			for _, f := range findings {
+				logger.Warn("%s", f)
+			}
+			logger.Warn("Skipping module '%s': its code doesn't parse in %s", info.Name, gen.Shell())
+			continue
+		}
+
+		// Catch edits to the cache since the registry was validated
+		repoPath := client.RepositoryPath(info.RegistryName)
+		if err := registry.VerifyModuleChecksum(repoPath, info.Module); err != nil {
+			return nil, err
+		}
+		dir := filepath.Join(repoPath, mod.Path)
+		checksum := ""
+		if !pending {
+			// An approved snapshot isn't what the cache holds, so only
+			// modules generated from the cache record its checksum
+			if checksum, err = registry.ModuleChecksum(dir); err != nil {
+				return nil, err
+			}
+		}
+
+		modules = append(modules, generator.Module{
+			Module:   mod,
+			Registry: info.RegistryName,
+			Dir:      dir,
+			Checksum: checksum,
+		})
+	}
+
+	for _, name := range prof.Modules.Enabled {
+		if name != "*" && !seen[name] {
+			logger.Warn("Enabled module '%s' was not found in any registry", name)
+		}
+	}
+
+	return modules, nil
+}
+
+// profilePrecedence returns the collision choices made in the profile
+func profilePrecedence(prof *profile.ProfileConfig) generator.Precedence {
+	return generator.Precedence{
+		Order:       prof.Modules.Precedence,
+		Aliases:     prof.Modules.Overrides.Aliases,
+		Functions:   prof.Modules.Overrides.Functions,
+		Environment: prof.Modules.Overrides.Environment,
+	}
+}
+
+// describeCollision formats a collision with its sources and winner
+func describeCollision(c generator.Collision) string {
+	sources := make([]string, len(c.Sources))
+	for i, source := range c.Sources {
+		sources[i] = fmt.Sprintf("%s (%s)", source.Module, source.Registry)
+	}
+
+	reason := "generated last"
+	if c.Chosen {
+		reason = "chosen in profile"
+	}
+	return fmt.Sprintf("%s '%s' is defined by %s, using %s, %s",
+		c.Kind, c.Name, strings.Join(sources, ", "), c.Sources[c.Winner].Module, reason)
+}
+
+// integrateScript makes the shell load the generated script in source mode,
+// or prints the line to add in manual mode
+func integrateScript(gen *generator.Generator, prof *profile.ProfileConfig, shellType, scriptPath string) error {
+	if shell.ShellType(shellType) == shell.Cmd {
+		printAutoRun(scriptPath)
+		return nil
+	}
+
+	line := gen.SourceLine(scriptPath)
+
+	rcPath, err := shell.GetConfigPath(shellType)
+	if err != nil || prof.Generation.IntegrationMode == "manual" {
+		fmt.Printf("Load it from your %s configuration with:\n  %s", shellType, line)
+		return nil
+	}
+
+	added, err := generator.EnsureSourced(rcPath, line)
+	if err != nil {
+		return errors.Wrap(err, errors.ErrTypeSystem, "Failed to update shell configuration").
+			WithContext("path", rcPath)
+	}
+	if added {
+		fmt.Printf("Added the script to %s\n", rcPath)
+	}
+	return nil
+}
+
+// printAutoRun prints how to make cmd.exe run the generated script. cmd.exe
+// has no rc file, it runs the AutoRun registry value instead.
+func printAutoRun(scriptPath string) {
+	if runtime.GOOS != "windows" {
+		scriptPath = `%USERPROFILE%\` + profile.ConfigDir + `\generated\` + filepath.Base(scriptPath)
+		fmt.Printf("Copy the script to %s on the Windows machine.\n", scriptPath)
+	}
+	fmt.Printf("Run it in every cmd.exe session by setting the AutoRun registry value:\n  %s\n", generator.CmdAutoRun(scriptPath))
+	fmt.Println("This replaces an existing AutoRun value, check it first with:")
+	fmt.Println(`  reg query "HKCU\Software\Microsoft\Command Processor" /v AutoRun`)
+	fmt.Println("and join both commands with & to keep it. doskey macros only work at the interactive prompt, not in batch files.")
+}
+
+// containsString checks if a list contains a value
+func containsString(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
+
+func init() {
+	rootCmd.AddCommand(profileCmd)
+
+	// Add subcommands to profile
+	profileCmd.AddCommand(profileInitCmd)
+	profileCmd.AddCommand(profileShowCmd)
+	profileCmd.AddCommand(profileEnableCmd)
+	profileCmd.AddCommand(profileDisableCmd)
+	profileCmd.AddCommand(profileGenerateCmd)
+	profileCmd.AddCommand(profileCollisionsCmd)
+
+	profileGenerateCmd.Flags().StringVarP(&generateShellFlag, "shell", "s", "", "Shell to generate for (bash, zsh, fish, powershell, nu, elvish, xonsh, tcsh, csh, cmd), default from profile or detected")
+	profileShowCmd.Flags().BoolVar(&showResolvedFlag, "resolved", false, "Show the configuration with the host overrides matching this machine applied")
+	profileCollisionsCmd.Flags().StringVarP(&generateShellFlag, "shell", "s", "", "Shell to check for (bash, zsh, fish, powershell, nu, elvish, xonsh, tcsh, csh, cmd), default from profile or detected")
+}
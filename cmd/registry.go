@@ -1,17 +1,45 @@
 package cmd
 
 import (
+	"crypto/ed25519"
+	stdErrors "errors"
 	"fmt"
 	"net/url"
 	"path/filepath"
 	"strings"
 
+	"github.com/griffin/go-shellify/internal/config"
 	"github.com/griffin/go-shellify/internal/errors"
 	"github.com/griffin/go-shellify/internal/logger"
+	"github.com/griffin/go-shellify/internal/profile"
 	"github.com/griffin/go-shellify/internal/registry"
 	"github.com/spf13/cobra"
 )
 
+var (
+	// Registry add flags
+	refFlag         string
+	trustedKeysFlag []string
+
+	// Registry sign flags
+	signKeyFlag      string
+	signGenerateFlag bool
+
+	// Registry changes flags
+	allChangesFlag bool
+
+	// Registry approve flags
+	approveYesFlag bool
+
+	// Registry authentication flags
+	tokenEnvFlag         string
+	tokenFileFlag        string
+	usernameFlag         string
+	netrcFlag            bool
+	credentialHelperFlag string
+	sshKeyFlag           string
+)
+
 // registryCmd represents the registry command
 var registryCmd = &cobra.Command{
 	Use:   "registry",
@@ -34,10 +62,22 @@ var registryAddCmd = &cobra.Command{
 
 The URL will be validated to ensure it points to a valid and accessible git repository.
 If no name is provided, one will be generated from the repository URL.
+Use --ref to track a specific branch or tag instead of the default branch;
+it is checked against the refs advertised by the remote before cloning.
+
+Pin the registry owner's ed25519 public key with --trusted-key to require
+every sync to be signed with it (see 'registry sign').
+
+Private registries can authenticate with a token read from an environment
+variable or file, ~/.netrc, a git credential helper, or a specific SSH key.
+Only the reference to the credential is stored, never the secret itself.
 
 Examples:
   go-shellify registry add https://github.com/user/shellify-registry
   go-shellify registry add https://github.com/user/registry my-registry
+  go-shellify registry add https://github.com/user/registry --ref v1.2.0
+  go-shellify registry add https://github.com/user/registry --trusted-key <base64 key>
+  go-shellify registry add https://github.com/team/private --token-env GITHUB_TOKEN
   go-shellify registry add git@github.com:user/registry.git`,
 	Args: cobra.RangeArgs(1, 2),
 	RunE: func(cmd *cobra.Command, args []string) error {
@@ -51,22 +91,48 @@ Examples:
 			name = generateRegistryName(url)
 		}
 		
-		logger.Info("Adding registry: %s (name: %s)", url, name)
+		logger.Info("Adding registry: %s (name: %s)", registry.RedactURL(url), name)
+		
+		auth, err := authFromFlags()
+		if err != nil {
+			return errors.Wrap(err, errors.ErrTypeValidation, "Invalid registry authentication").
+				WithContext("name", name)
+		}
 		
 		// Validate URL format and accessibility
 		logger.Debug("Validating registry URL...")
-		validator := registry.NewURLValidator()
-		if err := validator.ValidateURL(url); err != nil {
+		validator, err := newURLValidator()
+		if err != nil {
+			return errors.Wrap(err, errors.ErrTypeConfig, "Invalid network configuration")
+		}
+		validator.SetAuth(auth)
+		refs, err := validator.ValidateRemote(cmd.Context(), url)
+		if err != nil {
 			logger.Error("URL validation failed: %v", err)
 			return errors.Wrap(err, errors.ErrTypeValidation, "Invalid registry URL").
 				WithContext("url", url).
 				WithContext("name", name)
 		}
-		logger.Debug("URL validation passed")
+		logger.Debug("URL validation passed (%d refs, HEAD -> %s)", len(refs.Refs), refs.Head)
+		
+		if _, err := registry.ParsePublicKeys(trustedKeysFlag); err != nil {
+			return errors.Wrap(err, errors.ErrTypeValidation, "Invalid trusted key").
+				WithContext("name", name)
+		}
+		
+		// Validate the requested ref up front rather than failing during clone
+		if refFlag != "" {
+			if err := validator.ValidateRef(refs, refFlag); err != nil {
+				logger.Error("Ref validation failed: %v", err)
+				return errors.Wrap(err, errors.ErrTypeValidation, "Invalid registry ref").
+					WithContext("url", url).
+					WithContext("ref", refFlag)
+			}
+		}
 		
 		// Create registry client and add registry
 		logger.Debug("Creating registry client and cloning repository...")
-		client, err := registry.NewClient()
+		client, err := newRegistryClient()
 		if err != nil {
 			logger.Error("Failed to create registry client: %v", err)
 			return errors.Wrap(err, errors.ErrTypeConfig, "Failed to create registry client").
@@ -74,7 +140,14 @@ Examples:
 				WithContext("name", name)
 		}
 		
-		if err := client.AddRegistry(url, name); err != nil {
+		reg := registry.Registry{
+			URL:         url,
+			Name:        name,
+			Ref:         refFlag,
+			Auth:        auth,
+			TrustedKeys: trustedKeysFlag,
+		}
+		if err := client.AddRegistry(cmd.Context(), reg); err != nil {
 			logger.Error("Failed to add registry: %v", err)
 			return errors.Wrap(err, errors.ErrTypeConfig, "Failed to add registry").
 				WithContext("url", url).
@@ -84,6 +157,15 @@ Examples:
 		logger.Info("Registry '%s' added successfully", name)
 		fmt.Printf("Registry '%s' has been added successfully.\n", name)
 		fmt.Printf("URL: %s\n", url)
+		if refFlag != "" {
+			fmt.Printf("Ref: %s\n", refFlag)
+		}
+		if auth != nil {
+			fmt.Printf("Auth: %s\n", auth.Describe())
+		}
+		if len(trustedKeysFlag) > 0 {
+			fmt.Printf("Signature verified with %d trusted keys\n", len(trustedKeysFlag))
+		}
 		
 		return nil
 	},
@@ -97,7 +179,7 @@ var registryListCmd = &cobra.Command{
 	RunE: func(cmd *cobra.Command, args []string) error {
 		logger.Debug("Listing configured registries")
 		
-		client, err := registry.NewClient()
+		client, err := newRegistryClient()
 		if err != nil {
 			return errors.Wrap(err, errors.ErrTypeConfig, "Failed to create registry client")
 		}
@@ -113,11 +195,23 @@ var registryListCmd = &cobra.Command{
 		fmt.Println("Configured registries:")
 		for _, reg := range registries {
 			fmt.Printf("  - %s (%s)\n", reg.Name, reg.URL)
+			if reg.Ref != "" {
+				fmt.Printf("    Ref: %s\n", reg.Ref)
+			}
+			if !reg.Auth.IsZero() {
+				fmt.Printf("    Auth: %s\n", reg.Auth.Describe())
+			}
+			if len(reg.TrustedKeys) > 0 {
+				fmt.Printf("    Trusted keys: %d\n", len(reg.TrustedKeys))
+			}
 			if reg.LastSync.IsZero() {
 				fmt.Println("    Never synced")
 			} else {
 				fmt.Printf("    Last synced: %s\n", reg.LastSync.Format("2006-01-02 15:04:05"))
 			}
+			if reg.IsStale(ConfigManager.Get().Sync.TTLDuration()) {
+				fmt.Printf("    Stale: %s since last sync\n", formatAge(reg.CacheAge()))
+			}
 		}
 		
 		return nil
@@ -135,7 +229,7 @@ var registryRemoveCmd = &cobra.Command{
 		
 		logger.Info("Removing registry: %s", identifier)
 		
-		client, err := registry.NewClient()
+		client, err := newRegistryClient()
 		if err != nil {
 			logger.Error("Failed to create registry client: %v", err)
 			return errors.Wrap(err, errors.ErrTypeConfig, "Failed to create registry client").
@@ -155,21 +249,199 @@ var registryRemoveCmd = &cobra.Command{
 	},
 }
 
+// registrySyncCmd represents the registry sync command
+var registrySyncCmd = &cobra.Command{
+	Use:   "sync [name...]",
+	Short: "Sync registries",
+	Long: `Pull the latest changes for the named registries, or all registries.
+
+Registries are also synced automatically when they are older than the
+configured sync TTL and a command reads their modules.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		client, err := newRegistryClient()
+		if err != nil {
+			return errors.Wrap(err, errors.ErrTypeConfig, "Failed to create registry client")
+		}
+		
+		names := args
+		if len(names) == 0 {
+			for _, reg := range client.ListRegistries() {
+				names = append(names, reg.Name)
+			}
+		}
+		
+		release, err := client.LockSync()
+		if err != nil {
+			return errors.Wrap(err, errors.ErrTypeRegistry, "Failed to sync registries")
+		}
+		defer release()
+		
+		var failed []string
+		for _, name := range names {
+			logger.Info("Syncing registry '%s'", name)
+			if err := client.SyncRegistry(cmd.Context(), name); err != nil {
+				if stdErrors.Is(err, registry.ErrNetworkUnavailable) {
+					logger.Warn("Network unavailable, keeping cached copy of registry '%s'", name)
+				} else {
+					logger.Error("Failed to sync registry '%s': %v", name, err)
+				}
+				failed = append(failed, name)
+				continue
+			}
+			fmt.Printf("Registry '%s' synced.\n", name)
+			
+			if pending, err := client.PendingApprovals(name); err == nil && len(pending) > 0 {
+				fmt.Printf("  %d enabled modules have changes awaiting approval, run 'go-shellify registry approve %s'\n", len(pending), name)
+			}
+		}
+		
+		if len(failed) > 0 {
+			return errors.New(errors.ErrTypeRegistry, "Failed to sync registries").
+				WithContext("registries", strings.Join(failed, ", "))
+		}
+		
+		return nil
+	},
+}
+
+// registryChangesCmd represents the registry changes command
+var registryChangesCmd = &cobra.Command{
+	Use:   "changes <name>",
+	Short: "Show what changed in recent syncs",
+	Long: `Show the modules that were added, removed or updated by the most recent
+sync of a registry. Changed alias commands and function bodies are shown for
+modules enabled in your profile.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		name := args[0]
+		
+		client, err := newRegistryClient()
+		if err != nil {
+			return errors.Wrap(err, errors.ErrTypeConfig, "Failed to create registry client")
+		}
+		
+		changeSets, err := client.RegistryChanges(name)
+		if err != nil {
+			return errors.Wrap(err, errors.ErrTypeRegistry, "Failed to read registry changes").
+				WithContext("name", name)
+		}
+		
+		if len(changeSets) == 0 {
+			fmt.Printf("No changes recorded for registry '%s'\n", name)
+			return nil
+		}
+		if !allChangesFlag {
+			changeSets = changeSets[len(changeSets)-1:]
+		}
+		
+		prof, err := profile.Load()
+		if err != nil {
+			logger.Debug("No profile loaded, not showing module contents: %v", err)
+		}
+		
+		for i := len(changeSets) - 1; i >= 0; i-- {
+			printChangeSet(changeSets[i], prof)
+		}
+		
+		return nil
+	},
+}
+
+// registryApproveCmd represents the registry approve command
+var registryApproveCmd = &cobra.Command{
+	Use:   "approve <name> [module...]",
+	Short: "Review and approve held back module changes",
+	Long: `In trust mode, changes to the functions, aliases, files and checks of
+enabled modules are held back after a sync until they are approved, and
+'profile generate' keeps using the last approved version.
+
+This command shows the pending changes of a registry, or only of the named
+modules, and asks for confirmation before approving them.`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		name := args[0]
+		modules := args[1:]
+		
+		if !ConfigManager.Get().Trust.Enabled {
+			fmt.Println("Trust mode is disabled, module changes are used without approval")
+			return nil
+		}
+		
+		client, err := newRegistryClient()
+		if err != nil {
+			return errors.Wrap(err, errors.ErrTypeConfig, "Failed to create registry client")
+		}
+		
+		pending, err := client.PendingApprovals(name)
+		if err != nil {
+			return errors.Wrap(err, errors.ErrTypeRegistry, "Failed to determine pending changes").
+				WithContext("name", name)
+		}
+		if len(modules) > 0 {
+			var selected []registry.PendingApproval
+			for _, p := range pending {
+				if containsString(modules, p.Module) {
+					selected = append(selected, p)
+				}
+			}
+			pending = selected
+		}
+		
+		if len(pending) == 0 {
+			fmt.Printf("No changes awaiting approval in registry '%s'\n", name)
+			return nil
+		}
+		
+		for _, p := range pending {
+			printPendingApproval(p)
+		}
+		
+		if !approveYesFlag && !confirm(cmd, "Approve these changes?") {
+			fmt.Println("Changes not approved")
+			return nil
+		}
+		
+		approved := make([]string, 0, len(pending))
+		for _, p := range pending {
+			approved = append(approved, p.Module)
+		}
+		if err := client.Approve(name, pending); err != nil {
+			return errors.Wrap(err, errors.ErrTypeRegistry, "Failed to approve changes").
+				WithContext("name", name)
+		}
+		
+		fmt.Printf("Approved changes to %s. Run 'go-shellify profile generate' to use them.\n", strings.Join(approved, ", "))
+		return nil
+	},
+}
+
 // registryValidateCmd represents the registry validate command
 var registryValidateCmd = &cobra.Command{
 	Use:   "validate <url>",
 	Short: "Validate a registry structure",
-	Long:  `Validate that a git repository is a valid shellify registry by cloning it temporarily and checking its structure.`,
+	Long: `Validate that a git repository is a valid shellify registry by cloning it
+temporarily, checking its structure and linting the module code for risky
+patterns such as piping downloads to a shell and for syntax errors.`,
 	Args:  cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		url := args[0]
 		
-		logger.Info("Validating registry structure: %s", url)
+		logger.Info("Validating registry structure: %s", registry.RedactURL(url))
+		
+		auth, err := authFromFlags()
+		if err != nil {
+			return errors.Wrap(err, errors.ErrTypeValidation, "Invalid registry authentication").
+				WithContext("url", url)
+		}
 		
 		// Validate URL format first
 		logger.Debug("Validating registry URL format...")
-		validator := registry.NewURLValidator()
-		if err := validator.ValidateURL(url); err != nil {
+		validator, err := newURLValidator()
+		if err != nil {
+			return errors.Wrap(err, errors.ErrTypeConfig, "Invalid network configuration")
+		}
+		validator.SetAuth(auth)
+		if err := validator.ValidateURL(cmd.Context(), url); err != nil {
 			logger.Error("URL validation failed: %v", err)
 			return errors.Wrap(err, errors.ErrTypeValidation, "Invalid registry URL").
 				WithContext("url", url)
@@ -181,7 +453,7 @@ var registryValidateCmd = &cobra.Command{
 		
 		// Create registry client and clone temporarily
 		logger.Debug("Cloning repository for validation...")
-		client, err := registry.NewClient()
+		client, err := newRegistryClient()
 		if err != nil {
 			logger.Error("Failed to create registry client: %v", err)
 			return errors.Wrap(err, errors.ErrTypeConfig, "Failed to create registry client").
@@ -189,17 +461,33 @@ var registryValidateCmd = &cobra.Command{
 		}
 		
 		// Clone and validate (this will be cleaned up if validation fails)
-		if err := client.AddRegistry(url, tempName); err != nil {
+		reg := registry.Registry{URL: url, Name: tempName, Auth: auth, TrustedKeys: trustedKeysFlag}
+		if err := client.AddRegistry(cmd.Context(), reg); err != nil {
 			logger.Error("Registry validation failed: %v", err)
 			fmt.Printf("❌ Registry validation failed: %v\n", err)
 			return nil // Don't return error since we provided user feedback
 		}
 		
+		// Lint the module code before the temporary registry is removed
+		findings, warnings, lintErr := lintRegistry(client.RepositoryPath(tempName))
+		
 		// Validation passed, clean up the temporary registry
 		if err := client.RemoveRegistry(tempName); err != nil {
 			logger.Warn("Failed to clean up temporary registry: %v", err)
 		}
 		
+		if lintErr != nil {
+			fmt.Printf("❌ Registry validation failed: %v\n", lintErr)
+			return nil
+		}
+		printShadowWarnings(warnings)
+		if len(findings) > 0 {
+			fmt.Printf("❌ Registry '%s' has %d findings:\n", url, len(findings))
+			printFindings(findings)
+			fmt.Println("Allowlist reviewed security findings per rule under security.allow in the configuration.")
+			return nil
+		}
+		
 		logger.Info("Registry validation completed successfully")
 		fmt.Printf("✅ Registry '%s' is valid and follows shellify registry structure.\n", url)
 		
@@ -207,6 +495,331 @@ var registryValidateCmd = &cobra.Command{
 	},
 }
 
+// registryChecksumCmd represents the registry checksum command
+var registryChecksumCmd = &cobra.Command{
+	Use:   "checksum [dir]",
+	Short: "Record module checksums in a registry index",
+	Long: `Compute the sha256 of every module directory in a registry checkout
+(default the current directory) and record it in index.json. Modules with a
+checksum are verified on every sync and before generating a script, so an
+edited or corrupted module is refused. Run it before 'registry sign'.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		dir := "."
+		if len(args) > 0 {
+			dir = args[0]
+		}
+		
+		updated, err := registry.UpdateIndexChecksums(dir)
+		if err != nil {
+			return errors.Wrap(err, errors.ErrTypeRegistry, "Failed to update module checksums").
+				WithContext("dir", dir)
+		}
+		
+		fmt.Printf("Updated %d module checksums in %s\n", updated, filepath.Join(dir, "index.json"))
+		return nil
+	},
+}
+
+// registryLintCmd represents the registry lint command
+var registryLintCmd = &cobra.Command{
+	Use:   "lint [dir]",
+	Short: "Check a registry checkout before publishing",
+	Long: `Validate the structure of a local registry checkout (default the current
+directory) and check the functions and aliases of every module for syntax
+errors in the shells the module declares, and for risky patterns.
+
+bash, zsh and sh code is parsed in process. fish and PowerShell code is
+checked with 'fish -n' and the PowerShell parser when they are installed.
+
+Aliases and functions named like a shell builtin, keyword or a command on
+$PATH are reported as warnings unless they set "shadows": true.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		dir := "."
+		if len(args) > 0 {
+			dir = args[0]
+		}
+		
+		if err := registry.NewStructureValidator(dir).ValidateStructure(); err != nil {
+			return errors.Wrap(err, errors.ErrTypeValidation, "Invalid registry structure").
+				WithContext("dir", dir)
+		}
+		
+		findings, warnings, err := lintRegistry(dir)
+		if err != nil {
+			return errors.Wrap(err, errors.ErrTypeRegistry, "Failed to lint registry").
+				WithContext("dir", dir)
+		}
+		printShadowWarnings(warnings)
+		if len(findings) > 0 {
+			fmt.Printf("%d findings:\n", len(findings))
+			printFindings(findings)
+			return errors.New(errors.ErrTypeValidation, "Registry has lint findings").
+				WithContext("dir", dir)
+		}
+		
+		fmt.Println("No findings")
+		return nil
+	},
+}
+
+// registrySignCmd represents the registry sign command
+var registrySignCmd = &cobra.Command{
+	Use:   "sign [dir]",
+	Short: "Sign a registry for publishing",
+	Long: `Write a manifest of the sha256 hashes of every file in a registry
+checkout (default the current directory) and a detached ed25519 signature
+over it. Commit both files alongside the registry content.
+
+Users pin the public key with 'registry add --trusted-key' and every sync
+is refused unless the content matches a manifest signed with the key.
+Re-run this command after every change to the registry.
+
+Examples:
+  go-shellify registry sign --key ~/.go-shellify/registry.key
+  go-shellify registry sign ./my-registry --key registry.key --generate`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		dir := "."
+		if len(args) > 0 {
+			dir = args[0]
+		}
+		
+		if signKeyFlag == "" {
+			return errors.New(errors.ErrTypeValidation, "A signing key is required").
+				WithContext("flag", "--key")
+		}
+		
+		if err := registry.NewStructureValidator(dir).ValidateStructure(); err != nil {
+			return errors.Wrap(err, errors.ErrTypeValidation, "Refusing to sign an invalid registry").
+				WithContext("dir", dir)
+		}
+		
+		if signGenerateFlag {
+			publicKey, err := registry.GenerateSigningKey(signKeyFlag)
+			if err != nil {
+				return errors.Wrap(err, errors.ErrTypeSystem, "Failed to generate signing key").
+					WithContext("path", signKeyFlag)
+			}
+			fmt.Printf("Generated signing key %s\n", signKeyFlag)
+			fmt.Printf("Public key: %s\n", publicKey)
+		}
+		
+		key, err := registry.LoadSigningKey(signKeyFlag)
+		if err != nil {
+			return errors.Wrap(err, errors.ErrTypeValidation, "Failed to load signing key").
+				WithContext("path", signKeyFlag)
+		}
+		
+		if err := registry.SignRegistry(dir, key); err != nil {
+			return errors.Wrap(err, errors.ErrTypeSystem, "Failed to sign registry").
+				WithContext("dir", dir)
+		}
+		
+		fmt.Printf("Signed %s with key %s\n", dir, registry.EncodePublicKey(key.Public().(ed25519.PublicKey)))
+		fmt.Printf("Commit %s and %s to publish the signature.\n", registry.ManifestFile, registry.SignatureFile)
+		return nil
+	},
+}
+
+// networkConfig returns the configured network settings with proxy
+// environment variables applied as defaults
+func networkConfig() (config.NetworkConfig, error) {
+	network := ConfigManager.Get().Network.WithEnvironmentDefaults()
+	if err := network.Validate(); err != nil {
+		return network, err
+	}
+	if err := ConfigManager.Get().Timeouts.Validate(); err != nil {
+		return network, err
+	}
+	if err := ConfigManager.Get().Retry.Validate(); err != nil {
+		return network, err
+	}
+	if err := ConfigManager.Get().Offline.Validate(); err != nil {
+		return network, err
+	}
+	if err := ConfigManager.Get().Sync.Validate(); err != nil {
+		return network, err
+	}
+	return network, nil
+}
+
+// newRegistryClient creates a registry client using the global configuration
+func newRegistryClient() (*registry.Client, error) {
+	network, err := networkConfig()
+	if err != nil {
+		return nil, err
+	}
+	
+	client, err := registry.NewClient()
+	if err != nil {
+		return nil, err
+	}
+	client.SetNetwork(network)
+	client.SetTimeouts(ConfigManager.Get().Timeouts)
+	client.SetRetry(ConfigManager.Get().Retry)
+	client.SetOffline(isOffline())
+	client.SetRequireSignatures(ConfigManager.Get().Trust.RequireSignatures)
+	if ConfigManager.Get().Trust.Enabled {
+		client.SetTrust(enabledModules())
+	}
+	
+	return client, nil
+}
+
+// newURLValidator creates a URL validator using the global configuration
+func newURLValidator() (*registry.URLValidator, error) {
+	network, err := networkConfig()
+	if err != nil {
+		return nil, err
+	}
+	
+	validator := registry.NewURLValidator()
+	if err := validator.SetNetwork(network); err != nil {
+		return nil, err
+	}
+	validator.SetTimeout(ConfigManager.Get().Timeouts.ProbeTimeout())
+	validator.SetRetry(ConfigManager.Get().Retry)
+	validator.SetOffline(isOffline())
+	
+	return validator, nil
+}
+
+// enabledModules returns a function reporting whether a module is enabled
+// in the profile on this machine. Without a profile no modules are enabled.
+func enabledModules() func(string) bool {
+	prof, err := profile.Load()
+	if err != nil {
+		logger.Debug("No profile loaded: %v", err)
+		return func(string) bool { return false }
+	}
+	return resolveProfile(prof).IsModuleEnabled
+}
+
+// authFromFlags builds registry authentication settings from command flags
+func authFromFlags() (*registry.Auth, error) {
+	auth := &registry.Auth{
+		TokenEnv:         tokenEnvFlag,
+		TokenFile:        tokenFileFlag,
+		Username:         usernameFlag,
+		Netrc:            netrcFlag,
+		CredentialHelper: credentialHelperFlag,
+		SSHKey:           sshKeyFlag,
+	}
+	
+	if auth.IsZero() {
+		return nil, nil
+	}
+	
+	if err := auth.Validate(); err != nil {
+		return nil, err
+	}
+	
+	return auth, nil
+}
+
+// addAuthFlags registers the registry authentication flags on a command
+func addAuthFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&tokenEnvFlag, "token-env", "", "Environment variable holding an access token")
+	cmd.Flags().StringVar(&tokenFileFlag, "token-file", "", "File containing an access token")
+	cmd.Flags().StringVar(&usernameFlag, "username", "", "Username to send with the access token")
+	cmd.Flags().BoolVar(&netrcFlag, "netrc", false, "Read credentials from ~/.netrc")
+	cmd.Flags().StringVar(&credentialHelperFlag, "credential-helper", "", "git credential helper to use (e.g. osxkeychain, store)")
+	cmd.Flags().StringVar(&sshKeyFlag, "ssh-key", "", "Private key to use for SSH registries")
+}
+
+// printChangeSet prints the module changes of one sync, including alias and
+// function changes for modules enabled in the profile
+func printChangeSet(changes registry.ChangeSet, prof *profile.ProfileConfig) {
+	fmt.Printf("Changes in registry '%s' synced %s:\n", changes.Registry, changes.SyncedAt.Format("2006-01-02 15:04:05"))
+	
+	if len(changes.Added) > 0 {
+		fmt.Println("  New modules:")
+		for _, mod := range changes.Added {
+			fmt.Printf("    + %s %s\n", mod.Name, mod.NewVersion)
+		}
+	}
+	if len(changes.Removed) > 0 {
+		fmt.Println("  Removed modules:")
+		for _, mod := range changes.Removed {
+			fmt.Printf("    - %s %s\n", mod.Name, mod.OldVersion)
+		}
+	}
+	if len(changes.Updated) > 0 {
+		fmt.Println("  Updated modules:")
+		for _, mod := range changes.Updated {
+			if mod.Bumped() {
+				fmt.Printf("    ~ %s %s -> %s\n", mod.Name, mod.OldVersion, mod.NewVersion)
+			} else {
+				fmt.Printf("    ~ %s (content changed)\n", mod.Name)
+			}
+			
+			if prof == nil || !prof.IsModuleEnabled(mod.Name) {
+				continue
+			}
+			for _, alias := range mod.Aliases {
+				printItemChange("alias", alias)
+			}
+			for _, function := range mod.Functions {
+				printItemChange("function", function)
+			}
+		}
+	}
+	fmt.Println()
+}
+
+// printItemChange prints the old and new body of a changed alias or function
+func printItemChange(kind string, change registry.ItemChange) {
+	switch {
+	case change.Old == "":
+		fmt.Printf("      added %s %s:\n", kind, change.Name)
+	case change.New == "":
+		fmt.Printf("      removed %s %s:\n", kind, change.Name)
+	default:
+		fmt.Printf("      changed %s %s:\n", kind, change.Name)
+	}
+	
+	if change.Old != "" {
+		for _, line := range strings.Split(change.Old, "\n") {
+			fmt.Printf("        - %s\n", line)
+		}
+	}
+	if change.New != "" {
+		for _, line := range strings.Split(change.New, "\n") {
+			fmt.Printf("        + %s\n", line)
+		}
+	}
+}
+
+// printPendingApproval prints the held back changes of a module
+func printPendingApproval(p registry.PendingApproval) {
+	fmt.Printf("Module '%s' (%s):\n", p.Module, p.Registry)
+	for _, change := range p.Functions {
+		printItemChange("function", change)
+	}
+	for _, change := range p.Aliases {
+		printItemChange("alias", change)
+	}
+	for _, change := range p.Files {
+		printItemChange("file", change)
+	}
+	for _, change := range p.Checks {
+		printItemChange("check", change)
+	}
+}
+
+// confirm asks a yes/no question on the command's input
+func confirm(cmd *cobra.Command, question string) bool {
+	fmt.Printf("%s [y/N] ", question)
+	
+	var answer string
+	fmt.Fscanln(cmd.InOrStdin(), &answer)
+	
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
+}
+
 // generateRegistryName generates a registry name from a URL
 func generateRegistryName(rawURL string) string {
 	// Parse the URL
@@ -277,4 +890,23 @@ func init() {
 	registryCmd.AddCommand(registryListCmd)
 	registryCmd.AddCommand(registryRemoveCmd)
 	registryCmd.AddCommand(registryValidateCmd)
+	registryCmd.AddCommand(registrySyncCmd)
+	registryCmd.AddCommand(registryChangesCmd)
+	registryCmd.AddCommand(registryApproveCmd)
+	registryCmd.AddCommand(registryLintCmd)
+	registryCmd.AddCommand(registryChecksumCmd)
+	registryCmd.AddCommand(registrySignCmd)
+	
+	// Add flags to registry add command
+	registryAddCmd.Flags().StringVar(&refFlag, "ref", "", "Branch or tag to track instead of the default branch")
+	registryAddCmd.Flags().StringArrayVar(&trustedKeysFlag, "trusted-key", nil, "Base64 ed25519 public key the registry must be signed with (repeatable)")
+	registryValidateCmd.Flags().StringArrayVar(&trustedKeysFlag, "trusted-key", nil, "Base64 ed25519 public key the registry must be signed with (repeatable)")
+	addAuthFlags(registryAddCmd)
+	addAuthFlags(registryValidateCmd)
+	
+	registrySignCmd.Flags().StringVar(&signKeyFlag, "key", "", "PEM encoded ed25519 private key to sign with")
+	registrySignCmd.Flags().BoolVar(&signGenerateFlag, "generate", false, "Generate a new signing key at --key first")
+	
+	registryChangesCmd.Flags().BoolVar(&allChangesFlag, "all", false, "Show every recorded sync, not just the latest")
+	registryApproveCmd.Flags().BoolVarP(&approveYesFlag, "yes", "y", false, "Approve without asking for confirmation")
 }
\ No newline at end of file
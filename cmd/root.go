@@ -1,7 +1,11 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
 
 	"github.com/griffin/go-shellify/internal/config"
 	"github.com/griffin/go-shellify/internal/logger"
@@ -17,6 +21,7 @@ var (
 	// Global flags
 	verboseFlag bool
 	configFile  string
+	offlineFlag bool
 	
 	// ConfigManager is the global configuration manager
 	ConfigManager *config.Manager
@@ -39,8 +44,13 @@ across bash, zsh, fish, and PowerShell.`,
 }
 
 // Execute adds all child commands to the root command and sets flags appropriately.
+// Interrupts cancel the command context so in-flight git operations are stopped
+// and cleaned up instead of leaving partial state behind.
 func Execute() error {
-	return rootCmd.Execute()
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	
+	return rootCmd.ExecuteContext(ctx)
 }
 
 func init() {
@@ -49,6 +59,7 @@ func init() {
 	// Global flags
 	rootCmd.PersistentFlags().BoolVarP(&verboseFlag, "verbose", "v", false, "Enable verbose output")
 	rootCmd.PersistentFlags().StringVar(&configFile, "config", "", "Config file (default is $HOME/.go-shellify/config.json)")
+	rootCmd.PersistentFlags().BoolVar(&offlineFlag, "offline", false, "Work from cached registries without network access")
 
 	// Version template
 	rootCmd.SetVersionTemplate(fmt.Sprintf(`{{with .Name}}{{printf "%%s version information:\n" .}}{{end}}
@@ -75,4 +86,9 @@ func initConfig() {
 	} else {
 		logger.Debug("Configuration loaded successfully")
 	}
-}
\ No newline at end of file
+}
+
+// isOffline reports whether offline mode is enabled by flag or configuration
+func isOffline() bool {
+	return offlineFlag || ConfigManager.Get().Offline.Enabled
+}
@@ -0,0 +1,128 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+
+	"github.com/griffin/go-shellify/internal/config"
+	"github.com/griffin/go-shellify/internal/logger"
+	"github.com/griffin/go-shellify/internal/registry"
+)
+
+// refreshStaleRegistries applies the sync policy to registries whose cache
+// is older than the sync TTL before a command reads their index. Failures
+// never stop the command; it falls back to the cached copy with a warning.
+func refreshStaleRegistries(ctx context.Context, client *registry.Client) {
+	syncConfig := ConfigManager.Get().Sync
+
+	if client.IsOffline() {
+		warnOfflineCache(client)
+		return
+	}
+
+	stale := client.StaleRegistries(syncConfig.TTLDuration())
+	if len(stale) == 0 {
+		return
+	}
+
+	switch syncConfig.SyncMode() {
+	case config.SyncModeWarn:
+		warnStale(stale)
+
+	case config.SyncModeBackground:
+		if client.SyncInProgress() {
+			logger.Debug("Background sync already running")
+			return
+		}
+		if err := startBackgroundSync(client, stale); err != nil {
+			logger.Warn("Failed to start background sync: %v", err)
+			warnStale(stale)
+		}
+
+	default:
+		release, err := client.LockSync()
+		if err != nil {
+			logger.Debug("Skipping automatic sync: %v", err)
+			warnStale(stale)
+			return
+		}
+		defer release()
+
+		for i, reg := range stale {
+			logger.Info("Syncing stale registry '%s'", reg.Name)
+			err := client.SyncRegistry(ctx, reg.Name)
+			if err == nil {
+				continue
+			}
+
+			// Without a network the remaining syncs would fail the same way
+			if errors.Is(err, registry.ErrNetworkUnavailable) {
+				logger.Debug("Sync of registry '%s' failed: %v", reg.Name, err)
+				warnStale(stale[i:])
+				return
+			}
+			logger.Warn("Failed to sync registry '%s': %v", reg.Name, err)
+			warnStale([]registry.Registry{reg})
+		}
+	}
+}
+
+// warnOfflineCache warns about registries that can't be served or are out
+// of date while offline
+func warnOfflineCache(client *registry.Client) {
+	maxAge := ConfigManager.Get().Offline.MaxCacheAgeDuration()
+	for _, reg := range client.ListRegistries() {
+		if !client.IsCached(reg.Name) {
+			logger.Warn("Registry '%s' has no cached copy and is unavailable offline", reg.Name)
+			continue
+		}
+		if age := reg.CacheAge(); age > maxAge {
+			logger.Warn("Cached registry '%s' is %s old", reg.Name, formatAge(age))
+		}
+	}
+}
+
+// warnStale reports registries that are used despite being out of date
+func warnStale(stale []registry.Registry) {
+	for _, reg := range stale {
+		logger.Warn("Registry '%s' is %s stale", reg.Name, formatAge(reg.CacheAge()))
+	}
+}
+
+// startBackgroundSync runs `registry sync` for the stale registries in a
+// detached process so the current command doesn't wait for the network
+func startBackgroundSync(client *registry.Client, stale []registry.Registry) error {
+	executable, err := os.Executable()
+	if err != nil {
+		return err
+	}
+
+	args := []string{"registry", "sync"}
+	if configFile != "" {
+		args = append(args, "--config", configFile)
+	}
+	for _, reg := range stale {
+		args = append(args, reg.Name)
+	}
+
+	logFile, err := os.Create(client.SyncLogPath())
+	if err != nil {
+		return err
+	}
+	defer logFile.Close()
+
+	cmd := exec.Command(executable, args...)
+	cmd.Stdout = logFile
+	cmd.Stderr = logFile
+	detach(cmd)
+
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	logger.Debug("Started background sync (pid %d), output in %s", cmd.Process.Pid, client.SyncLogPath())
+
+	// The child outlives this process; don't wait for it
+	return cmd.Process.Release()
+}
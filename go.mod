@@ -2,7 +2,10 @@ module github.com/griffin/go-shellify
 
 go 1.25.0
 
-require github.com/spf13/cobra v1.9.1
+require (
+	github.com/spf13/cobra v1.9.1
+	mvdan.cc/sh/v3 v3.13.0
+)
 
 require (
 	github.com/inconshreveable/mousetrap v1.1.0 // indirect
@@ -0,0 +1,206 @@
+// Package condition parses and evaluates the `when` conditions of modules
+// and their items, such as
+//
+//	os == "linux" && command("kubectl") && env("CI") == ""
+//
+// Facts known when the script is generated (os, arch, shell, hostname) are
+// evaluated in Go. What remains, command() and env(), is only known when the
+// shell starts and is rendered as a runtime guard by the generator.
+package condition
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+	"strings"
+)
+
+// Expr is a parsed condition
+type Expr interface {
+	String() string
+}
+
+// Bool is a condition whose value is known
+type Bool struct{ Value bool }
+
+// Text is a string literal, or a static fact after Simplify
+type Text struct{ Value string }
+
+// Fact is a static fact: os, arch, shell or hostname
+type Fact struct{ Name string }
+
+// Command is command("name"), true when the command is found at startup
+type Command struct{ Name string }
+
+// Env is env("NAME"), the value of an environment variable at startup. As
+// a condition on its own it is true when the variable is set and not empty.
+type Env struct{ Name string }
+
+// Compare is X == Y or X != Y
+type Compare struct {
+	Op   string
+	X, Y Expr
+}
+
+// Not is !X
+type Not struct{ X Expr }
+
+// And is X && Y
+type And struct{ X, Y Expr }
+
+// Or is X || Y
+type Or struct{ X, Y Expr }
+
+func (e Bool) String() string    { return fmt.Sprint(e.Value) }
+func (e Text) String() string    { return fmt.Sprintf("%q", e.Value) }
+func (e Fact) String() string    { return e.Name }
+func (e Command) String() string { return fmt.Sprintf("command(%q)", e.Name) }
+func (e Env) String() string     { return fmt.Sprintf("env(%q)", e.Name) }
+func (e Compare) String() string { return e.X.String() + " " + e.Op + " " + e.Y.String() }
+func (e Not) String() string     { return "!" + group(e.X) }
+func (e And) String() string     { return group(e.X) + " && " + group(e.Y) }
+func (e Or) String() string      { return group(e.X) + " || " + group(e.Y) }
+
+// group parenthesizes compound operands
+func group(e Expr) string {
+	switch e.(type) {
+	case And, Or, Compare:
+		return "(" + e.String() + ")"
+	}
+	return e.String()
+}
+
+// Facts are the values of the static facts
+type Facts struct {
+	OS       string
+	Arch     string
+	Shell    string
+	Hostname string
+}
+
+// HostFacts returns the facts of the machine go-shellify runs on for a
+// target shell. cmd.exe only runs on Windows, so its scripts are generated
+// for it wherever go-shellify runs.
+func HostFacts(shell string) Facts {
+	hostname, _ := os.Hostname()
+	facts := Facts{OS: runtime.GOOS, Arch: runtime.GOARCH, Shell: shell, Hostname: hostname}
+	if shell == "cmd" {
+		facts.OS = "windows"
+	}
+	return facts
+}
+
+// value returns the value of a fact
+func (f Facts) value(name string) string {
+	switch name {
+	case "os":
+		return f.OS
+	case "arch":
+		return f.Arch
+	case "shell":
+		return f.Shell
+	default:
+		return f.Hostname
+	}
+}
+
+// Simplify evaluates the static parts of a condition. The result is a Bool
+// when the condition doesn't depend on the shell's runtime, and otherwise a
+// condition made of Command, Env, comparisons of Env with Text, Not, And and
+// Or.
+func Simplify(e Expr, facts Facts) Expr {
+	switch e := e.(type) {
+	case Compare:
+		x, y := operand(e.X, facts), operand(e.Y, facts)
+		xt, xok := x.(Text)
+		yt, yok := y.(Text)
+		if xok && yok {
+			return Bool{(xt.Value == yt.Value) == (e.Op == "==")}
+		}
+		if xok {
+			// Keep the variable on the left
+			x, y = y, x
+		}
+		return Compare{Op: e.Op, X: x, Y: y}
+	case Not:
+		x := Simplify(e.X, facts)
+		if value, ok := Constant(x); ok {
+			return Bool{!value}
+		}
+		return Not{x}
+	case And:
+		x := Simplify(e.X, facts)
+		if value, ok := Constant(x); ok {
+			if !value {
+				return Bool{false}
+			}
+			return Simplify(e.Y, facts)
+		}
+		y := Simplify(e.Y, facts)
+		if value, ok := Constant(y); ok {
+			if !value {
+				return Bool{false}
+			}
+			return x
+		}
+		return And{x, y}
+	case Or:
+		x := Simplify(e.X, facts)
+		if value, ok := Constant(x); ok {
+			if value {
+				return Bool{true}
+			}
+			return Simplify(e.Y, facts)
+		}
+		y := Simplify(e.Y, facts)
+		if value, ok := Constant(y); ok {
+			if value {
+				return Bool{true}
+			}
+			return x
+		}
+		return Or{x, y}
+	}
+	return truth(operand(e, facts))
+}
+
+// operand replaces a fact with its value
+func operand(e Expr, facts Facts) Expr {
+	if fact, ok := e.(Fact); ok {
+		return Text{facts.value(fact.Name)}
+	}
+	return e
+}
+
+// truth turns text used as a condition into a Bool
+func truth(e Expr) Expr {
+	if text, ok := e.(Text); ok {
+		return Bool{text.Value != ""}
+	}
+	return e
+}
+
+// Constant returns the value of a condition that doesn't depend on the
+// shell's runtime
+func Constant(e Expr) (bool, bool) {
+	switch e := e.(type) {
+	case Bool:
+		return e.Value, true
+	case Text:
+		return e.Value != "", true
+	}
+	return false, false
+}
+
+// Evaluate parses a condition and evaluates its static parts, see Simplify.
+// An empty condition is true.
+func Evaluate(condition string, facts Facts) (Expr, error) {
+	if strings.TrimSpace(condition) == "" {
+		return Bool{true}, nil
+	}
+	e, err := Parse(condition)
+	if err != nil {
+		return nil, err
+	}
+	return Simplify(e, facts), nil
+}
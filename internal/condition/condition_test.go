@@ -0,0 +1,86 @@
+package condition
+
+import (
+	"strings"
+	"testing"
+)
+
+var testFacts = Facts{OS: "linux", Arch: "amd64", Shell: "bash", Hostname: "work-laptop"}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		condition string
+		expected  string
+		wantErr   string
+	}{
+		{condition: `os == "linux"`, expected: `os == "linux"`},
+		{condition: `os == "linux" && command("kubectl") && env("CI") == ""`, expected: `((os == "linux") && command("kubectl")) && (env("CI") == "")`},
+		{condition: `!(a == "b")`, wantErr: `unknown name "a"`},
+		{condition: `!command("x") || env("HOME")`, expected: `!command("x") || env("HOME")`},
+		{condition: `"say \"hi\"" != hostname`, expected: `"say \"hi\"" != hostname`},
+		{condition: `true && !false`, expected: `true && !false`},
+		{condition: `os ==`, wantErr: "unexpected end"},
+		{condition: `os = "linux"`, wantErr: `unexpected '='`},
+		{condition: `env("A") == env("B")`, wantErr: "can't compare"},
+		{condition: `command("x") == "y"`, wantErr: "can't compare"},
+		{condition: `env("not a name")`, wantErr: "invalid variable name"},
+		{condition: `(os == "linux"`, wantErr: "unexpected end"},
+		{condition: `"open`, wantErr: "unterminated string"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.condition, func(t *testing.T) {
+			e, err := Parse(tt.condition)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Parse() error = %v, expected %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Parse() failed: %v", err)
+			}
+			if e.String() != tt.expected {
+				t.Errorf("Parse() = %s, expected %s", e, tt.expected)
+			}
+		})
+	}
+}
+
+func TestEvaluate(t *testing.T) {
+	tests := []struct {
+		condition string
+		expected  string
+	}{
+		{condition: "", expected: "true"},
+		{condition: `os == "linux"`, expected: "true"},
+		{condition: `os != "linux"`, expected: "false"},
+		{condition: `os == "darwin" && command("brew")`, expected: "false"},
+		{condition: `os == "darwin" || arch == "amd64"`, expected: "true"},
+		{condition: `os == "linux" && command("kubectl") && env("CI") == ""`, expected: `command("kubectl") && (env("CI") == "")`},
+		{condition: `command("git") && shell == "bash"`, expected: `command("git")`},
+		{condition: `"work-laptop" == hostname || env("WORK")`, expected: "true"},
+		{condition: `hostname != "work-laptop" || env("WORK")`, expected: `env("WORK")`},
+		{condition: `os == env("TARGET_OS")`, expected: `env("TARGET_OS") == "linux"`},
+		{condition: `!(shell == "zsh") && !command("zsh")`, expected: `!command("zsh")`},
+		{condition: `hostname`, expected: "true"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.condition, func(t *testing.T) {
+			e, err := Evaluate(tt.condition, testFacts)
+			if err != nil {
+				t.Fatalf("Evaluate() failed: %v", err)
+			}
+			if e.String() != tt.expected {
+				t.Errorf("Evaluate() = %s, expected %s", e, tt.expected)
+			}
+		})
+	}
+}
+
+func TestHostFacts(t *testing.T) {
+	if facts := HostFacts("cmd"); facts.OS != "windows" || facts.Shell != "cmd" {
+		t.Errorf("HostFacts(cmd) = %+v, expected windows", facts)
+	}
+}
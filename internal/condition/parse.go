@@ -0,0 +1,245 @@
+package condition
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/griffin/go-shellify/internal/quote"
+)
+
+// token kinds
+const (
+	tokenEnd = iota
+	tokenIdent
+	tokenString
+	tokenOperator
+)
+
+type token struct {
+	kind int
+	text string
+	pos  int
+}
+
+// operators are the operator tokens, longest first
+var operators = []string{"&&", "||", "==", "!=", "!", "(", ")"}
+
+// lex splits a condition into tokens
+func lex(condition string) ([]token, error) {
+	var tokens []token
+	for i := 0; i < len(condition); {
+		c := condition[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case c == '"':
+			end := i + 1
+			for end < len(condition) && condition[end] != '"' {
+				if condition[end] == '\\' {
+					end++
+				}
+				end++
+			}
+			if end >= len(condition) {
+				return nil, fmt.Errorf("unterminated string at %d", i+1)
+			}
+			text, err := strconv.Unquote(condition[i : end+1])
+			if err != nil {
+				return nil, fmt.Errorf("invalid string at %d: %w", i+1, err)
+			}
+			tokens = append(tokens, token{tokenString, text, i})
+			i = end + 1
+		case c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z':
+			end := i
+			for end < len(condition) && (condition[end] == '_' || condition[end] >= 'a' && condition[end] <= 'z' ||
+				condition[end] >= 'A' && condition[end] <= 'Z' || condition[end] >= '0' && condition[end] <= '9') {
+				end++
+			}
+			tokens = append(tokens, token{tokenIdent, condition[i:end], i})
+			i = end
+		default:
+			matched := false
+			for _, operator := range operators {
+				if strings.HasPrefix(condition[i:], operator) {
+					tokens = append(tokens, token{tokenOperator, operator, i})
+					i += len(operator)
+					matched = true
+					break
+				}
+			}
+			if !matched {
+				return nil, fmt.Errorf("unexpected %q at %d", c, i+1)
+			}
+		}
+	}
+	return append(tokens, token{tokenEnd, "", len(condition)}), nil
+}
+
+// parser is a recursive descent parser over the tokens of a condition:
+//
+//	or      = and { "||" and }
+//	and     = unary { "&&" unary }
+//	unary   = "!" unary | compare
+//	compare = primary [ ( "==" | "!=" ) primary ]
+//	primary = "(" or ")" | string | "true" | "false" | fact | call
+//	call    = ( "command" | "env" ) "(" string ")"
+type parser struct {
+	tokens []token
+	next   int
+}
+
+// Parse parses a condition
+func Parse(condition string) (Expr, error) {
+	tokens, err := lex(condition)
+	if err != nil {
+		return nil, fmt.Errorf("invalid condition %q: %w", condition, err)
+	}
+
+	p := &parser{tokens: tokens}
+	e, err := p.or()
+	if err == nil && p.peek().kind != tokenEnd {
+		err = p.unexpected()
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid condition %q: %w", condition, err)
+	}
+	return e, nil
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.next]
+}
+
+func (p *parser) accept(operator string) bool {
+	if t := p.peek(); t.kind == tokenOperator && t.text == operator {
+		p.next++
+		return true
+	}
+	return false
+}
+
+func (p *parser) unexpected() error {
+	t := p.peek()
+	if t.kind == tokenEnd {
+		return fmt.Errorf("unexpected end")
+	}
+	return fmt.Errorf("unexpected %q at %d", t.text, t.pos+1)
+}
+
+func (p *parser) or() (Expr, error) {
+	x, err := p.and()
+	for err == nil && p.accept("||") {
+		var y Expr
+		if y, err = p.and(); err == nil {
+			x = Or{x, y}
+		}
+	}
+	return x, err
+}
+
+func (p *parser) and() (Expr, error) {
+	x, err := p.unary()
+	for err == nil && p.accept("&&") {
+		var y Expr
+		if y, err = p.unary(); err == nil {
+			x = And{x, y}
+		}
+	}
+	return x, err
+}
+
+func (p *parser) unary() (Expr, error) {
+	if p.accept("!") {
+		x, err := p.unary()
+		return Not{x}, err
+	}
+	return p.compare()
+}
+
+func (p *parser) compare() (Expr, error) {
+	x, err := p.primary()
+	if err != nil {
+		return nil, err
+	}
+
+	op := p.peek().text
+	if !p.accept("==") && !p.accept("!=") {
+		return x, nil
+	}
+	y, err := p.primary()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, operand := range []Expr{x, y} {
+		switch operand.(type) {
+		case Text, Fact, Env:
+		default:
+			return nil, fmt.Errorf("can't compare %s", operand)
+		}
+	}
+	_, xEnv := x.(Env)
+	_, yEnv := y.(Env)
+	if xEnv && yEnv {
+		return nil, fmt.Errorf("can't compare %s with %s, compare with a string or fact", x, y)
+	}
+	return Compare{Op: op, X: x, Y: y}, nil
+}
+
+func (p *parser) primary() (Expr, error) {
+	if p.accept("(") {
+		e, err := p.or()
+		if err != nil {
+			return nil, err
+		}
+		if !p.accept(")") {
+			return nil, p.unexpected()
+		}
+		return e, nil
+	}
+
+	t := p.peek()
+	switch t.kind {
+	case tokenString:
+		p.next++
+		return Text{t.text}, nil
+	case tokenIdent:
+		p.next++
+		switch t.text {
+		case "true", "false":
+			return Bool{t.text == "true"}, nil
+		case "os", "arch", "shell", "hostname":
+			return Fact{t.text}, nil
+		case "command", "env":
+			return p.call(t.text)
+		}
+		return nil, fmt.Errorf("unknown name %q at %d, expected os, arch, shell, hostname, command() or env()", t.text, t.pos+1)
+	}
+	return nil, p.unexpected()
+}
+
+func (p *parser) call(name string) (Expr, error) {
+	if !p.accept("(") {
+		return nil, p.unexpected()
+	}
+	t := p.peek()
+	if t.kind != tokenString {
+		return nil, p.unexpected()
+	}
+	p.next++
+	if !p.accept(")") {
+		return nil, p.unexpected()
+	}
+
+	if name == "env" {
+		if !quote.IsName(t.text) {
+			return nil, fmt.Errorf("invalid variable name %q at %d", t.text, t.pos+1)
+		}
+		return Env{t.text}, nil
+	}
+	if t.text == "" {
+		return nil, fmt.Errorf("empty command name at %d", t.pos+1)
+	}
+	return Command{t.text}, nil
+}
@@ -3,19 +3,169 @@ package config
 import (
 	"encoding/json"
 	"fmt"
+	"net/url"
 	"os"
 	"path/filepath"
+	"strings"
 	"time"
 )
 
 // Config represents the application configuration
 type Config struct {
-	Registries []Registry `json:"registries"`
-	CacheDir   string     `json:"cache_dir"`
-	Shell      string     `json:"shell"`
-	Platform   string     `json:"platform"`
+	Registries []Registry     `json:"registries"`
+	CacheDir   string         `json:"cache_dir"`
+	Shell      string         `json:"shell"`
+	Platform   string         `json:"platform"`
+	Network    NetworkConfig  `json:"network"`
+	Timeouts   TimeoutConfig  `json:"timeouts"`
+	Retry      RetryConfig    `json:"retry"`
+	Offline    OfflineConfig  `json:"offline"`
+	Sync       SyncConfig     `json:"sync"`
+	Trust      TrustConfig    `json:"trust"`
+	Security   SecurityConfig `json:"security"`
 }
 
+// SecurityConfig controls the security linting of module code
+type SecurityConfig struct {
+	Allow map[string][]string `json:"allow,omitempty"` // Rule -> module name patterns the rule doesn't apply to
+}
+
+// TrustConfig controls review of registry changes before they reach the shell
+type TrustConfig struct {
+	Enabled           bool `json:"enabled,omitempty"`            // Hold back code changes to enabled modules until approved
+	RequireSignatures bool `json:"require_signatures,omitempty"` // Refuse registries that aren't signed by a pinned key
+}
+
+// SyncConfig controls automatic syncing of registries that are out of date
+type SyncConfig struct {
+	TTL  string `json:"ttl,omitempty"`  // Registries synced longer ago than this are stale, default 24h
+	Mode string `json:"mode,omitempty"` // auto (sync before reading), warn or background, default auto
+}
+
+// Sync modes for stale registries
+const (
+	// SyncModeAuto syncs stale registries before commands read their index
+	SyncModeAuto = "auto"
+	// SyncModeWarn only reports stale registries
+	SyncModeWarn = "warn"
+	// SyncModeBackground syncs stale registries in a detached process
+	SyncModeBackground = "background"
+)
+
+// DefaultSyncTTL is the age after which a registry is considered stale
+const DefaultSyncTTL = 24 * time.Hour
+
+// TTLDuration returns the configured sync TTL or its default
+func (s SyncConfig) TTLDuration() time.Duration {
+	return parseDurationOr(s.TTL, DefaultSyncTTL)
+}
+
+// SyncMode returns the configured sync mode or its default
+func (s SyncConfig) SyncMode() string {
+	if s.Mode == "" {
+		return SyncModeAuto
+	}
+	return s.Mode
+}
+
+// Validate checks the sync TTL and mode
+func (s SyncConfig) Validate() error {
+	switch s.SyncMode() {
+	case SyncModeAuto, SyncModeWarn, SyncModeBackground:
+	default:
+		return fmt.Errorf("invalid sync mode '%s': must be one of auto, warn, background", s.Mode)
+	}
+	if s.TTL == "" {
+		return nil
+	}
+	d, err := time.ParseDuration(s.TTL)
+	if err != nil {
+		return fmt.Errorf("invalid sync ttl '%s': %w", s.TTL, err)
+	}
+	if d <= 0 {
+		return fmt.Errorf("invalid sync ttl '%s': must be positive", s.TTL)
+	}
+	return nil
+}
+
+// OfflineConfig controls working from cached registries without network access
+type OfflineConfig struct {
+	Enabled     bool   `json:"enabled,omitempty"`       // Always run as if --offline was given
+	MaxCacheAge string `json:"max_cache_age,omitempty"` // Warn when cached registries are older than this, default 168h
+}
+
+// DefaultMaxCacheAge is the cache age after which offline mode warns
+const DefaultMaxCacheAge = 7 * 24 * time.Hour
+
+// MaxCacheAgeDuration returns the configured maximum cache age or its default
+func (o OfflineConfig) MaxCacheAgeDuration() time.Duration {
+	return parseDurationOr(o.MaxCacheAge, DefaultMaxCacheAge)
+}
+
+// Validate checks that the maximum cache age is a valid positive duration
+func (o OfflineConfig) Validate() error {
+	if o.MaxCacheAge == "" {
+		return nil
+	}
+	d, err := time.ParseDuration(o.MaxCacheAge)
+	if err != nil {
+		return fmt.Errorf("invalid max cache age '%s': %w", o.MaxCacheAge, err)
+	}
+	if d <= 0 {
+		return fmt.Errorf("invalid max cache age '%s': must be positive", o.MaxCacheAge)
+	}
+	return nil
+}
+
+// NetworkConfig holds proxy and TLS settings shared by HTTP probes and git
+type NetworkConfig struct {
+	HTTPSProxy string `json:"https_proxy,omitempty"` // Proxy for HTTPS requests, defaults to $HTTPS_PROXY
+	HTTPProxy  string `json:"http_proxy,omitempty"`  // Proxy for plain HTTP requests, defaults to $HTTP_PROXY
+	NoProxy    string `json:"no_proxy,omitempty"`    // Comma separated hosts that bypass the proxy, defaults to $NO_PROXY
+	CABundle   string `json:"ca_bundle,omitempty"`   // PEM file with the certificate authorities to trust instead of the system ones
+	Insecure   bool   `json:"insecure,omitempty"`    // Skip TLS certificate verification (internal test servers only)
+}
+
+// TimeoutConfig holds per-operation timeouts as Go duration strings (e.g. "90s", "5m")
+type TimeoutConfig struct {
+	Clone string `json:"clone,omitempty"` // git clone of a registry, default 5m
+	Fetch string `json:"fetch,omitempty"` // git pull/fetch during sync, default 2m
+	Probe string `json:"probe,omitempty"` // URL accessibility probes, default 15s
+}
+
+// RetryConfig controls how transient network failures are retried
+type RetryConfig struct {
+	Attempts       int      `json:"attempts,omitempty"`        // Total attempts including the first, default 3
+	InitialBackoff string   `json:"initial_backoff,omitempty"` // Delay before the first retry, default 1s
+	MaxBackoff     string   `json:"max_backoff,omitempty"`     // Upper bound for the exponential delay, default 10s
+	Jitter         *float64 `json:"jitter,omitempty"`          // Fraction of each delay that is randomized, default 0.2, 0 disables it
+}
+
+const (
+	// DefaultRetryAttempts is the total number of attempts for network operations
+	DefaultRetryAttempts = 3
+
+	// DefaultInitialBackoff is the delay before the first retry
+	DefaultInitialBackoff = time.Second
+
+	// DefaultMaxBackoff caps the exponential retry delay
+	DefaultMaxBackoff = 10 * time.Second
+
+	// DefaultRetryJitter is the fraction of each delay that is randomized
+	DefaultRetryJitter = 0.2
+)
+
+const (
+	// DefaultCloneTimeout bounds a registry clone
+	DefaultCloneTimeout = 5 * time.Minute
+
+	// DefaultFetchTimeout bounds a registry update
+	DefaultFetchTimeout = 2 * time.Minute
+
+	// DefaultProbeTimeout bounds a single accessibility probe
+	DefaultProbeTimeout = 15 * time.Second
+)
+
 // Registry represents a configured registry
 type Registry struct {
 	URL      string    `json:"url"`
@@ -194,6 +344,160 @@ func (m *Manager) UpdateRegistrySync(url string) error {
 	return fmt.Errorf("registry not found: %s", url)
 }
 
+// WithEnvironmentDefaults returns a copy of the network settings with unset
+// proxy fields filled in from the standard proxy environment variables
+func (n NetworkConfig) WithEnvironmentDefaults() NetworkConfig {
+	if n.HTTPSProxy == "" {
+		n.HTTPSProxy = firstEnv("HTTPS_PROXY", "https_proxy")
+	}
+	if n.HTTPProxy == "" {
+		n.HTTPProxy = firstEnv("HTTP_PROXY", "http_proxy")
+	}
+	if n.NoProxy == "" {
+		n.NoProxy = firstEnv("NO_PROXY", "no_proxy")
+	}
+	return n
+}
+
+// Validate checks that the network settings are usable
+func (n NetworkConfig) Validate() error {
+	for name, proxy := range map[string]string{"https_proxy": n.HTTPSProxy, "http_proxy": n.HTTPProxy} {
+		if proxy == "" {
+			continue
+		}
+		parsed, err := url.Parse(proxy)
+		if err != nil {
+			return fmt.Errorf("invalid %s: %w", name, err)
+		}
+		switch parsed.Scheme {
+		case "http", "https", "socks5":
+		default:
+			return fmt.Errorf("invalid %s '%s': scheme must be http, https or socks5", name, proxy)
+		}
+	}
+	
+	if n.CABundle != "" {
+		if _, err := os.Stat(ExpandHome(n.CABundle)); err != nil {
+			return fmt.Errorf("CA bundle not accessible: %w", err)
+		}
+	}
+	
+	return nil
+}
+
+// CloneTimeout returns the configured clone timeout or its default
+func (t TimeoutConfig) CloneTimeout() time.Duration {
+	return parseDurationOr(t.Clone, DefaultCloneTimeout)
+}
+
+// FetchTimeout returns the configured fetch timeout or its default
+func (t TimeoutConfig) FetchTimeout() time.Duration {
+	return parseDurationOr(t.Fetch, DefaultFetchTimeout)
+}
+
+// ProbeTimeout returns the configured probe timeout or its default
+func (t TimeoutConfig) ProbeTimeout() time.Duration {
+	return parseDurationOr(t.Probe, DefaultProbeTimeout)
+}
+
+// Validate checks that all timeouts are positive durations
+func (t TimeoutConfig) Validate() error {
+	for name, value := range map[string]string{"clone": t.Clone, "fetch": t.Fetch, "probe": t.Probe} {
+		if value == "" {
+			continue
+		}
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return fmt.Errorf("invalid %s timeout '%s': %w", name, value, err)
+		}
+		if d <= 0 {
+			return fmt.Errorf("invalid %s timeout '%s': must be positive", name, value)
+		}
+	}
+	return nil
+}
+
+// AttemptCount returns the configured number of attempts or its default
+func (r RetryConfig) AttemptCount() int {
+	if r.Attempts <= 0 {
+		return DefaultRetryAttempts
+	}
+	return r.Attempts
+}
+
+// InitialBackoffDuration returns the configured initial backoff or its default
+func (r RetryConfig) InitialBackoffDuration() time.Duration {
+	return parseDurationOr(r.InitialBackoff, DefaultInitialBackoff)
+}
+
+// MaxBackoffDuration returns the configured maximum backoff or its default
+func (r RetryConfig) MaxBackoffDuration() time.Duration {
+	return parseDurationOr(r.MaxBackoff, DefaultMaxBackoff)
+}
+
+// JitterFraction returns the configured jitter or its default when unset
+func (r RetryConfig) JitterFraction() float64 {
+	if r.Jitter == nil {
+		return DefaultRetryJitter
+	}
+	return *r.Jitter
+}
+
+// Validate checks that the retry settings are usable
+func (r RetryConfig) Validate() error {
+	if r.Attempts < 0 {
+		return fmt.Errorf("invalid retry attempts %d: must not be negative", r.Attempts)
+	}
+	if r.Jitter != nil && (*r.Jitter < 0 || *r.Jitter > 1) {
+		return fmt.Errorf("invalid retry jitter %g: must be between 0 and 1", *r.Jitter)
+	}
+	for name, value := range map[string]string{"initial_backoff": r.InitialBackoff, "max_backoff": r.MaxBackoff} {
+		if value == "" {
+			continue
+		}
+		if _, err := time.ParseDuration(value); err != nil {
+			return fmt.Errorf("invalid retry %s '%s': %w", name, value, err)
+		}
+	}
+	if r.MaxBackoffDuration() < r.InitialBackoffDuration() {
+		return fmt.Errorf("invalid retry max_backoff %s: shorter than initial_backoff %s", r.MaxBackoffDuration(), r.InitialBackoffDuration())
+	}
+	return nil
+}
+
+// parseDurationOr parses a duration string, falling back to def when empty or invalid
+func parseDurationOr(value string, def time.Duration) time.Duration {
+	if value == "" {
+		return def
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil || d <= 0 {
+		return def
+	}
+	return d
+}
+
+// firstEnv returns the first non-empty environment variable among keys
+func firstEnv(keys ...string) string {
+	for _, key := range keys {
+		if value := os.Getenv(key); value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+// ExpandHome expands a leading ~/ to the user's home directory
+func ExpandHome(path string) string {
+	if strings.HasPrefix(path, "~/") {
+		homeDir, err := os.UserHomeDir()
+		if err == nil {
+			return filepath.Join(homeDir, path[2:])
+		}
+	}
+	return path
+}
+
 // defaultConfig returns the default configuration
 func (m *Manager) defaultConfig() *Config {
 	return &Config{
@@ -0,0 +1,40 @@
+package config
+
+import "testing"
+
+func TestRetryConfigValidate(t *testing.T) {
+	zero, half, tooMuch := 0.0, 0.5, 1.5
+
+	tests := []struct {
+		name    string
+		retry   RetryConfig
+		wantErr bool
+	}{
+		{name: "defaults", retry: RetryConfig{}},
+		{name: "jitter disabled", retry: RetryConfig{Jitter: &zero}},
+		{name: "jitter", retry: RetryConfig{Jitter: &half}},
+		{name: "jitter too large", retry: RetryConfig{Jitter: &tooMuch}, wantErr: true},
+		{name: "negative attempts", retry: RetryConfig{Attempts: -1}, wantErr: true},
+		{name: "invalid backoff", retry: RetryConfig{InitialBackoff: "soon"}, wantErr: true},
+		{name: "max below initial", retry: RetryConfig{InitialBackoff: "5s", MaxBackoff: "2s"}, wantErr: true},
+		{name: "initial above default max", retry: RetryConfig{InitialBackoff: "30s"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.retry.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestJitterFraction(t *testing.T) {
+	zero := 0.0
+	if jitter := (RetryConfig{}).JitterFraction(); jitter != DefaultRetryJitter {
+		t.Errorf("JitterFraction() unset = %g, want %g", jitter, DefaultRetryJitter)
+	}
+	if jitter := (RetryConfig{Jitter: &zero}).JitterFraction(); jitter != 0 {
+		t.Errorf("JitterFraction() = %g, want 0 to disable jitter", jitter)
+	}
+}
@@ -0,0 +1,198 @@
+package generator
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/griffin/go-shellify/internal/registry"
+)
+
+// Kinds of names modules can collide on
+const (
+	KindAlias       = "alias"
+	KindFunction    = "function"
+	KindEnvironment = "env"
+)
+
+// Source is a module defining a colliding name
+type Source struct {
+	Module   string
+	Registry string
+}
+
+// Collision is a name defined by more than one module
+type Collision struct {
+	Kind    string
+	Name    string
+	Sources []Source // Defining modules in generation order
+	Winner  int      // Index of the source whose definition is generated
+	Chosen  bool     // Whether the winner was picked by the precedence rather than by order
+}
+
+// Precedence picks the module that wins when several define the same name
+type Precedence struct {
+	Order       []string          // Module names, highest precedence first
+	Aliases     map[string]string // Winning module per alias name
+	Functions   map[string]string // Winning module per function name
+	Environment map[string]string // Winning module per variable name
+}
+
+// overrides returns the explicit winners for a kind of name
+func (p Precedence) overrides(kind string) map[string]string {
+	switch kind {
+	case KindAlias:
+		return p.Aliases
+	case KindFunction:
+		return p.Functions
+	default:
+		return p.Environment
+	}
+}
+
+// winner returns the index of the winning source and whether the precedence
+// chose it. An explicit override wins over the order; without either the
+// last definition wins, as it would in the generated script.
+func (p Precedence) winner(kind, name string, sources []Source) (int, bool) {
+	if module := p.overrides(kind)[name]; module != "" {
+		for i, source := range sources {
+			if source.Module == module {
+				return i, true
+			}
+		}
+	}
+
+	for _, module := range p.Order {
+		for i, source := range sources {
+			if source.Module == module {
+				return i, true
+			}
+		}
+	}
+
+	return len(sources) - 1, false
+}
+
+// ResolveCollisions finds aliases, functions and environment variables
+// defined by more than one module and drops every definition but the winning
+// one. The returned modules don't share item slices with the given ones.
+func (g *Generator) ResolveCollisions(modules []Module, precedence Precedence) ([]Module, []Collision) {
+	type key struct{ kind, name string }
+	definitions := make(map[key][]int)
+	var order []key
+
+	define := func(kind, name string, index int) {
+		k := key{kind, name}
+		indexes := definitions[k]
+		if len(indexes) > 0 && indexes[len(indexes)-1] == index {
+			return
+		}
+		if len(indexes) == 0 {
+			order = append(order, k)
+		}
+		definitions[k] = append(indexes, index)
+	}
+
+	for i, module := range modules {
+		for _, env := range module.Environment {
+			define(KindEnvironment, env.Name, i)
+		}
+		for _, alias := range module.Aliases {
+			define(KindAlias, alias.Name, i)
+		}
+		for _, function := range module.Functions {
+			if g.rendersFunction(function) {
+				define(KindFunction, function.Name, i)
+			}
+		}
+	}
+
+	resolved := make([]Module, len(modules))
+	copy(resolved, modules)
+
+	var collisions []Collision
+	for _, k := range order {
+		indexes := definitions[k]
+		if len(indexes) < 2 {
+			continue
+		}
+
+		sources := make([]Source, len(indexes))
+		for i, index := range indexes {
+			sources[i] = Source{Module: modules[index].Name, Registry: modules[index].Registry}
+		}
+		winner, chosen := precedence.winner(k.kind, k.name, sources)
+		collisions = append(collisions, Collision{Kind: k.kind, Name: k.name, Sources: sources, Winner: winner, Chosen: chosen})
+
+		for i, index := range indexes {
+			if i != winner {
+				resolved[index] = g.withoutName(resolved[index], k.kind, k.name)
+			}
+		}
+	}
+
+	return resolved, collisions
+}
+
+// OverrideEnvironment replaces the values modules set for environment
+// variables, returning the modules with the new values and the names no
+// module sets. The returned modules don't share environment slices with the
+// given ones.
+func OverrideEnvironment(modules []Module, values map[string]string) ([]Module, []string) {
+	used := make(map[string]bool)
+	overridden := make([]Module, len(modules))
+	for i, module := range modules {
+		module.Environment = append([]registry.Environment(nil), module.Environment...)
+		for j, env := range module.Environment {
+			if value, ok := values[env.Name]; ok {
+				module.Environment[j].Value = value
+				used[env.Name] = true
+			}
+		}
+		overridden[i] = module
+	}
+
+	var unused []string
+	for name := range values {
+		if !used[name] {
+			unused = append(unused, name)
+		}
+	}
+	sort.Strings(unused)
+	return overridden, unused
+}
+
+// rendersFunction reports whether a function is generated for the
+// generator's shell
+func (g *Generator) rendersFunction(function registry.Function) bool {
+	return function.Shell == "" || strings.EqualFold(function.Shell, string(g.shellType))
+}
+
+// withoutName returns a copy of a module without its definitions of a name
+func (g *Generator) withoutName(module Module, kind, name string) Module {
+	switch kind {
+	case KindAlias:
+		module.Aliases = filter(module.Aliases, func(alias registry.Alias) bool {
+			return alias.Name != name
+		})
+	case KindFunction:
+		module.Functions = filter(module.Functions, func(function registry.Function) bool {
+			return function.Name != name || !g.rendersFunction(function)
+		})
+	case KindEnvironment:
+		module.Environment = filter(module.Environment, func(env registry.Environment) bool {
+			return env.Name != name
+		})
+	}
+	return module
+}
+
+// filter returns a new slice with the items keep returns true for
+func filter[T any](items []T, keep func(T) bool) []T {
+	var kept []T
+	for _, item := range items {
+		if keep(item) {
+			kept = append(kept, item)
+		}
+	}
+	return kept
+}
@@ -0,0 +1,123 @@
+package generator
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/griffin/go-shellify/internal/registry"
+)
+
+func collidingModules() []Module {
+	return []Module{
+		{
+			Registry: "main",
+			Module: registry.Module{
+				Name:        "kube",
+				Environment: []registry.Environment{{Name: "EDITOR", Value: "vim"}},
+				Aliases:     []registry.Alias{{Name: "k", Command: "kubectl"}},
+				Functions:   []registry.Function{{Name: "ctx", Commands: []string{"kubectl config use-context \"$1\""}}},
+			},
+		},
+		{
+			Registry: "extra",
+			Module: registry.Module{
+				Name:        "editors",
+				Environment: []registry.Environment{{Name: "EDITOR", Value: "nano"}, {Name: "PAGER", Value: "less"}},
+				Aliases:     []registry.Alias{{Name: "k", Command: "kak"}},
+				Functions:   []registry.Function{{Name: "ctx", Shell: "fish", Commands: []string{"echo fish"}}},
+			},
+		},
+	}
+}
+
+func TestResolveCollisions(t *testing.T) {
+	tests := []struct {
+		name       string
+		precedence Precedence
+		expected   []string // "kind name winner chosen" of each collision
+		script     []string
+		absent     []string
+	}{
+		{
+			name:     "last module wins by default",
+			expected: []string{"env EDITOR editors false", "alias k editors false"},
+			script:   []string{`EDITOR="nano"`, "alias k='kak'", "ctx() {"},
+			absent:   []string{`EDITOR="vim"`, "alias k='kubectl'"},
+		},
+		{
+			name:       "precedence order",
+			precedence: Precedence{Order: []string{"kube"}},
+			expected:   []string{"env EDITOR kube true", "alias k kube true"},
+			script:     []string{`EDITOR="vim"`, "alias k='kubectl'", `PAGER="less"`},
+			absent:     []string{`EDITOR="nano"`, "alias k='kak'"},
+		},
+		{
+			name: "override beats order",
+			precedence: Precedence{
+				Order:       []string{"kube"},
+				Environment: map[string]string{"EDITOR": "editors"},
+				Aliases:     map[string]string{"k": "unknown"},
+			},
+			expected: []string{"env EDITOR editors true", "alias k kube true"},
+			script:   []string{`EDITOR="nano"`, "alias k='kubectl'"},
+			absent:   []string{`EDITOR="vim"`, "alias k='kak'"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gen, _ := New("bash")
+			modules := collidingModules()
+
+			resolved, collisions := gen.ResolveCollisions(modules, tt.precedence)
+
+			var got []string
+			for _, c := range collisions {
+				got = append(got, fmt.Sprintf("%s %s %s %v", c.Kind, c.Name, c.Sources[c.Winner].Module, c.Chosen))
+			}
+			if strings.Join(got, "\n") != strings.Join(tt.expected, "\n") {
+				t.Errorf("ResolveCollisions() = %v, expected %v", got, tt.expected)
+			}
+
+			script, err := gen.Generate(resolved)
+			if err != nil {
+				t.Fatalf("Generate() failed: %v", err)
+			}
+			for _, expected := range tt.script {
+				if !strings.Contains(script, expected) {
+					t.Errorf("script should contain %q:\n%s", expected, script)
+				}
+			}
+			for _, absent := range tt.absent {
+				if strings.Contains(script, absent) {
+					t.Errorf("script should not contain %q:\n%s", absent, script)
+				}
+			}
+
+			if len(modules[0].Aliases) != 1 || len(modules[1].Environment) != 2 {
+				t.Error("ResolveCollisions() modified the given modules")
+			}
+		})
+	}
+}
+
+func TestOverrideEnvironment(t *testing.T) {
+	modules := collidingModules()
+	overridden, unused := OverrideEnvironment(modules, map[string]string{"EDITOR": "code --wait", "BROWSER": "firefox"})
+
+	if fmt.Sprint(unused) != "[BROWSER]" {
+		t.Errorf("OverrideEnvironment() unused = %v, expected [BROWSER]", unused)
+	}
+	for i, module := range overridden {
+		if module.Environment[0].Value != "code --wait" {
+			t.Errorf("module %s sets EDITOR to %q, expected the override", module.Name, module.Environment[0].Value)
+		}
+		if modules[i].Environment[0].Value == "code --wait" {
+			t.Errorf("OverrideEnvironment() changed the given module %s", modules[i].Name)
+		}
+	}
+	if overridden[1].Environment[1].Value != "less" {
+		t.Errorf("PAGER = %q, expected it unchanged", overridden[1].Environment[1].Value)
+	}
+}
@@ -0,0 +1,214 @@
+package generator
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/griffin/go-shellify/internal/condition"
+	"github.com/griffin/go-shellify/internal/registry"
+)
+
+// Kinds of items a runtime condition can guard, besides the collision kinds
+const (
+	kindPath = "path"
+	kindFile = "file"
+)
+
+// guardLimitedRenderer is implemented by renderers for shells that can't
+// guard some kinds of items with a runtime condition
+type guardLimitedRenderer interface {
+	unguarded(kind string) bool
+}
+
+// conditionSyntax renders the runtime parts of a condition in a shell's
+// syntax, see renderCondition
+type conditionSyntax struct {
+	command func(name string) string            // The command is found
+	env     func(name string) string            // The variable is set and not empty
+	compare func(name, op, value string) string // The variable compared with text, op is == or !=
+	not     func(x string) string
+	and     func(x, y string) string
+	or      func(x, y string) string
+	group   func(x string) string // Groups an operand of a different operator
+}
+
+// renderCondition renders a condition simplified by condition.Simplify,
+// which only has runtime facts left
+func renderCondition(e condition.Expr, s conditionSyntax) string {
+	switch e := e.(type) {
+	case condition.Command:
+		return s.command(e.Name)
+	case condition.Env:
+		return s.env(e.Name)
+	case condition.Compare:
+		// Simplify leaves the variable on the left and text on the right
+		return s.compare(e.X.(condition.Env).Name, e.Op, e.Y.(condition.Text).Value)
+	case condition.Not:
+		return s.not(conditionOperand(e.X, nil, s))
+	case condition.And:
+		return s.and(conditionOperand(e.X, e, s), conditionOperand(e.Y, e, s))
+	case condition.Or:
+		return s.or(conditionOperand(e.X, e, s), conditionOperand(e.Y, e, s))
+	}
+	return ""
+}
+
+// conditionOperand renders an operand, grouping it when it is an And or Or
+// other than its parent. Shells disagree on the precedence of && and ||, so
+// it is never relied on.
+func conditionOperand(e, parent condition.Expr, s conditionSyntax) string {
+	rendered := renderCondition(e, s)
+	switch e.(type) {
+	case condition.And:
+		if _, ok := parent.(condition.And); !ok {
+			return s.group(rendered)
+		}
+	case condition.Or:
+		if _, ok := parent.(condition.Or); !ok {
+			return s.group(rendered)
+		}
+	}
+	return rendered
+}
+
+// hasCommand reports whether a condition tests for a command
+func hasCommand(e condition.Expr) bool {
+	switch e := e.(type) {
+	case condition.Command:
+		return true
+	case condition.Not:
+		return hasCommand(e.X)
+	case condition.And:
+		return hasCommand(e.X) || hasCommand(e.Y)
+	case condition.Or:
+		return hasCommand(e.X) || hasCommand(e.Y)
+	}
+	return false
+}
+
+// indentBlock indents the lines of rendered code, leaving empty lines empty
+func indentBlock(code, prefix string) string {
+	var b strings.Builder
+	for _, line := range strings.Split(strings.TrimSuffix(code, "\n"), "\n") {
+		if line != "" {
+			b.WriteString(prefix)
+		}
+		b.WriteString(line + "\n")
+	}
+	return b.String()
+}
+
+// Applicable reports whether a module is generated on this machine: its
+// platforms include the target OS and its when condition isn't false by
+// the static facts. The returned module only has the items whose conditions
+// can hold.
+func (g *Generator) Applicable(module registry.Module) (registry.Module, bool, error) {
+	if len(module.Platforms) > 0 {
+		found := false
+		for _, platform := range module.Platforms {
+			found = found || strings.EqualFold(platform, g.facts.OS)
+		}
+		if !found {
+			return module, false, nil
+		}
+	}
+
+	var err error
+	possible := func(when string) bool {
+		e, parseErr := condition.Evaluate(when, g.facts)
+		if parseErr != nil {
+			err = parseErr
+			return false
+		}
+		value, static := condition.Constant(e)
+		return value || !static
+	}
+
+	if !possible(module.When) {
+		if err != nil {
+			return module, false, fmt.Errorf("module %s: %w", module.Name, err)
+		}
+		return module, false, nil
+	}
+	module.Environment = filter(module.Environment, func(env registry.Environment) bool { return possible(env.When) })
+	module.Aliases = filter(module.Aliases, func(alias registry.Alias) bool { return possible(alias.When) })
+	module.Functions = filter(module.Functions, func(function registry.Function) bool { return possible(function.When) })
+	module.PathEntries = filter(module.PathEntries, func(entry registry.PathEntry) bool { return possible(entry.When) })
+	if err != nil {
+		return module, false, fmt.Errorf("module %s: %w", module.Name, err)
+	}
+	return module, true, nil
+}
+
+// runtimeCondition returns the part of a condition left for the shell to
+// evaluate, or nil when the static facts decide it
+func (g *Generator) runtimeCondition(when string) (condition.Expr, error) {
+	e, err := condition.Evaluate(when, g.facts)
+	if err != nil {
+		return nil, err
+	}
+	if _, static := condition.Constant(e); static {
+		return nil, nil
+	}
+	return e, nil
+}
+
+// guard wraps rendered code in the runtime part of a condition
+func (g *Generator) guard(when, code string) (string, error) {
+	e, err := g.runtimeCondition(when)
+	if err != nil || e == nil || code == "" {
+		return code, err
+	}
+	return g.renderer.guard(e, code), nil
+}
+
+// unguarded describes the items of a module that need a runtime condition
+// the generator's shell can't guard them with
+func (g *Generator) unguarded(module registry.Module) []string {
+	limited, ok := g.renderer.(guardLimitedRenderer)
+	if !ok {
+		return nil
+	}
+
+	var reasons []string
+	check := func(kind, name, when string) {
+		if e, _ := g.runtimeCondition(when); e != nil && limited.unguarded(kind) {
+			reasons = append(reasons, fmt.Sprintf("%s %s: %s can't check %s at startup", kind, name, g.shellType, e))
+		}
+	}
+
+	if e, _ := g.runtimeCondition(module.When); e != nil {
+		// The condition guards every item of the module
+		kinds := map[string]bool{
+			KindEnvironment: len(module.Environment) > 0,
+			kindPath:        len(module.PathEntries) > 0,
+			KindAlias:       len(module.Aliases) > 0,
+			KindFunction:    len(module.Functions) > 0,
+		}
+		for _, file := range module.Files {
+			kinds[kindFile] = kinds[kindFile] || file.Source
+		}
+		for _, kind := range []string{KindEnvironment, kindPath, KindAlias, KindFunction, kindFile} {
+			if kinds[kind] && limited.unguarded(kind) {
+				reasons = append(reasons, fmt.Sprintf("module condition: %s can't check %s at startup for %s items", g.shellType, e, kind))
+			}
+		}
+	}
+	for _, env := range module.Environment {
+		check(KindEnvironment, env.Name, env.When)
+	}
+	for _, entry := range module.PathEntries {
+		dir := entry.Directory
+		if dir == "" {
+			dir = entry.Path
+		}
+		check(kindPath, dir, entry.When)
+	}
+	for _, alias := range module.Aliases {
+		check(KindAlias, alias.Name, alias.When)
+	}
+	for _, function := range module.Functions {
+		check(KindFunction, function.Name, function.When)
+	}
+	return reasons
+}
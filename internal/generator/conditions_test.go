@@ -0,0 +1,174 @@
+package generator
+
+import (
+	"os/exec"
+	"strings"
+	"testing"
+
+	"github.com/griffin/go-shellify/internal/condition"
+	"github.com/griffin/go-shellify/internal/registry"
+)
+
+var linuxFacts = condition.Facts{OS: "linux", Arch: "amd64", Shell: "bash", Hostname: "work-laptop"}
+
+func TestApplicable(t *testing.T) {
+	gen, _ := New("bash")
+	gen.facts = linuxFacts
+
+	tests := []struct {
+		name    string
+		module  registry.Module
+		ok      bool
+		aliases int
+		wantErr bool
+	}{
+		{name: "no conditions", module: registry.Module{Aliases: []registry.Alias{{Name: "a"}}}, ok: true, aliases: 1},
+		{name: "other platform", module: registry.Module{Platforms: []string{"darwin", "windows"}}, ok: false},
+		{name: "matching platform", module: registry.Module{Platforms: []string{"Linux"}}, ok: true},
+		{name: "false condition", module: registry.Module{When: `os == "darwin"`}, ok: false},
+		{name: "runtime condition", module: registry.Module{When: `os == "linux" && command("kubectl")`}, ok: true},
+		{
+			name: "false item condition",
+			module: registry.Module{Aliases: []registry.Alias{
+				{Name: "a", When: `hostname == "work-laptop"`},
+				{Name: "b", When: `hostname != "work-laptop"`},
+				{Name: "c", When: `env("CI") == ""`},
+			}},
+			ok:      true,
+			aliases: 2,
+		},
+		{name: "invalid condition", module: registry.Module{When: `os = "linux"`}, wantErr: true},
+		{name: "invalid item condition", module: registry.Module{Aliases: []registry.Alias{{Name: "a", When: "nope"}}}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			module, ok, err := gen.Applicable(tt.module)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Applicable() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if ok != tt.ok {
+				t.Errorf("Applicable() = %v, expected %v", ok, tt.ok)
+			}
+			if ok && len(module.Aliases) != tt.aliases {
+				t.Errorf("Applicable() kept %d aliases, expected %d", len(module.Aliases), tt.aliases)
+			}
+		})
+	}
+}
+
+func TestRenderCondition(t *testing.T) {
+	e, err := condition.Evaluate(`command("kubectl") && (env("CI") == "" || !env("KUBECONFIG"))`, linuxFacts)
+	if err != nil {
+		t.Fatalf("Evaluate() failed: %v", err)
+	}
+
+	tests := []struct {
+		shell    string
+		syntax   conditionSyntax
+		expected string
+	}{
+		{"bash", posixCondition, `command -v 'kubectl' >/dev/null 2>&1 && { [ "${CI:-}" = '' ] || ! [ -n "${KUBECONFIG:-}" ]; }`},
+		{"fish", fishCondition, `command -q 'kubectl' && begin; test "$CI" = '' || not test -n "$KUBECONFIG"; end`},
+		{"powershell", powershellCondition, `(Get-Command 'kubectl' -ErrorAction SilentlyContinue) -and (("$env:CI" -ceq '') -or -not [bool]$env:KUBECONFIG)`},
+		{"nu", nuCondition, `((which 'kubectl' | length) > 0) and ((($env.CI? | default '') == '') or not (($env.KUBECONFIG? | default '') != ''))`},
+		{"elvish", elvishCondition, `(and (has-external 'kubectl') (or (==s $E:CI '') (not (!=s $E:KUBECONFIG ''))))`},
+		{"xonsh", xonshCondition, `shutil.which('kubectl') and (${...}.detype().get('CI', '') == '' or not ${...}.detype().get('KUBECONFIG', '') != '')`},
+		{"tcsh", cshCondition, "{ which 'kubectl' >& /dev/null } && ( \"`printenv CI`\" == '' || ! ( \"`printenv KUBECONFIG`\" != '' ) )"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.shell, func(t *testing.T) {
+			if rendered := renderCondition(e, tt.syntax); rendered != tt.expected {
+				t.Errorf("renderCondition() = %s, expected %s", rendered, tt.expected)
+			}
+		})
+	}
+}
+
+func TestGenerateConditions(t *testing.T) {
+	gen, _ := New("bash")
+	gen.facts = linuxFacts
+
+	modules := []Module{
+		{Module: registry.Module{Name: "mac-only", When: `os == "darwin"`, Aliases: []registry.Alias{{Name: "o", Command: "open"}}}},
+		{Module: registry.Module{
+			Name: "tools",
+			When: `shell == "bash" && env("TOOLS_OFF") != "1"`,
+			Environment: []registry.Environment{
+				{Name: "ON_LINUX", Value: "yes", Export: true, When: `os == "linux"`},
+				{Name: "ON_MAC", Value: "yes", Export: true, When: `os == "darwin"`},
+			},
+			Aliases: []registry.Alias{
+				{Name: "found", Command: "echo found", When: `command("sh")`},
+				{Name: "missing", Command: "echo missing", When: `command("go-shellify-missing-command")`},
+			},
+		}},
+	}
+
+	script, err := gen.Generate(modules)
+	if err != nil {
+		t.Fatalf("Generate() failed: %v", err)
+	}
+
+	for _, expected := range []string{
+		"# Module: mac-only skipped, its platforms or condition don't match",
+		"if [ \"${TOOLS_OFF:-}\" != '1' ]; then\n  export ON_LINUX=\"yes\"\n  if command -v 'sh' >/dev/null 2>&1; then\n    alias found='echo found'\n  fi\n",
+	} {
+		if !strings.Contains(script, expected) {
+			t.Errorf("script does not contain %q:\n%s", expected, script)
+		}
+	}
+	if strings.Contains(script, "ON_MAC") || strings.Contains(script, "alias o=") {
+		t.Errorf("script contains items whose condition is false:\n%s", script)
+	}
+
+	bash, err := exec.LookPath("bash")
+	if err != nil {
+		t.Skip("bash not available")
+	}
+	for _, tt := range []struct {
+		env      string
+		expected string
+	}{
+		{"TOOLS_OFF=", "yes found"},
+		{"TOOLS_OFF=1", ""},
+	} {
+		cmd := exec.Command(bash, "--norc", "-c", script+"\nshopt -s expand_aliases\necho $ON_LINUX $(alias found missing 2>/dev/null | grep -o 'found' | head -1)")
+		cmd.Env = []string{"PATH=/usr/bin:/bin", tt.env}
+		output, _ := cmd.Output()
+		if strings.TrimSpace(string(output)) != tt.expected {
+			t.Errorf("with %s the script printed %q, expected %q", tt.env, output, tt.expected)
+		}
+	}
+}
+
+func TestUnguarded(t *testing.T) {
+	module := registry.Module{
+		Name:    "kube",
+		Shells:  []string{"nu", "cmd"},
+		When:    `command("kubectl")`,
+		Aliases: []registry.Alias{{Name: "k", Command: "kubectl"}},
+		Environment: []registry.Environment{
+			{Name: "KUBE_EDITOR", Value: "vim", When: `env("EDITOR") == ""`},
+		},
+	}
+
+	nu, _ := New("nu")
+	if reasons := nu.Unsupported(module); len(reasons) != 1 || !strings.Contains(reasons[0], "for alias items") {
+		t.Errorf("nu Unsupported() = %v, expected the module condition on aliases", reasons)
+	}
+
+	cmd, _ := New("cmd")
+	if reasons := cmd.Unsupported(module); len(reasons) != 3 || !strings.Contains(reasons[2], `env KUBE_EDITOR: cmd can't check env("EDITOR") == ""`) {
+		t.Errorf("cmd Unsupported() = %v, expected the module and variable conditions", reasons)
+	}
+
+	module.When = `shell == "nu"`
+	if reasons := nu.Unsupported(module); len(reasons) != 0 {
+		t.Errorf("nu Unsupported() = %v, expected static conditions to be supported", reasons)
+	}
+}
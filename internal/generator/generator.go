@@ -0,0 +1,352 @@
+package generator
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/griffin/go-shellify/internal/condition"
+	"github.com/griffin/go-shellify/internal/quote"
+	"github.com/griffin/go-shellify/internal/registry"
+	"github.com/griffin/go-shellify/internal/shell"
+)
+
+// Module is a module to render together with where it came from
+type Module struct {
+	registry.Module
+	Registry string // Name of the registry the module was loaded from
+	Dir      string // Directory of the module in the registry cache
+	Checksum string // sha256 of Dir at generation time, empty for approved snapshots, see registry.ModuleChecksum
+}
+
+// renderer renders module items for a specific shell
+type renderer interface {
+	comment(text string) string
+	env(env registry.Environment) string
+	alias(alias registry.Alias) string
+	function(function registry.Function) string
+	pathEntry(dir string, prepend bool) string
+	source(path string) string
+	check(check registry.Check) string
+	// guard wraps code in a runtime condition, see renderCondition
+	guard(when condition.Expr, code string) string
+}
+
+// limitedRenderer is implemented by renderers for shells that can't express
+// every module item
+type limitedRenderer interface {
+	// unsupported describes the items of a module the shell can't express.
+	// Functions are limited to the ones generated for the shell.
+	unsupported(module registry.Module) []string
+}
+
+// Shells are the shells scripts can be generated for
+var Shells = []string{
+	string(shell.Bash), string(shell.Zsh), string(shell.Fish), string(shell.PowerShell),
+	string(shell.Nu), string(shell.Elvish), string(shell.Xonsh),
+	string(shell.Tcsh), string(shell.Csh), string(shell.Cmd),
+}
+
+// Generator renders enabled modules into a shell init script
+type Generator struct {
+	shellType shell.ShellType
+	renderer  renderer
+	facts     condition.Facts // Static facts when conditions are evaluated with
+}
+
+// New creates a generator for a shell type
+func New(shellType string) (*Generator, error) {
+	var r renderer
+	switch shell.ShellType(shellType) {
+	case shell.Bash, shell.Zsh:
+		r = posixRenderer{}
+	case shell.Fish:
+		r = fishRenderer{}
+	case shell.PowerShell:
+		r = powershellRenderer{}
+	case shell.Nu:
+		r = nuRenderer{}
+	case shell.Elvish:
+		r = elvishRenderer{}
+	case shell.Xonsh:
+		r = xonshRenderer{}
+	case shell.Tcsh, shell.Csh:
+		r = cshRenderer{shell: shellType}
+	case shell.Cmd:
+		r = cmdRenderer{}
+	default:
+		return nil, fmt.Errorf("unsupported shell for generation: %s", shellType)
+	}
+
+	return &Generator{
+		shellType: shell.ShellType(shellType),
+		renderer:  r,
+		facts:     condition.HostFacts(shellType),
+	}, nil
+}
+
+// Shell returns the shell the generator renders for
+func (g *Generator) Shell() string {
+	return string(g.shellType)
+}
+
+// Supports reports whether a module can be used in the generator's shell
+func (g *Generator) Supports(module registry.Module) bool {
+	if len(module.Shells) > 0 {
+		for _, s := range module.Shells {
+			if strings.EqualFold(s, string(g.shellType)) {
+				return true
+			}
+		}
+		return false
+	}
+	switch g.shellType {
+	case shell.Nu, shell.Elvish, shell.Xonsh:
+		// These shells aren't POSIX-like, so modules have to be written for them
+		return strings.EqualFold(module.Shell, string(g.shellType))
+	}
+	return module.Shell == "" || strings.EqualFold(module.Shell, string(g.shellType))
+}
+
+// Unsupported describes the items of a module the generator's shell can't
+// express. Generate refuses such modules rather than emitting broken code.
+func (g *Generator) Unsupported(module registry.Module) []string {
+	module.Functions = filter(module.Functions, g.rendersFunction)
+
+	var reasons []string
+	if limited, ok := g.renderer.(limitedRenderer); ok {
+		reasons = limited.unsupported(module)
+	}
+	return append(reasons, g.unguarded(module)...)
+}
+
+// Generate renders the modules into a single script
+func (g *Generator) Generate(modules []Module) (string, error) {
+	var b strings.Builder
+
+	b.WriteString(g.renderer.comment("Generated by go-shellify - do not edit, changes are overwritten"))
+
+	for _, module := range modules {
+		if !g.Supports(module.Module) {
+			return "", fmt.Errorf("module %s does not support %s", module.Name, g.shellType)
+		}
+		applicable, ok, err := g.Applicable(module.Module)
+		if err != nil {
+			return "", err
+		}
+		if !ok {
+			b.WriteString("\n")
+			b.WriteString(g.renderer.comment(fmt.Sprintf("Module: %s skipped, its platforms or condition don't match", module.Name)))
+			continue
+		}
+		module.Module = applicable
+		if reasons := g.Unsupported(module.Module); len(reasons) > 0 {
+			return "", fmt.Errorf("module %s can't be generated for %s: %s", module.Name, g.shellType, strings.Join(reasons, "; "))
+		}
+
+		b.WriteString("\n")
+		header := module.Name
+		if module.Version != "" {
+			header += " " + module.Version
+		}
+		if module.Registry != "" {
+			header += " (" + module.Registry + ")"
+		}
+		b.WriteString(g.renderer.comment("Module: " + header))
+		if module.Checksum != "" {
+			b.WriteString(g.renderer.comment("sha256: " + module.Checksum))
+		}
+
+		body, err := g.renderItems(module)
+		if err != nil {
+			return "", fmt.Errorf("module %s: %w", module.Name, err)
+		}
+		if body, err = g.guard(module.When, body); err != nil {
+			return "", fmt.Errorf("module %s: %w", module.Name, err)
+		}
+		b.WriteString(body)
+	}
+
+	if g.shellType == shell.Cmd {
+		// cmd.exe misparses labels and blocks in batch files with LF endings
+		return strings.ReplaceAll(b.String(), "\n", "\r\n"), nil
+	}
+	return b.String(), nil
+}
+
+// HasCode reports whether a generated script does more than hold comments,
+// which it doesn't when every module or item was skipped
+func (g *Generator) HasCode(script string) bool {
+	comment := strings.TrimSpace(g.renderer.comment(""))
+	for _, line := range strings.Split(script, "\n") {
+		line = strings.TrimSpace(line)
+		if line != "" && !strings.HasPrefix(line, comment) {
+			return true
+		}
+	}
+	return false
+}
+
+// renderItems renders the items of a module, each guarded by its condition
+func (g *Generator) renderItems(module Module) (string, error) {
+	var b strings.Builder
+	write := func(when, code string) error {
+		guarded, err := g.guard(when, code)
+		b.WriteString(guarded)
+		return err
+	}
+
+	for _, env := range module.Environment {
+		if !quote.IsName(env.Name) {
+			b.WriteString(g.renderer.comment(fmt.Sprintf("Skipped environment variable %q: invalid name", env.Name)))
+			continue
+		}
+		if err := write(env.When, g.renderer.env(env)); err != nil {
+			return "", err
+		}
+	}
+	for _, entry := range module.PathEntries {
+		dir := entry.Directory
+		if dir == "" {
+			dir = entry.Path
+		}
+		if dir != "" {
+			if err := write(entry.When, g.renderer.pathEntry(dir, entry.Prepend)); err != nil {
+				return "", err
+			}
+		}
+	}
+	for _, alias := range module.Aliases {
+		if err := write(alias.When, g.renderer.alias(alias)); err != nil {
+			return "", err
+		}
+	}
+	for _, function := range module.Functions {
+		if !g.rendersFunction(function) {
+			continue
+		}
+		if err := write(function.When, g.renderer.function(function)); err != nil {
+			return "", err
+		}
+	}
+	for _, file := range module.Files {
+		if !file.Source {
+			continue
+		}
+		if file.Content != "" {
+			b.WriteString(strings.TrimRight(file.Content, "\n") + "\n")
+			continue
+		}
+		path := file.Path
+		if !filepath.IsAbs(path) && module.Dir != "" {
+			path = filepath.Join(module.Dir, path)
+		}
+		b.WriteString(g.renderer.source(path))
+	}
+	for _, check := range module.Checks {
+		if len(check.OnSuccess) == 0 && len(check.OnFailure) == 0 {
+			continue
+		}
+		if check.Type == "env" && !quote.IsName(check.Variable) {
+			b.WriteString(g.renderer.comment(fmt.Sprintf("Skipped check %s: invalid variable name %q", check.Name, check.Variable)))
+			continue
+		}
+		b.WriteString(g.renderer.check(check))
+	}
+
+	return b.String(), nil
+}
+
+// FileName returns the script file name for a base name in the generator's shell
+func (g *Generator) FileName(base string) string {
+	return base + shell.GetFileExtension(string(g.shellType))
+}
+
+// SourceLine returns the line that loads a generated script from an rc file
+func (g *Generator) SourceLine(path string) string {
+	return g.renderer.source(path)
+}
+
+// sourcedMarker precedes the source line added to rc files
+const sourcedMarker = "# Added by go-shellify"
+
+// EnsureSourced adds the source line to an rc file unless it is already
+// there, replacing a line added before for another script path
+func EnsureSourced(rcPath, line string) (bool, error) {
+	existing, err := os.ReadFile(rcPath)
+	if err != nil && !os.IsNotExist(err) {
+		return false, fmt.Errorf("reading %s: %w", rcPath, err)
+	}
+	if strings.Contains(string(existing), strings.TrimSpace(line)) {
+		return false, nil
+	}
+
+	if updated, ok := replaceSourced(string(existing), line); ok {
+		if err := os.WriteFile(rcPath, []byte(updated), 0644); err != nil {
+			return false, fmt.Errorf("writing %s: %w", rcPath, err)
+		}
+		return true, nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(rcPath), 0755); err != nil {
+		return false, fmt.Errorf("creating %s: %w", filepath.Dir(rcPath), err)
+	}
+
+	file, err := os.OpenFile(rcPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return false, fmt.Errorf("opening %s: %w", rcPath, err)
+	}
+	defer file.Close()
+
+	if _, err := file.WriteString("\n" + sourcedMarker + "\n" + line); err != nil {
+		return false, fmt.Errorf("writing %s: %w", rcPath, err)
+	}
+
+	return true, nil
+}
+
+// replaceSourced swaps the lines after the go-shellify marker for the source
+// line. They were rendered for the same shell, so they span as many lines.
+func replaceSourced(content, line string) (string, bool) {
+	lines := strings.SplitAfter(content, "\n")
+	count := strings.Count(line, "\n")
+	for i, l := range lines {
+		if strings.TrimSpace(l) != sourcedMarker || i+1+count > len(lines) {
+			continue
+		}
+		return strings.Join(lines[:i+1], "") + line + strings.Join(lines[i+1+count:], ""), true
+	}
+	return content, false
+}
+
+// WriteScript writes a generated script, keeping a backup of the previous
+// version when backup is set
+func WriteScript(path, content string, backup bool) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("creating output directory: %w", err)
+	}
+
+	if backup {
+		if existing, err := os.ReadFile(path); err == nil {
+			if err := os.WriteFile(path+".bak", existing, 0644); err != nil {
+				return fmt.Errorf("backing up %s: %w", path, err)
+			}
+		}
+	}
+
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		return fmt.Errorf("writing %s: %w", path, err)
+	}
+
+	return nil
+}
+
+// indent indents every line of a command list
+func indent(lines []string, prefix string) string {
+	var b strings.Builder
+	for _, line := range lines {
+		b.WriteString(prefix + line + "\n")
+	}
+	return b.String()
+}
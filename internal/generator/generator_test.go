@@ -0,0 +1,610 @@
+package generator
+
+import (
+	"flag"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/griffin/go-shellify/internal/registry"
+)
+
+var update = flag.Bool("update", false, "update golden files")
+
+func testModule() Module {
+	return Module{
+		Registry: "test-registry",
+		Module: registry.Module{
+			Name:        "git-helpers",
+			Version:     "1.0.0",
+			Environment: []registry.Environment{{Name: "GIT_EDITOR", Value: "vim", Export: true}},
+			PathEntries: []registry.PathEntry{{Directory: "$HOME/.local/bin", Prepend: true}},
+			Aliases:     []registry.Alias{{Name: "gs", Command: "git status -sb"}, {Name: "gq", Command: "echo 'quoted'"}},
+			Functions: []registry.Function{
+				{Name: "gclean", Commands: []string{"git clean -n"}},
+				{Name: "zonly", Shell: "zsh", Commands: []string{"echo zsh"}},
+			},
+			Checks: []registry.Check{{Name: "git", Type: "command", Command: "git", OnFailure: []string{"echo missing git"}}},
+		},
+	}
+}
+
+func TestGenerate(t *testing.T) {
+	tests := []struct {
+		shell    string
+		expected []string
+		absent   []string
+	}{
+		{
+			shell: "bash",
+			expected: []string{
+				"# Module: git-helpers 1.0.0 (test-registry)",
+				`export GIT_EDITOR="vim"`,
+				`export PATH="$HOME/.local/bin:$PATH"`,
+				"alias gs='git status -sb'",
+				`alias gq='echo '\''quoted'\'''`,
+				"gclean() {\n  git clean -n\n}",
+				"if command -v 'git' >/dev/null 2>&1; then",
+			},
+			absent: []string{"zonly"},
+		},
+		{
+			shell: "zsh",
+			expected: []string{
+				"zonly() {\n  echo zsh\n}",
+			},
+		},
+		{
+			shell: "fish",
+			expected: []string{
+				`set -gx GIT_EDITOR "vim"`,
+				"alias gs 'git status -sb'",
+				`alias gq 'echo \'quoted\''`,
+				"function gclean\n    git clean -n\nend",
+				"if command -q 'git'",
+			},
+			absent: []string{"zonly"},
+		},
+		{
+			shell: "powershell",
+			expected: []string{
+				`$env:GIT_EDITOR = "vim"`,
+				"Remove-Item Alias:gs -Force -ErrorAction SilentlyContinue\nfunction gs { git status -sb @args }",
+				"Remove-Item Alias:gclean -Force -ErrorAction SilentlyContinue\nfunction gclean {\n    git clean -n\n}",
+			},
+			absent: []string{"zonly"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.shell, func(t *testing.T) {
+			gen, err := New(tt.shell)
+			if err != nil {
+				t.Fatalf("New() failed: %v", err)
+			}
+
+			script, err := gen.Generate([]Module{testModule()})
+			if err != nil {
+				t.Fatalf("Generate() failed: %v", err)
+			}
+
+			for _, expected := range tt.expected {
+				if !strings.Contains(script, expected) {
+					t.Errorf("script does not contain %q:\n%s", expected, script)
+				}
+			}
+			for _, absent := range tt.absent {
+				if strings.Contains(script, absent) {
+					t.Errorf("script should not contain %q:\n%s", absent, script)
+				}
+			}
+		})
+	}
+}
+
+func TestGeneratedBashScriptRuns(t *testing.T) {
+	bash, err := exec.LookPath("bash")
+	if err != nil {
+		t.Skip("bash not available")
+	}
+
+	gen, _ := New("bash")
+	script, err := gen.Generate([]Module{testModule()})
+	if err != nil {
+		t.Fatalf("Generate() failed: %v", err)
+	}
+
+	cmd := exec.Command(bash, "-c", script+"\nshopt -s expand_aliases\necho \"$GIT_EDITOR\"\nalias gq")
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("script failed: %v, output: %s", err, output)
+	}
+	if !strings.Contains(string(output), "vim") || !strings.Contains(string(output), `echo '\''quoted'\''`) {
+		t.Errorf("unexpected output: %s", output)
+	}
+}
+
+func TestGeneratedBashScriptQuoting(t *testing.T) {
+	bash, err := exec.LookPath("bash")
+	if err != nil {
+		t.Skip("bash not available")
+	}
+
+	dir := t.TempDir()
+	marker := filepath.Join(dir, "pwned")
+	module := Module{
+		Registry: "test-registry",
+		Module: registry.Module{
+			Name: "hostile",
+			Environment: []registry.Environment{
+				{Name: "INJECTED", Value: "$(touch " + marker + ") `touch " + marker + "` \\\" 'q'", Export: true},
+				{Name: "X; touch " + marker + "; Y", Value: "x"},
+			},
+			PathEntries: []registry.PathEntry{{Directory: "$HOME/dir with spaces/$(touch " + marker + ")", Prepend: true}},
+		},
+	}
+
+	gen, _ := New("bash")
+	script, err := gen.Generate([]Module{module})
+	if err != nil {
+		t.Fatalf("Generate() failed: %v", err)
+	}
+
+	cmd := exec.Command(bash, "--norc", "-c", script+"\nprintf '%s\\n' \"$INJECTED\" \"${PATH%%:*}\"")
+	cmd.Env = []string{"HOME=/home/test", "PATH=/usr/bin:/bin"}
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("script failed: %v, output: %s", err, output)
+	}
+
+	if _, err := os.Stat(marker); err == nil {
+		t.Fatalf("generated script executed injected code:\n%s", script)
+	}
+	expected := "$(touch " + marker + ") `touch " + marker + "` \\\" 'q'\n" +
+		"/home/test/dir with spaces/$(touch " + marker + ")\n"
+	if string(output) != expected {
+		t.Errorf("output = %q, expected %q", output, expected)
+	}
+}
+
+func TestSupports(t *testing.T) {
+	gen, _ := New("fish")
+
+	tests := []struct {
+		name     string
+		module   registry.Module
+		expected bool
+	}{
+		{name: "no shell restriction", module: registry.Module{}, expected: true},
+		{name: "legacy shell field", module: registry.Module{Shell: "fish"}, expected: true},
+		{name: "other legacy shell", module: registry.Module{Shell: "bash"}, expected: false},
+		{name: "shells list", module: registry.Module{Shells: []string{"bash", "fish"}}, expected: true},
+		{name: "shells list without fish", module: registry.Module{Shells: []string{"bash", "zsh"}}, expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if result := gen.Supports(tt.module); result != tt.expected {
+				t.Errorf("Supports() = %v, expected %v", result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestGenerateNu(t *testing.T) {
+	gen, err := New("nu")
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+
+	module := testModule()
+	if gen.Supports(module.Module) {
+		t.Fatal("Supports() should reject modules that don't declare nu")
+	}
+
+	module.Shells = []string{"bash", "nu"}
+	module.Aliases = []registry.Alias{{Name: "ll", Command: "ls -l"}}
+	module.Functions = []registry.Function{{Name: "mkcd", Description: "Make and enter a directory", Commands: []string{"mkdir $args.0", "cd $args.0"}}}
+	module.Environment = append(module.Environment, registry.Environment{Name: "EDITOR_ARGS", Value: "-w ($HOME) 'x'"})
+	module.Checks = append(module.Checks, registry.Check{Name: "cfg", Type: "file", Path: "$HOME/.gitconfig", OnFailure: []string{"print 'no gitconfig'"}})
+
+	script, err := gen.Generate([]Module{module})
+	if err != nil {
+		t.Fatalf("Generate() failed: %v", err)
+	}
+
+	for _, expected := range []string{
+		"$env.GIT_EDITOR = 'vim'",
+		`$env.EDITOR_ARGS = $"-w \(($env.HOME? | default '')) 'x'"`,
+		`use std/util "path add"; path add $"($env.HOME? | default '')/.local/bin"`,
+		"alias ll = ls -l",
+		"# Make and enter a directory\ndef --wrapped mkcd [...args] {\n    mkdir $args.0\n    cd $args.0\n}",
+		"if (which 'git' | length) > 0 {\n}",
+		"if ($\"($env.HOME? | default '')/.gitconfig\" | path type) == 'file' {\n} else {\n    print 'no gitconfig'\n}",
+	} {
+		if !strings.Contains(script, expected) {
+			t.Errorf("script does not contain %q:\n%s", expected, script)
+		}
+	}
+}
+
+func TestGenerateElvish(t *testing.T) {
+	gen, err := New("elvish")
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+
+	module := testModule()
+	if gen.Supports(module.Module) {
+		t.Fatal("Supports() should reject modules that don't declare elvish")
+	}
+
+	module.Shells = []string{"bash", "elvish"}
+	module.Aliases = append(module.Aliases, registry.Alias{Name: "both", Command: "make && make install"})
+	if reasons := gen.Unsupported(module.Module); len(reasons) != 1 || !strings.Contains(reasons[0], "alias both: elvish has no &&") {
+		t.Errorf("Unsupported() = %v, expected the both alias", reasons)
+	}
+
+	module.Aliases = []registry.Alias{{Name: "g", Command: `git "$@"`}, {Name: "say", Command: "echo 'a && b'"}, {Name: "col2", Command: "awk '{print $2}'"}}
+	module.Functions = []registry.Function{
+		{Name: "mkcd", Description: "Make and enter a directory", Commands: []string{`mkdir -p "$1"`, "cd $1"}},
+		{Name: "field", Commands: []string{`awk '{print $'$1'}' "$2"`}},
+		{Name: "all", Shell: "elvish", Commands: []string{"echo $@args"}},
+	}
+	module.Environment = append(module.Environment, registry.Environment{Name: "PAGER_OPTS", Value: "it's $HOME"})
+	module.Checks = append(module.Checks, registry.Check{Name: "cfg", Type: "file", Path: "$HOME/.gitconfig", OnSuccess: []string{"echo found"}})
+
+	script, err := gen.Generate([]Module{module})
+	if err != nil {
+		t.Fatalf("Generate() failed: %v", err)
+	}
+
+	for _, expected := range []string{
+		"set-env GIT_EDITOR 'vim'",
+		"edit:add-var PAGER_OPTS 'it''s '$E:HOME",
+		"if (not (has-value $paths $E:HOME'/.local/bin')) { set paths = [$E:HOME'/.local/bin' $@paths] }",
+		"edit:add-var g~ {|@args| git $@args }",
+		"edit:add-var say~ {|@args| echo 'a && b' $@args }",
+		"# Make and enter a directory\nedit:add-var mkcd~ {|@args|\n    mkdir -p $args[0]\n    cd $args[0]\n}",
+		"edit:add-var col2~ {|@args| awk '{print $2}' $@args }",
+		"edit:add-var field~ {|@args|\n    awk '{print $'$args[0]'}' $args[1]\n}",
+		"edit:add-var all~ {|@args|\n    echo $@args\n}",
+		"if (has-external 'git') {\n} else {\n    echo missing git\n}",
+		"use path\nif (path:is-regular $E:HOME'/.gitconfig') {\n    echo found\n}",
+	} {
+		if !strings.Contains(script, expected) {
+			t.Errorf("script does not contain %q:\n%s", expected, script)
+		}
+	}
+}
+
+func TestGenerateXonsh(t *testing.T) {
+	gen, err := New("xonsh")
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+
+	module := testModule()
+	module.Shell = "xonsh"
+	module.Aliases = append(module.Aliases, registry.Alias{Name: "mkcd", Command: `mkdir "$1" && cd "$1"`})
+	if reasons := gen.Unsupported(module.Module); len(reasons) != 1 || !strings.Contains(reasons[0], "alias mkcd: xonsh aliases can't place arguments") {
+		t.Errorf("Unsupported() = %v, expected the mkcd alias", reasons)
+	}
+
+	module.Aliases = []registry.Alias{{Name: "g", Command: `git "$@"`}, {Name: "gq", Command: "echo 'quoted'"}}
+	module.Functions = []registry.Function{
+		{Name: "git-clean", Commands: []string{`git clean "$@"`}},
+		{Name: "noop"},
+		{Name: "col", Commands: []string{`awk '{print $2}' "$1"`}},
+		{Name: "first", Shell: "xonsh", Commands: []string{"print($1)"}},
+	}
+	module.Environment = append(module.Environment, registry.Environment{Name: "LOCAL", Value: "$HOME/x"})
+	module.Checks = append(module.Checks, registry.Check{Name: "ci", Type: "env", Variable: "CI", OnSuccess: []string{"echo ci"}})
+
+	script, err := gen.Generate([]Module{module})
+	if err != nil {
+		t.Fatalf("Generate() failed: %v", err)
+	}
+
+	for _, expected := range []string{
+		"$GIT_EDITOR = 'vim'",
+		"LOCAL = ${...}.detype().get('HOME', '') + '/x'",
+		"if ${...}.detype().get('HOME', '') + '/.local/bin' not in $PATH: $PATH.insert(0, ${...}.detype().get('HOME', '') + '/.local/bin')",
+		"aliases['g'] = 'git'",
+		`aliases['gq'] = 'echo \'quoted\''`,
+		"def _go_shellify_git_clean(args):\n    git clean @(args)\naliases['git-clean'] = _go_shellify_git_clean",
+		"def _go_shellify_noop(args):\n    pass\n",
+		"def _go_shellify_col(args):\n    awk '{print $2}' @(args[0])\n",
+		"def _go_shellify_first(args):\n    print($1)\n",
+		"import shutil\nif shutil.which('git'):\n    pass\nelse:\n    echo missing git\n",
+		"if 'CI' in ${...}:\n    echo ci\n",
+	} {
+		if !strings.Contains(script, expected) {
+			t.Errorf("script does not contain %q:\n%s", expected, script)
+		}
+	}
+}
+
+func TestGenerateTcsh(t *testing.T) {
+	gen, err := New("tcsh")
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+
+	module := testModule()
+	if reasons := gen.Unsupported(module.Module); len(reasons) != 1 || !strings.Contains(reasons[0], "function gclean") {
+		t.Errorf("Unsupported() = %v, expected the gclean function", reasons)
+	}
+	if _, err := gen.Generate([]Module{module}); err == nil || !strings.Contains(err.Error(), "tcsh has no functions") {
+		t.Errorf("Generate() error = %v, expected a function error", err)
+	}
+
+	module.Functions = nil
+	module.Environment = append(module.Environment, registry.Environment{Name: "GREETING", Value: "hi! it's $USER"})
+	module.Aliases = append(module.Aliases, registry.Alias{Name: "mkcd", Command: `mkdir -p "$1" && cd "$1"`}, registry.Alias{Name: "g", Command: `git "$@"`},
+		registry.Alias{Name: "col2", Command: "awk '{print $2}' $1"})
+	module.PathEntries = append(module.PathEntries, registry.PathEntry{Directory: "/opt/tools"})
+
+	script, err := gen.Generate([]Module{module})
+	if err != nil {
+		t.Fatalf("Generate() failed: %v", err)
+	}
+
+	for _, expected := range []string{
+		"setenv GIT_EDITOR 'vim'",
+		`set GREETING = 'hi\! it'\''s '"${USER}"`,
+		"alias gs 'git status -sb'",
+		`alias mkcd 'mkdir -p "\!:1" && cd "\!:1"'`,
+		`alias g 'git \!*'`,
+		`alias col2 'awk '\''{print $2}'\'' \!:1'`,
+		`if ( ":${PATH}:" !~ *:"${HOME}"'/.local/bin':* ) set path = ( "${HOME}"'/.local/bin' $path:q )`,
+		`if ( ":${PATH}:" !~ *:'/opt/tools':* ) set path = ( $path:q '/opt/tools' )`,
+		"if ( { which 'git' >& /dev/null } ) then\nelse\n  echo missing git\nendif",
+	} {
+		if !strings.Contains(script, expected) {
+			t.Errorf("script does not contain %q:\n%s", expected, script)
+		}
+	}
+}
+
+func TestGenerateCmd(t *testing.T) {
+	gen, err := New("cmd")
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+
+	module := testModule()
+	if _, err := gen.Generate([]Module{module}); err == nil || !strings.Contains(err.Error(), "cmd has no functions") {
+		t.Errorf("Generate() error = %v, expected a function error", err)
+	}
+
+	module.Functions = nil
+	module.Environment = append(module.Environment,
+		registry.Environment{Name: "GREETING", Value: "50% of $USER's & more"},
+		registry.Environment{Name: "CACHE", Value: "$HOME\\cache"})
+	module.Aliases = append(module.Aliases,
+		registry.Alias{Name: "mkcd", Command: `mkdir "$1" && cd "$1"`},
+		registry.Alias{Name: "g", Command: `git "$@"`},
+		registry.Alias{Name: "ll", Command: "dir /w | more"},
+		registry.Alias{Name: "quiet", Command: `echo "a|b" > nul 2>&1`},
+		registry.Alias{Name: "either", Command: "a || b"})
+	module.PathEntries = append(module.PathEntries, registry.PathEntry{Directory: `C:\Tools`})
+	module.Checks = append(module.Checks,
+		registry.Check{Name: "config", Type: "file", Path: `$HOME\.gitconfig`, OnSuccess: []string{"echo found"}},
+		registry.Check{Name: "tools", Type: "directory", Path: `C:\Tools`, OnSuccess: []string{"echo tools"}},
+		registry.Check{Name: "ci", Type: "env", Variable: "CI", OnSuccess: []string{"echo ci"}, OnFailure: []string{"echo local"}})
+
+	script, err := gen.Generate([]Module{module})
+	if err != nil {
+		t.Fatalf("Generate() failed: %v", err)
+	}
+
+	golden := filepath.Join("testdata", "cmd.golden")
+	if *update {
+		if err := os.WriteFile(golden, []byte(script), 0644); err != nil {
+			t.Fatalf("updating %s: %v", golden, err)
+		}
+	}
+	expected, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatalf("reading %s: %v", golden, err)
+	}
+	if script != string(expected) {
+		t.Errorf("script doesn't match %s, run go test -update to see the difference:\n%s", golden, script)
+	}
+}
+
+func TestDoskeyMacro(t *testing.T) {
+	tests := []struct {
+		command  string
+		expected string
+		wantErr  bool
+	}{
+		{"git status", "git status $*", false},
+		{`git "$@"`, "git $*", false},
+		{"ls $*", "ls $*", false},
+		{`mkdir "$1" && cd "$1"`, "", true},
+		{`mkdir "$1"; cd "$1"`, `mkdir "$1"$T cd "$1"`, false},
+		{`echo "a && b"`, `echo "a && b" $*`, false},
+		{"a | b > c < d", "a $B b $G c $L d $*", false},
+		{"a; b & c", "a$T b $T c $*", false},
+		{`echo "a|b&c"`, `echo "a|b&c" $*`, false},
+		{"run 2>&1", "run 2$G^&1 $*", false},
+		{"echo 100% $PATH ^", "echo 100%% $$PATH ^^ $*", false},
+		{"a || b", "", true},
+		{"a\nb", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.command, func(t *testing.T) {
+			macro, err := doskeyMacro(tt.command)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("doskeyMacro() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if macro != tt.expected {
+				t.Errorf("doskeyMacro() = %q, expected %q", macro, tt.expected)
+			}
+		})
+	}
+}
+
+func TestHasCode(t *testing.T) {
+	skipped := testModule()
+	skipped.Functions = nil
+	skipped.Environment = nil
+	skipped.PathEntries = nil
+	skipped.Checks = nil
+	skipped.Aliases = []registry.Alias{{Name: "either", Command: "a || b"}}
+
+	tests := []struct {
+		shell    string
+		modules  []Module
+		expected bool
+	}{
+		{"tcsh", nil, false},
+		{"bash", []Module{testModule()}, true},
+		{"cmd", []Module{skipped}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.shell, func(t *testing.T) {
+			gen, _ := New(tt.shell)
+			script, err := gen.Generate(tt.modules)
+			if err != nil {
+				t.Fatalf("Generate() failed: %v", err)
+			}
+			if got := gen.HasCode(script); got != tt.expected {
+				t.Errorf("HasCode() = %v, expected %v for:\n%s", got, tt.expected, script)
+			}
+		})
+	}
+}
+
+func TestNewUnsupportedShell(t *testing.T) {
+	if _, err := New("ksh"); err == nil {
+		t.Error("New() should reject shells without a renderer")
+	}
+}
+
+func TestWriteScriptBackup(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "go-shellify.sh")
+
+	if err := WriteScript(path, "first\n", true); err != nil {
+		t.Fatalf("WriteScript() failed: %v", err)
+	}
+	if err := WriteScript(path, "second\n", true); err != nil {
+		t.Fatalf("WriteScript() failed: %v", err)
+	}
+
+	backup, err := os.ReadFile(path + ".bak")
+	if err != nil || string(backup) != "first\n" {
+		t.Errorf("backup = %q, %v, expected the previous script", backup, err)
+	}
+}
+
+func TestEnsureSourced(t *testing.T) {
+	rcPath := filepath.Join(t.TempDir(), ".bashrc")
+	if err := os.WriteFile(rcPath, []byte("export EDITOR=vim\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	gen, _ := New("bash")
+	line := gen.SourceLine("/home/user/.go-shellify/generated/go-shellify.sh")
+
+	added, err := EnsureSourced(rcPath, line)
+	if err != nil || !added {
+		t.Fatalf("EnsureSourced() = %v, %v, expected the line to be added", added, err)
+	}
+
+	added, err = EnsureSourced(rcPath, line)
+	if err != nil || added {
+		t.Errorf("second EnsureSourced() = %v, %v, expected no change", added, err)
+	}
+
+	data, _ := os.ReadFile(rcPath)
+	if strings.Count(string(data), strings.TrimSpace(line)) != 1 {
+		t.Errorf("rc file should contain the source line once:\n%s", data)
+	}
+}
+
+func TestEnsureSourcedReplacesPreviousScript(t *testing.T) {
+	for _, shellType := range []string{"bash", "xonsh"} {
+		t.Run(shellType, func(t *testing.T) {
+			gen, _ := New(shellType)
+			oldLine := gen.SourceLine("/home/user/.go-shellify/generated/go-shellify.sh")
+			newLine := gen.SourceLine("/home/user/.go-shellify/generated/go-shellify-" + shellType + ".sh")
+
+			rcPath := filepath.Join(t.TempDir(), "rc")
+			content := "export EDITOR=vim\n\n# Added by go-shellify\n" + oldLine + "alias ll='ls -l'\n"
+			if err := os.WriteFile(rcPath, []byte(content), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			added, err := EnsureSourced(rcPath, newLine)
+			if err != nil || !added {
+				t.Fatalf("EnsureSourced() = %v, %v, expected the line to be replaced", added, err)
+			}
+
+			data, _ := os.ReadFile(rcPath)
+			expected := "export EDITOR=vim\n\n# Added by go-shellify\n" + newLine + "alias ll='ls -l'\n"
+			if string(data) != expected {
+				t.Errorf("rc file after EnsureSourced():\n%s\nexpected:\n%s", data, expected)
+			}
+		})
+	}
+}
+
+func TestLockfile(t *testing.T) {
+	gen, _ := New("bash")
+	module := testModule()
+	module.Checksum = "abc123"
+
+	script, err := gen.Generate([]Module{module})
+	if err != nil {
+		t.Fatalf("Generate() failed: %v", err)
+	}
+	if !strings.Contains(script, "# sha256: abc123") {
+		t.Errorf("script should record the module checksum:\n%s", script)
+	}
+
+	path := LockfilePath(filepath.Join(t.TempDir(), "go-shellify.sh"))
+	if lock, err := LoadLockfile(path); err != nil || lock != nil {
+		t.Fatalf("LoadLockfile() of a missing file = %v, %v", lock, err)
+	}
+	if err := gen.NewLockfile([]Module{module}).Save(path); err != nil {
+		t.Fatalf("Save() failed: %v", err)
+	}
+
+	lock, err := LoadLockfile(path)
+	if err != nil {
+		t.Fatalf("LoadLockfile() failed: %v", err)
+	}
+	locked := lock.Find("test-registry", "git-helpers")
+	if locked == nil || locked.Checksum != "abc123" || locked.Version != "1.0.0" {
+		t.Fatalf("Find() = %+v", locked)
+	}
+
+	tests := []struct {
+		name     string
+		version  string
+		checksum string
+		expected bool
+	}{
+		{name: "unchanged", version: "1.0.0", checksum: "abc123", expected: false},
+		{name: "edited in place", version: "1.0.0", checksum: "def456", expected: true},
+		{name: "new version", version: "1.1.0", checksum: "def456", expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			current := module
+			current.Version = tt.version
+			current.Checksum = tt.checksum
+			if result := locked.ChangedWithoutVersion(current); result != tt.expected {
+				t.Errorf("ChangedWithoutVersion() = %v, expected %v", result, tt.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,103 @@
+package generator
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Lockfile records exactly which module contents a script was generated from
+type Lockfile struct {
+	Shell       string         `json:"shell"`
+	GeneratedAt time.Time      `json:"generated_at"`
+	Modules     []LockedModule `json:"modules"`
+}
+
+// LockedModule is a module as it was when the script was generated
+type LockedModule struct {
+	Name     string `json:"name"`
+	Registry string `json:"registry"`
+	Version  string `json:"version,omitempty"`
+	Checksum string `json:"sha256,omitempty"`
+}
+
+// LockfilePath returns the lockfile path for a generated script
+func LockfilePath(scriptPath string) string {
+	return scriptPath + ".lock"
+}
+
+// NewLockfile records the modules a script is generated from
+func (g *Generator) NewLockfile(modules []Module) *Lockfile {
+	lock := &Lockfile{
+		Shell:       string(g.shellType),
+		GeneratedAt: time.Now(),
+		Modules:     make([]LockedModule, 0, len(modules)),
+	}
+	for _, module := range modules {
+		lock.Modules = append(lock.Modules, LockedModule{
+			Name:     module.Name,
+			Registry: module.Registry,
+			Version:  module.Version,
+			Checksum: module.Checksum,
+		})
+	}
+	return lock
+}
+
+// LoadLockfile reads a lockfile, returning nil when there is none yet
+func LoadLockfile(path string) (*Lockfile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("reading %s: %w", path, err)
+	}
+
+	var lock Lockfile
+	if err := json.Unmarshal(data, &lock); err != nil {
+		return nil, fmt.Errorf("decoding %s: %w", path, err)
+	}
+	return &lock, nil
+}
+
+// Save writes the lockfile
+func (l *Lockfile) Save(path string) error {
+	data, err := json.MarshalIndent(l, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encoding lockfile: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("creating %s: %w", filepath.Dir(path), err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("writing %s: %w", path, err)
+	}
+	return nil
+}
+
+// Find returns the locked state of a module, or nil when it isn't locked
+func (l *Lockfile) Find(registryName, name string) *LockedModule {
+	if l == nil {
+		return nil
+	}
+	for i := range l.Modules {
+		if l.Modules[i].Registry == registryName && l.Modules[i].Name == name {
+			return &l.Modules[i]
+		}
+	}
+	return nil
+}
+
+// ChangedWithoutVersion reports whether the module contents differ from the
+// locked ones although its version is unchanged, which points at an edit in
+// the cache or a registry republishing a version
+func (m *LockedModule) ChangedWithoutVersion(module Module) bool {
+	if m == nil || m.Checksum == "" || module.Checksum == "" {
+		return false
+	}
+	return m.Version == module.Version && m.Checksum != module.Checksum
+}
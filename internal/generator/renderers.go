@@ -0,0 +1,1051 @@
+package generator
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/griffin/go-shellify/internal/condition"
+	"github.com/griffin/go-shellify/internal/quote"
+	"github.com/griffin/go-shellify/internal/registry"
+	"github.com/griffin/go-shellify/internal/shell"
+)
+
+// posixRenderer renders for bash and zsh
+type posixRenderer struct{}
+
+func (posixRenderer) comment(text string) string {
+	return "# " + text + "\n"
+}
+
+func (posixRenderer) env(env registry.Environment) string {
+	if env.Export {
+		return fmt.Sprintf("export %s=%s\n", env.Name, quote.POSIXExpand(env.Value))
+	}
+	return fmt.Sprintf("%s=%s\n", env.Name, quote.POSIXExpand(env.Value))
+}
+
+func (posixRenderer) alias(alias registry.Alias) string {
+	return fmt.Sprintf("alias %s=%s\n", alias.Name, quote.POSIX(alias.Command))
+}
+
+func (posixRenderer) function(function registry.Function) string {
+	return fmt.Sprintf("%s() {\n%s}\n", function.Name, indent(function.Commands, "  "))
+}
+
+func (posixRenderer) pathEntry(dir string, prepend bool) string {
+	value := quote.POSIXExpand("$PATH:" + dir)
+	if prepend {
+		value = quote.POSIXExpand(dir + ":$PATH")
+	}
+	return fmt.Sprintf("case \":$PATH:\" in *:%s:*) ;; *) export PATH=%s ;; esac\n", quote.POSIXExpand(dir), value)
+}
+
+func (posixRenderer) source(path string) string {
+	return fmt.Sprintf("[ -f %s ] && . %s\n", quote.POSIX(path), quote.POSIX(path))
+}
+
+func (posixRenderer) check(check registry.Check) string {
+	var condition string
+	switch check.Type {
+	case "command":
+		condition = fmt.Sprintf("command -v %s >/dev/null 2>&1", quote.POSIX(check.Command))
+	case "file":
+		condition = fmt.Sprintf("[ -f %s ]", quote.POSIXExpand(check.Path))
+	case "directory":
+		condition = fmt.Sprintf("[ -d %s ]", quote.POSIXExpand(check.Path))
+	case "env":
+		condition = fmt.Sprintf("[ -n \"${%s:-}\" ]", check.Variable)
+	default:
+		return posixRenderer{}.comment(fmt.Sprintf("Skipped check %s: unsupported type %q", check.Name, check.Type))
+	}
+
+	var b strings.Builder
+	b.WriteString("if " + condition + "; then\n")
+	b.WriteString(indent(check.OnSuccess, "  "))
+	if len(check.OnSuccess) == 0 {
+		b.WriteString("  :\n")
+	}
+	if len(check.OnFailure) > 0 {
+		b.WriteString("else\n")
+		b.WriteString(indent(check.OnFailure, "  "))
+	}
+	b.WriteString("fi\n")
+	return b.String()
+}
+
+// posixCondition renders runtime conditions for POSIX shells
+var posixCondition = conditionSyntax{
+	command: func(name string) string { return fmt.Sprintf("command -v %s >/dev/null 2>&1", quote.POSIX(name)) },
+	env:     func(name string) string { return fmt.Sprintf("[ -n \"${%s:-}\" ]", name) },
+	compare: func(name, op, value string) string {
+		return fmt.Sprintf("[ \"${%s:-}\" %s %s ]", name, strings.TrimPrefix(op, "="), quote.POSIX(value))
+	},
+	not:   func(x string) string { return "! " + x },
+	and:   func(x, y string) string { return x + " && " + y },
+	or:    func(x, y string) string { return x + " || " + y },
+	group: func(x string) string { return "{ " + x + "; }" },
+}
+
+func (posixRenderer) guard(when condition.Expr, code string) string {
+	return fmt.Sprintf("if %s; then\n%sfi\n", renderCondition(when, posixCondition), indentBlock(code, "  "))
+}
+
+// fishRenderer renders for fish
+type fishRenderer struct{}
+
+func (fishRenderer) comment(text string) string {
+	return "# " + text + "\n"
+}
+
+func (fishRenderer) env(env registry.Environment) string {
+	scope := "-g"
+	if env.Export {
+		scope = "-gx"
+	}
+	return fmt.Sprintf("set %s %s %s\n", scope, env.Name, quote.FishExpand(env.Value))
+}
+
+func (fishRenderer) alias(alias registry.Alias) string {
+	return fmt.Sprintf("alias %s %s\n", alias.Name, quote.Fish(alias.Command))
+}
+
+func (fishRenderer) function(function registry.Function) string {
+	header := "function " + function.Name
+	if function.Description != "" {
+		header += " --description " + quote.Fish(function.Description)
+	}
+	return header + "\n" + indent(function.Commands, "    ") + "end\n"
+}
+
+func (fishRenderer) pathEntry(dir string, prepend bool) string {
+	quoted := quote.FishExpand(dir)
+	if prepend {
+		return fmt.Sprintf("contains -- %s $PATH; or set -gx PATH %s $PATH\n", quoted, quoted)
+	}
+	return fmt.Sprintf("contains -- %s $PATH; or set -gx PATH $PATH %s\n", quoted, quoted)
+}
+
+func (fishRenderer) source(path string) string {
+	return fmt.Sprintf("test -f %s; and source %s\n", quote.Fish(path), quote.Fish(path))
+}
+
+func (fishRenderer) check(check registry.Check) string {
+	var condition string
+	switch check.Type {
+	case "command":
+		condition = "command -q " + quote.Fish(check.Command)
+	case "file":
+		condition = "test -f " + quote.FishExpand(check.Path)
+	case "directory":
+		condition = "test -d " + quote.FishExpand(check.Path)
+	case "env":
+		condition = "set -q " + check.Variable
+	default:
+		return fishRenderer{}.comment(fmt.Sprintf("Skipped check %s: unsupported type %q", check.Name, check.Type))
+	}
+
+	var b strings.Builder
+	b.WriteString("if " + condition + "\n")
+	b.WriteString(indent(check.OnSuccess, "    "))
+	if len(check.OnFailure) > 0 {
+		b.WriteString("else\n")
+		b.WriteString(indent(check.OnFailure, "    "))
+	}
+	b.WriteString("end\n")
+	return b.String()
+}
+
+// fishCondition renders runtime conditions for fish
+var fishCondition = conditionSyntax{
+	command: func(name string) string { return "command -q " + quote.Fish(name) },
+	env:     func(name string) string { return fmt.Sprintf("test -n \"$%s\"", name) },
+	compare: func(name, op, value string) string {
+		return fmt.Sprintf("test \"$%s\" %s %s", name, strings.TrimPrefix(op, "="), quote.Fish(value))
+	},
+	not:   func(x string) string { return "not " + x },
+	and:   func(x, y string) string { return x + " && " + y },
+	or:    func(x, y string) string { return x + " || " + y },
+	group: func(x string) string { return "begin; " + x + "; end" },
+}
+
+func (fishRenderer) guard(when condition.Expr, code string) string {
+	return fmt.Sprintf("if %s\n%send\n", renderCondition(when, fishCondition), indentBlock(code, "    "))
+}
+
+// powershellRenderer renders for PowerShell
+type powershellRenderer struct{}
+
+func (powershellRenderer) comment(text string) string {
+	return "# " + text + "\n"
+}
+
+func (powershellRenderer) env(env registry.Environment) string {
+	if env.Export {
+		return fmt.Sprintf("$env:%s = %s\n", env.Name, quote.PowerShellExpand(env.Value))
+	}
+	return fmt.Sprintf("$%s = %s\n", env.Name, quote.PowerShellExpand(env.Value))
+}
+
+func (powershellRenderer) alias(alias registry.Alias) string {
+	// PowerShell aliases can't carry arguments, so wrap the command in a function
+	return powershellUnalias(alias.Name) + fmt.Sprintf("function %s { %s @args }\n", alias.Name, alias.Command)
+}
+
+func (powershellRenderer) function(function registry.Function) string {
+	return powershellUnalias(function.Name) + fmt.Sprintf("function %s {\n%s}\n", function.Name, indent(function.Commands, "    "))
+}
+
+// powershellUnalias removes an alias of the same name, such as the built-in
+// ls, cp or cat, which PowerShell would run instead of the function
+func powershellUnalias(name string) string {
+	return fmt.Sprintf("Remove-Item Alias:%s -Force -ErrorAction SilentlyContinue\n", name)
+}
+
+func (powershellRenderer) pathEntry(dir string, prepend bool) string {
+	quoted := quote.PowerShellExpand(dir)
+	value := fmt.Sprintf("$env:PATH + [IO.Path]::PathSeparator + %s", quoted)
+	if prepend {
+		value = fmt.Sprintf("%s + [IO.Path]::PathSeparator + $env:PATH", quoted)
+	}
+	return fmt.Sprintf("if (($env:PATH -split [IO.Path]::PathSeparator) -notcontains %s) { $env:PATH = %s }\n", quoted, value)
+}
+
+func (powershellRenderer) source(path string) string {
+	quoted := quote.PowerShell(path)
+	return fmt.Sprintf("if (Test-Path %s) { . %s }\n", quoted, quoted)
+}
+
+func (powershellRenderer) check(check registry.Check) string {
+	var condition string
+	switch check.Type {
+	case "command":
+		condition = fmt.Sprintf("Get-Command %s -ErrorAction SilentlyContinue", quote.PowerShell(check.Command))
+	case "file":
+		condition = fmt.Sprintf("Test-Path -PathType Leaf %s", quote.PowerShellExpand(check.Path))
+	case "directory":
+		condition = fmt.Sprintf("Test-Path -PathType Container %s", quote.PowerShellExpand(check.Path))
+	case "env":
+		condition = fmt.Sprintf("Test-Path env:%s", check.Variable)
+	default:
+		return powershellRenderer{}.comment(fmt.Sprintf("Skipped check %s: unsupported type %q", check.Name, check.Type))
+	}
+
+	var b strings.Builder
+	b.WriteString("if (" + condition + ") {\n")
+	b.WriteString(indent(check.OnSuccess, "    "))
+	b.WriteString("}")
+	if len(check.OnFailure) > 0 {
+		b.WriteString(" else {\n")
+		b.WriteString(indent(check.OnFailure, "    "))
+		b.WriteString("}")
+	}
+	b.WriteString("\n")
+	return b.String()
+}
+
+// powershellCondition renders runtime conditions for PowerShell. Text is
+// compared case-sensitively, as in the other shells.
+var powershellCondition = conditionSyntax{
+	command: func(name string) string {
+		return fmt.Sprintf("(Get-Command %s -ErrorAction SilentlyContinue)", quote.PowerShell(name))
+	},
+	env: func(name string) string { return "[bool]$env:" + name },
+	compare: func(name, op, value string) string {
+		operator := "-ceq"
+		if op == "!=" {
+			operator = "-cne"
+		}
+		return fmt.Sprintf("(\"$env:%s\" %s %s)", name, operator, quote.PowerShell(value))
+	},
+	not:   func(x string) string { return "-not " + x },
+	and:   func(x, y string) string { return x + " -and " + y },
+	or:    func(x, y string) string { return x + " -or " + y },
+	group: func(x string) string { return "(" + x + ")" },
+}
+
+func (powershellRenderer) guard(when condition.Expr, code string) string {
+	return fmt.Sprintf("if (%s) {\n%s}\n", renderCondition(when, powershellCondition), indentBlock(code, "    "))
+}
+
+// nuRenderer renders for Nushell
+type nuRenderer struct{}
+
+func (nuRenderer) comment(text string) string {
+	return "# " + text + "\n"
+}
+
+func (nuRenderer) env(env registry.Environment) string {
+	// Nushell has no unexported shell variables that outlive a sourced file
+	return fmt.Sprintf("$env.%s = %s\n", env.Name, quote.NuExpand(env.Value))
+}
+
+func (nuRenderer) alias(alias registry.Alias) string {
+	return fmt.Sprintf("alias %s = %s\n", alias.Name, alias.Command)
+}
+
+func (nuRenderer) function(function registry.Function) string {
+	var b strings.Builder
+	if function.Description != "" {
+		// A comment right above def becomes the command's description
+		b.WriteString("# " + strings.ReplaceAll(function.Description, "\n", " ") + "\n")
+	}
+	// --wrapped passes every argument through in $args, as other shells do
+	fmt.Fprintf(&b, "def --wrapped %s [...args] {\n%s}\n", function.Name, indent(function.Commands, "    "))
+	return b.String()
+}
+
+func (nuRenderer) pathEntry(dir string, prepend bool) string {
+	flag := ""
+	if !prepend {
+		flag = "--append "
+	}
+	return fmt.Sprintf("use std/util \"path add\"; path add %s%s\n", flag, quote.NuExpand(dir))
+}
+
+func (nuRenderer) source(path string) string {
+	// source is resolved when the file is parsed, so the path must exist
+	return fmt.Sprintf("source %s\n", quote.Nu(path))
+}
+
+func (nuRenderer) check(check registry.Check) string {
+	var condition string
+	switch check.Type {
+	case "command":
+		condition = fmt.Sprintf("(which %s | length) > 0", quote.Nu(check.Command))
+	case "file":
+		condition = fmt.Sprintf("(%s | path type) == 'file'", quote.NuExpand(check.Path))
+	case "directory":
+		condition = fmt.Sprintf("(%s | path type) == 'dir'", quote.NuExpand(check.Path))
+	case "env":
+		condition = fmt.Sprintf("%s in $env", quote.Nu(check.Variable))
+	default:
+		return nuRenderer{}.comment(fmt.Sprintf("Skipped check %s: unsupported type %q", check.Name, check.Type))
+	}
+
+	var b strings.Builder
+	b.WriteString("if " + condition + " {\n")
+	b.WriteString(indent(check.OnSuccess, "    "))
+	b.WriteString("}")
+	if len(check.OnFailure) > 0 {
+		b.WriteString(" else {\n")
+		b.WriteString(indent(check.OnFailure, "    "))
+		b.WriteString("}")
+	}
+	b.WriteString("\n")
+	return b.String()
+}
+
+// nuCondition renders runtime conditions for Nushell
+var nuCondition = conditionSyntax{
+	command: func(name string) string { return fmt.Sprintf("((which %s | length) > 0)", quote.Nu(name)) },
+	env:     func(name string) string { return fmt.Sprintf("(($env.%s? | default '') != '')", name) },
+	compare: func(name, op, value string) string {
+		return fmt.Sprintf("(($env.%s? | default '') %s %s)", name, op, quote.Nu(value))
+	},
+	not:   func(x string) string { return "not " + x },
+	and:   func(x, y string) string { return x + " and " + y },
+	or:    func(x, y string) string { return x + " or " + y },
+	group: func(x string) string { return "(" + x + ")" },
+}
+
+// unguarded reports the items Nushell defines when a file is parsed, before
+// any condition runs
+func (nuRenderer) unguarded(kind string) bool {
+	return kind == KindAlias || kind == KindFunction || kind == kindFile
+}
+
+func (nuRenderer) guard(when condition.Expr, code string) string {
+	// if blocks keep the environment changes made in them
+	return fmt.Sprintf("if %s {\n%s}\n", renderCondition(when, nuCondition), indentBlock(code, "    "))
+}
+
+// elvishRenderer renders for Elvish. The script is evaluated from rc.elv,
+// so aliases, functions and shell variables are added to the REPL namespace
+// with edit:add-var rather than defined in the script's own namespace.
+type elvishRenderer struct{}
+
+// elvishArguments maps POSIX argument references to the rest argument of
+// the closure an alias or function is wrapped in
+var elvishArguments = strings.NewReplacer(
+	`"$@"`, "$@args", `"$*"`, "$@args", "$@", "$@args", "$*", "$@args",
+	`"$1"`, "$args[0]", `"$2"`, "$args[1]", `"$3"`, "$args[2]",
+	`"$4"`, "$args[3]", `"$5"`, "$args[4]", `"$6"`, "$args[5]",
+	`"$7"`, "$args[6]", `"$8"`, "$args[7]", `"$9"`, "$args[8]",
+	"$1", "$args[0]", "$2", "$args[1]", "$3", "$args[2]",
+	"$4", "$args[3]", "$5", "$args[4]", "$6", "$args[5]",
+	"$7", "$args[6]", "$8", "$args[7]", "$9", "$args[8]",
+)
+
+// elvishUnsupported are POSIX constructs Elvish has no syntax for
+var elvishUnsupported = []string{"&&", "||", "$(", "`"}
+
+func (elvishRenderer) unsupported(module registry.Module) []string {
+	var reasons []string
+	for _, alias := range module.Aliases {
+		if construct := unquotedConstruct(alias.Command, elvishUnsupported); construct != "" {
+			reasons = append(reasons, fmt.Sprintf("alias %s: elvish has no %s", alias.Name, construct))
+		}
+	}
+	for _, function := range module.Functions {
+		for _, command := range function.Commands {
+			if construct := unquotedConstruct(command, elvishUnsupported); construct != "" {
+				reasons = append(reasons, fmt.Sprintf("function %s: elvish has no %s", function.Name, construct))
+				break
+			}
+		}
+	}
+	return reasons
+}
+
+func (elvishRenderer) comment(text string) string {
+	return "# " + text + "\n"
+}
+
+func (elvishRenderer) env(env registry.Environment) string {
+	if env.Export {
+		return fmt.Sprintf("set-env %s %s\n", env.Name, quote.ElvishExpand(env.Value))
+	}
+	return fmt.Sprintf("edit:add-var %s %s\n", env.Name, quote.ElvishExpand(env.Value))
+}
+
+func (elvishRenderer) alias(alias registry.Alias) string {
+	command := replaceUnquoted(elvishArguments, alias.Command)
+	if command == alias.Command {
+		command += " $@args"
+	}
+	return fmt.Sprintf("edit:add-var %s~ {|@args| %s }\n", alias.Name, command)
+}
+
+func (elvishRenderer) function(function registry.Function) string {
+	var b strings.Builder
+	if function.Description != "" {
+		b.WriteString("# " + strings.ReplaceAll(function.Description, "\n", " ") + "\n")
+	}
+	commands := function.Commands
+	if !strings.EqualFold(function.Shell, string(shell.Elvish)) {
+		// Functions written for elvish already use the rest argument
+		commands = make([]string, len(function.Commands))
+		for i, command := range function.Commands {
+			commands[i] = replaceUnquoted(elvishArguments, command)
+		}
+	}
+	fmt.Fprintf(&b, "edit:add-var %s~ {|@args|\n%s}\n", function.Name, indent(commands, "    "))
+	return b.String()
+}
+
+func (elvishRenderer) pathEntry(dir string, prepend bool) string {
+	quoted := quote.ElvishExpand(dir)
+	value := "$@paths " + quoted
+	if prepend {
+		value = quoted + " $@paths"
+	}
+	return fmt.Sprintf("if (not (has-value $paths %s)) { set paths = [%s] }\n", quoted, value)
+}
+
+func (elvishRenderer) source(path string) string {
+	quoted := quote.Elvish(path)
+	return fmt.Sprintf("use path; if (path:is-regular %s) { eval (slurp < %s) }\n", quoted, quoted)
+}
+
+func (elvishRenderer) check(check registry.Check) string {
+	var condition string
+	switch check.Type {
+	case "command":
+		condition = "has-external " + quote.Elvish(check.Command)
+	case "file":
+		condition = "path:is-regular " + quote.ElvishExpand(check.Path)
+	case "directory":
+		condition = "path:is-dir " + quote.ElvishExpand(check.Path)
+	case "env":
+		condition = "has-env " + quote.Elvish(check.Variable)
+	default:
+		return elvishRenderer{}.comment(fmt.Sprintf("Skipped check %s: unsupported type %q", check.Name, check.Type))
+	}
+
+	var b strings.Builder
+	if strings.HasPrefix(condition, "path:") {
+		b.WriteString("use path\n")
+	}
+	b.WriteString("if (" + condition + ") {\n")
+	b.WriteString(indent(check.OnSuccess, "    "))
+	b.WriteString("}")
+	if len(check.OnFailure) > 0 {
+		b.WriteString(" else {\n")
+		b.WriteString(indent(check.OnFailure, "    "))
+		b.WriteString("}")
+	}
+	b.WriteString("\n")
+	return b.String()
+}
+
+// elvishCondition renders runtime conditions for Elvish
+var elvishCondition = conditionSyntax{
+	command: func(name string) string { return "(has-external " + quote.Elvish(name) + ")" },
+	env:     func(name string) string { return fmt.Sprintf("(!=s $E:%s '')", name) },
+	compare: func(name, op, value string) string {
+		return fmt.Sprintf("(%ss $E:%s %s)", op, name, quote.Elvish(value))
+	},
+	not:   func(x string) string { return "(not " + x + ")" },
+	and:   func(x, y string) string { return "(and " + x + " " + y + ")" },
+	or:    func(x, y string) string { return "(or " + x + " " + y + ")" },
+	group: func(x string) string { return x },
+}
+
+func (elvishRenderer) guard(when condition.Expr, code string) string {
+	return fmt.Sprintf("if %s {\n%s}\n", renderCondition(when, elvishCondition), indentBlock(code, "    "))
+}
+
+// xonshRenderer renders for xonsh, mixing Python and subprocess mode
+type xonshRenderer struct{}
+
+// xonshArguments maps POSIX argument references in function commands to the
+// arguments list of the callable alias
+var xonshArguments = strings.NewReplacer(
+	`"$@"`, "@(args)", `"$*"`, "@(args)", "$@", "@(args)", "$*", "@(args)",
+	`"$1"`, "@(args[0])", `"$2"`, "@(args[1])", `"$3"`, "@(args[2])",
+	`"$4"`, "@(args[3])", `"$5"`, "@(args[4])", `"$6"`, "@(args[5])",
+	`"$7"`, "@(args[6])", `"$8"`, "@(args[7])", `"$9"`, "@(args[8])",
+	"$1", "@(args[0])", "$2", "@(args[1])", "$3", "@(args[2])",
+	"$4", "@(args[3])", "$5", "@(args[4])", "$6", "@(args[5])",
+	"$7", "@(args[6])", "$8", "@(args[7])", "$9", "@(args[8])",
+)
+
+// xonshTrailingArguments are the argument references string aliases can drop,
+// xonsh appends the arguments to them
+var xonshTrailingArguments = []string{` "$@"`, ` "$*"`, " $@", " $*"}
+
+// xonshAliasCommand returns the command of a string alias, or false when it
+// references arguments anywhere but at its end
+func xonshAliasCommand(command string) (string, bool) {
+	for _, suffix := range xonshTrailingArguments {
+		command = strings.TrimSuffix(command, suffix)
+	}
+	return command, replaceUnquoted(xonshArguments, command) == command
+}
+
+func (xonshRenderer) unsupported(module registry.Module) []string {
+	var reasons []string
+	for _, alias := range module.Aliases {
+		if _, ok := xonshAliasCommand(alias.Command); !ok {
+			reasons = append(reasons, fmt.Sprintf("alias %s: xonsh aliases can't place arguments, use a function", alias.Name))
+		}
+		if unquotedConstruct(alias.Command, []string{"`"}) != "" {
+			reasons = append(reasons, fmt.Sprintf("alias %s: backticks are glob patterns in xonsh", alias.Name))
+		}
+	}
+	for _, function := range module.Functions {
+		for _, command := range function.Commands {
+			if unquotedConstruct(command, []string{"`"}) != "" {
+				reasons = append(reasons, fmt.Sprintf("function %s: backticks are glob patterns in xonsh", function.Name))
+				break
+			}
+		}
+	}
+	return reasons
+}
+
+func (xonshRenderer) comment(text string) string {
+	return "# " + text + "\n"
+}
+
+func (xonshRenderer) env(env registry.Environment) string {
+	if env.Export {
+		return fmt.Sprintf("$%s = %s\n", env.Name, quote.XonshExpand(env.Value))
+	}
+	return fmt.Sprintf("%s = %s\n", env.Name, quote.XonshExpand(env.Value))
+}
+
+func (r xonshRenderer) alias(alias registry.Alias) string {
+	command, ok := xonshAliasCommand(alias.Command)
+	if !ok {
+		// Not reached, Generate refuses such modules
+		return r.comment(fmt.Sprintf("Skipped alias %s: arguments can't be placed", alias.Name))
+	}
+	return fmt.Sprintf("aliases[%s] = %s\n", quote.Xonsh(alias.Name), quote.Xonsh(command))
+}
+
+func (xonshRenderer) function(function registry.Function) string {
+	// Function names can contain characters Python identifiers can't
+	name := "_go_shellify_" + strings.Map(func(r rune) rune {
+		if r == '_' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' {
+			return r
+		}
+		return '_'
+	}, function.Name)
+
+	commands := function.Commands
+	if !strings.EqualFold(function.Shell, string(shell.Xonsh)) {
+		// Functions written for xonsh already use the args list
+		commands = make([]string, len(function.Commands))
+		for i, command := range function.Commands {
+			commands[i] = replaceUnquoted(xonshArguments, command)
+		}
+	}
+	if len(commands) == 0 {
+		commands = []string{"pass"}
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "def %s(args):\n", name)
+	if function.Description != "" {
+		b.WriteString("    " + quote.Xonsh(function.Description) + "\n")
+	}
+	b.WriteString(indent(commands, "    "))
+	fmt.Fprintf(&b, "aliases[%s] = %s\n", quote.Xonsh(function.Name), name)
+	return b.String()
+}
+
+func (xonshRenderer) pathEntry(dir string, prepend bool) string {
+	quoted := quote.XonshExpand(dir)
+	update := fmt.Sprintf("$PATH.append(%s)", quoted)
+	if prepend {
+		update = fmt.Sprintf("$PATH.insert(0, %s)", quoted)
+	}
+	return fmt.Sprintf("if %s not in $PATH: %s\n", quoted, update)
+}
+
+func (xonshRenderer) source(path string) string {
+	quoted := quote.Xonsh(path)
+	return fmt.Sprintf("import os.path\nif os.path.isfile(%s):\n    source @(%s)\n", quoted, quoted)
+}
+
+func (xonshRenderer) check(check registry.Check) string {
+	var module, condition string
+	switch check.Type {
+	case "command":
+		module, condition = "shutil", fmt.Sprintf("shutil.which(%s)", quote.Xonsh(check.Command))
+	case "file":
+		module, condition = "os.path", fmt.Sprintf("os.path.isfile(%s)", quote.XonshExpand(check.Path))
+	case "directory":
+		module, condition = "os.path", fmt.Sprintf("os.path.isdir(%s)", quote.XonshExpand(check.Path))
+	case "env":
+		condition = fmt.Sprintf("%s in ${...}", quote.Xonsh(check.Variable))
+	default:
+		return xonshRenderer{}.comment(fmt.Sprintf("Skipped check %s: unsupported type %q", check.Name, check.Type))
+	}
+
+	var b strings.Builder
+	if module != "" {
+		b.WriteString("import " + module + "\n")
+	}
+	b.WriteString("if " + condition + ":\n")
+	b.WriteString(indent(check.OnSuccess, "    "))
+	if len(check.OnSuccess) == 0 {
+		b.WriteString("    pass\n")
+	}
+	if len(check.OnFailure) > 0 {
+		b.WriteString("else:\n")
+		b.WriteString(indent(check.OnFailure, "    "))
+	}
+	return b.String()
+}
+
+// xonshCondition renders runtime conditions for xonsh
+var xonshCondition = conditionSyntax{
+	command: func(name string) string { return "shutil.which(" + quote.Xonsh(name) + ")" },
+	env: func(name string) string {
+		return fmt.Sprintf("${...}.detype().get(%s, '') != ''", quote.Xonsh(name))
+	},
+	compare: func(name, op, value string) string {
+		return fmt.Sprintf("${...}.detype().get(%s, '') %s %s", quote.Xonsh(name), op, quote.Xonsh(value))
+	},
+	not:   func(x string) string { return "not " + x },
+	and:   func(x, y string) string { return x + " and " + y },
+	or:    func(x, y string) string { return x + " or " + y },
+	group: func(x string) string { return "(" + x + ")" },
+}
+
+func (xonshRenderer) guard(when condition.Expr, code string) string {
+	var b strings.Builder
+	if hasCommand(when) {
+		b.WriteString("import shutil\n")
+	}
+	fmt.Fprintf(&b, "if %s:\n%s", renderCondition(when, xonshCondition), indentBlock(code, "    "))
+	return b.String()
+}
+
+// unquotedConstruct returns the first of constructs found in a command outside
+// single and double quotes, or an empty string
+func unquotedConstruct(command string, constructs []string) string {
+	var quoting byte
+	for i := 0; i < len(command); i++ {
+		c := command[i]
+		switch {
+		case quoting != 0:
+			if c == quoting {
+				quoting = 0
+			} else if c == '\\' && quoting == '"' {
+				i++
+			}
+			continue
+		case c == '\\':
+			i++
+			continue
+		case c == '\'' || c == '"':
+			quoting = c
+			continue
+		}
+		for _, construct := range constructs {
+			if strings.HasPrefix(command[i:], construct) {
+				return construct
+			}
+		}
+	}
+	return ""
+}
+
+// replaceUnquoted applies argument replacements to a command outside its
+// single-quoted text, which POSIX shells don't expand either
+func replaceUnquoted(arguments *strings.Replacer, command string) string {
+	var b strings.Builder
+	start := 0
+	inDouble := false
+	for i := 0; i < len(command); i++ {
+		switch c := command[i]; {
+		case c == '\\':
+			i++
+		case c == '"':
+			inDouble = !inDouble
+		case c == '\'' && !inDouble:
+			end := strings.IndexByte(command[i+1:], '\'')
+			if end < 0 {
+				end = len(command) - i - 2
+			}
+			b.WriteString(arguments.Replace(command[start:i]))
+			b.WriteString(command[i : i+end+2])
+			i += end + 1
+			start = i + 1
+		}
+	}
+	b.WriteString(arguments.Replace(command[start:]))
+	return b.String()
+}
+
+// cshRenderer renders for tcsh and csh
+type cshRenderer struct {
+	shell string
+}
+
+// cshArguments maps POSIX argument references in alias commands to csh
+// history references to the alias arguments
+var cshArguments = strings.NewReplacer(
+	`"$@"`, "!*", `"$*"`, "!*", "$@", "!*", "$*", "!*",
+	"$1", "!:1", "$2", "!:2", "$3", "!:3", "$4", "!:4", "$5", "!:5",
+	"$6", "!:6", "$7", "!:7", "$8", "!:8", "$9", "!:9",
+)
+
+func (r cshRenderer) unsupported(module registry.Module) []string {
+	var reasons []string
+	for _, function := range module.Functions {
+		reasons = append(reasons, fmt.Sprintf("function %s: %s has no functions, use an alias or a script", function.Name, r.shell))
+	}
+	if len(module.Shells) == 0 && module.Shell == "" {
+		// Modules that don't declare csh ship POSIX scripts
+		for _, file := range module.Files {
+			if file.Source {
+				reasons = append(reasons, fmt.Sprintf("file %s: sourced files are POSIX scripts", file.Path))
+			}
+		}
+	}
+	return reasons
+}
+
+func (cshRenderer) comment(text string) string {
+	return "# " + text + "\n"
+}
+
+func (cshRenderer) env(env registry.Environment) string {
+	if env.Export {
+		return fmt.Sprintf("setenv %s %s\n", env.Name, quote.CshExpand(env.Value))
+	}
+	return fmt.Sprintf("set %s = %s\n", env.Name, quote.CshExpand(env.Value))
+}
+
+func (cshRenderer) alias(alias registry.Alias) string {
+	// The escaped ! of the argument references is read when the alias runs
+	return fmt.Sprintf("alias %s %s\n", alias.Name, quote.Csh(replaceUnquoted(cshArguments, alias.Command)))
+}
+
+func (r cshRenderer) function(function registry.Function) string {
+	// Not reached, Generate refuses modules with functions
+	return r.comment(fmt.Sprintf("Skipped function %s: %s has no functions", function.Name, r.shell))
+}
+
+func (cshRenderer) pathEntry(dir string, prepend bool) string {
+	quoted := quote.CshExpand(dir)
+	value := "$path:q " + quoted
+	if prepend {
+		value = quoted + " $path:q"
+	}
+	return fmt.Sprintf("if ( \":${PATH}:\" !~ *:%s:* ) set path = ( %s )\n", quoted, value)
+}
+
+func (cshRenderer) source(path string) string {
+	return fmt.Sprintf("if ( -f %s ) source %s\n", quote.Csh(path), quote.Csh(path))
+}
+
+func (r cshRenderer) check(check registry.Check) string {
+	var condition string
+	switch check.Type {
+	case "command":
+		condition = fmt.Sprintf("{ which %s >& /dev/null }", quote.Csh(check.Command))
+	case "file":
+		condition = "-f " + quote.CshExpand(check.Path)
+	case "directory":
+		condition = "-d " + quote.CshExpand(check.Path)
+	case "env":
+		condition = "$?" + check.Variable
+	default:
+		return r.comment(fmt.Sprintf("Skipped check %s: unsupported type %q", check.Name, check.Type))
+	}
+
+	var b strings.Builder
+	b.WriteString("if ( " + condition + " ) then\n")
+	b.WriteString(indent(check.OnSuccess, "  "))
+	if len(check.OnFailure) > 0 {
+		b.WriteString("else\n")
+		b.WriteString(indent(check.OnFailure, "  "))
+	}
+	b.WriteString("endif\n")
+	return b.String()
+}
+
+// cshCondition renders runtime conditions for csh. Variables are read with
+// printenv, as csh fails on unset variables even in a guarded operand.
+var cshCondition = conditionSyntax{
+	command: func(name string) string { return fmt.Sprintf("{ which %s >& /dev/null }", quote.Csh(name)) },
+	env:     func(name string) string { return fmt.Sprintf("\"`printenv %s`\" != ''", name) },
+	compare: func(name, op, value string) string {
+		return fmt.Sprintf("\"`printenv %s`\" %s %s", name, op, quote.Csh(value))
+	},
+	not:   func(x string) string { return "! ( " + x + " )" },
+	and:   func(x, y string) string { return x + " && " + y },
+	or:    func(x, y string) string { return x + " || " + y },
+	group: func(x string) string { return "( " + x + " )" },
+}
+
+func (cshRenderer) guard(when condition.Expr, code string) string {
+	return fmt.Sprintf("if ( %s ) then\n%sendif\n", renderCondition(when, cshCondition), indentBlock(code, "  "))
+}
+
+// cmdRenderer renders a batch file for cmd.exe. The script runs from the
+// AutoRun registry value in every interactive session, so every line is
+// prefixed with @ rather than turning echo off for the session.
+type cmdRenderer struct{}
+
+// cmdTemp are the variables the script uses for PATH and check logic
+const (
+	cmdTempPath  = "GO_SHELLIFY_PATH"
+	cmdTempDir   = "GO_SHELLIFY_DIR"
+	cmdTempRest  = "GO_SHELLIFY_REST"
+	cmdTempCheck = "GO_SHELLIFY_CHECK"
+)
+
+var doskeyMacroName = regexp.MustCompile(`^[A-Za-z0-9_.-]+$`)
+
+func (cmdRenderer) unsupported(module registry.Module) []string {
+	var reasons []string
+	for _, function := range module.Functions {
+		reasons = append(reasons, fmt.Sprintf("function %s: cmd has no functions, use an alias or a script", function.Name))
+	}
+	if len(module.Shells) == 0 && module.Shell == "" {
+		// Modules that don't declare cmd ship POSIX scripts
+		for _, file := range module.Files {
+			if file.Source {
+				reasons = append(reasons, fmt.Sprintf("file %s: sourced files are POSIX scripts", file.Path))
+			}
+		}
+	}
+	return reasons
+}
+
+func (cmdRenderer) comment(text string) string {
+	// Variables are expanded even in rem lines
+	return "@rem " + strings.ReplaceAll(text, "%", "%%") + "\n"
+}
+
+func (r cmdRenderer) env(env registry.Environment) string {
+	line, err := quote.CmdSetExpand(env.Name, env.Value)
+	if err != nil {
+		return r.comment(fmt.Sprintf("Skipped environment variable %s: value can't be set in cmd", env.Name))
+	}
+	return "@" + line + "\n"
+}
+
+func (r cmdRenderer) alias(alias registry.Alias) string {
+	macro, err := doskeyMacro(alias.Command)
+	if err != nil || !doskeyMacroName.MatchString(alias.Name) {
+		return r.comment(fmt.Sprintf("Skipped alias %s: can't be written as a doskey macro", alias.Name))
+	}
+	return fmt.Sprintf("@doskey %s=%s\n", alias.Name, macro)
+}
+
+func (r cmdRenderer) function(function registry.Function) string {
+	// Not reached, Generate refuses modules with functions
+	return r.comment(fmt.Sprintf("Skipped function %s: cmd has no functions", function.Name))
+}
+
+func (r cmdRenderer) pathEntry(dir string, prepend bool) string {
+	setDir, err := quote.CmdSetExpand(cmdTempDir, dir)
+	if err != nil {
+		return r.comment("Skipped PATH entry: directory can't be set in cmd")
+	}
+
+	value := fmt.Sprintf("%%PATH%%;%%%s%%", cmdTempDir)
+	if prepend {
+		value = fmt.Sprintf("%%%s%%;%%PATH%%", cmdTempDir)
+	}
+
+	// call expands the directory inside the substitution, which removes it
+	// from the ;-delimited PATH when it is already there
+	var b strings.Builder
+	fmt.Fprintf(&b, "@set \"%s=;%%PATH%%;\"\n", cmdTempPath)
+	fmt.Fprintf(&b, "@%s\n", setDir)
+	fmt.Fprintf(&b, "@call set \"%s=%%%%%s:;%%%s%%;=%%%%\"\n", cmdTempRest, cmdTempPath, cmdTempDir)
+	fmt.Fprintf(&b, "@if \"%%%s%%\"==\"%%%s%%\" set \"PATH=%s\"\n", cmdTempRest, cmdTempPath, value)
+	fmt.Fprintf(&b, "@set \"%s=\" & set \"%s=\" & set \"%s=\"\n", cmdTempPath, cmdTempDir, cmdTempRest)
+	return b.String()
+}
+
+func (r cmdRenderer) source(path string) string {
+	quoted, err := quote.Cmd(path)
+	if err != nil {
+		return r.comment("Skipped file: path can't be quoted for cmd")
+	}
+	return fmt.Sprintf("@if exist %s call %s\n", quoted, quoted)
+}
+
+func (r cmdRenderer) check(check registry.Check) string {
+	var condition string
+	var err error
+	switch check.Type {
+	case "command":
+		var quoted string
+		quoted, err = quote.Cmd(check.Command)
+		condition = fmt.Sprintf("where /q %s && set \"%s=1\"", quoted, cmdTempCheck)
+	case "file":
+		var quoted, contents string
+		quoted, err = quote.CmdExpand(check.Path)
+		if err == nil {
+			// Only directories have contents matching \*
+			contents, err = quote.CmdExpand(check.Path + `\*`)
+		}
+		condition = fmt.Sprintf("if exist %s if not exist %s set \"%s=1\"", quoted, contents, cmdTempCheck)
+	case "directory":
+		var contents string
+		contents, err = quote.CmdExpand(check.Path + `\*`)
+		condition = fmt.Sprintf("if exist %s set \"%s=1\"", contents, cmdTempCheck)
+	case "env":
+		condition = fmt.Sprintf("if defined %s set \"%s=1\"", check.Variable, cmdTempCheck)
+	default:
+		return r.comment(fmt.Sprintf("Skipped check %s: unsupported type %q", check.Name, check.Type))
+	}
+	if err != nil {
+		return r.comment(fmt.Sprintf("Skipped check %s: can't be quoted for cmd", check.Name))
+	}
+
+	block := func(lines []string) string {
+		if len(lines) == 0 {
+			// cmd rejects empty blocks
+			return "  rem\n"
+		}
+		return indent(lines, "  ")
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "@set \"%s=\"\n", cmdTempCheck)
+	fmt.Fprintf(&b, "@%s\n", condition)
+	fmt.Fprintf(&b, "@if defined %s (\n", cmdTempCheck)
+	b.WriteString(block(check.OnSuccess))
+	if len(check.OnFailure) > 0 {
+		b.WriteString(") else (\n")
+		b.WriteString(block(check.OnFailure))
+	}
+	b.WriteString(")\n")
+	fmt.Fprintf(&b, "@set \"%s=\"\n", cmdTempCheck)
+	return b.String()
+}
+
+// unguarded reports every kind, batch files have no way to combine the
+// runtime conditions
+func (cmdRenderer) unguarded(kind string) bool {
+	return true
+}
+
+func (r cmdRenderer) guard(when condition.Expr, code string) string {
+	// Not reached, Generate refuses items with runtime conditions
+	return r.comment("Skipped items with the runtime condition " + when.String())
+}
+
+// doskeyMacro translates a POSIX alias command into the text of a doskey
+// macro definition in a batch file. Argument references become $* and $1-$9,
+// command separators and redirections outside quotes become $T, $B, $G and
+// $L, and the rest is escaped for the batch file. Commands chained with &&
+// or || can't be written as macros. The arguments are appended
+// when the command doesn't reference them, as POSIX aliases do.
+func doskeyMacro(command string) (string, error) {
+	if strings.ContainsAny(command, "\r\n") {
+		return "", quote.ErrUnquotable
+	}
+	command = strings.ReplaceAll(command, "\x00", "")
+
+	var b strings.Builder
+	quoted := false
+	arguments := false
+	for i := 0; i < len(command); i++ {
+		rest := command[i:]
+		switch {
+		case strings.HasPrefix(rest, `"$@"`) || strings.HasPrefix(rest, `"$*"`):
+			b.WriteString("$*")
+			arguments = true
+			i += 3
+		case strings.HasPrefix(rest, "$@") || strings.HasPrefix(rest, "$*"):
+			b.WriteString("$*")
+			arguments = true
+			i++
+		case rest[0] == '$' && len(rest) > 1 && rest[1] >= '1' && rest[1] <= '9':
+			b.WriteString(rest[:2])
+			arguments = true
+			i++
+		case rest[0] == '$':
+			b.WriteString("$$")
+		case rest[0] == '%':
+			b.WriteString("%%")
+		case rest[0] == '"':
+			quoted = !quoted
+			b.WriteByte('"')
+		case quoted:
+			b.WriteByte(rest[0])
+		case strings.HasPrefix(rest, "||") || strings.HasPrefix(rest, "&&"):
+			// cmd's || and && have no doskey equivalent, $T would run the
+			// next command regardless of the status
+			return "", quote.ErrUnquotable
+		case rest[0] == '&' && i > 0 && command[i-1] == '>':
+			b.WriteString("^&")
+		case rest[0] == '&' || rest[0] == ';':
+			b.WriteString("$T")
+		case rest[0] == '|':
+			b.WriteString("$B")
+		case rest[0] == '>':
+			b.WriteString("$G")
+		case rest[0] == '<':
+			b.WriteString("$L")
+		case rest[0] == '^':
+			b.WriteString("^^")
+		default:
+			b.WriteByte(rest[0])
+		}
+	}
+
+	if !arguments {
+		b.WriteString(" $*")
+	}
+	return b.String(), nil
+}
+
+// CmdAutoRun returns the command registering a cmd.exe init script in the
+// AutoRun registry value, which cmd.exe runs when it starts
+func CmdAutoRun(script string) string {
+	return fmt.Sprintf(`reg add "HKCU\Software\Microsoft\Command Processor" /v AutoRun /t REG_EXPAND_SZ /d "if exist \"%s\" call \"%s\"" /f`, script, script)
+}
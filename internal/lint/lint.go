@@ -0,0 +1,104 @@
+// Package lint checks the shell code of registry modules before it reaches
+// a user's shell.
+package lint
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/griffin/go-shellify/internal/registry"
+)
+
+// Finding is a problem found in a module
+type Finding struct {
+	Rule     string // Rule that produced the finding
+	Module   string
+	Location string // Item the code belongs to, e.g. "function gclean"
+	Line     int    // 1-based line within the item, 0 when not applicable
+	Text     string // Offending code
+	Message  string
+}
+
+// String formats the finding for display
+func (f Finding) String() string {
+	where := f.Location
+	if f.Line > 0 {
+		where += fmt.Sprintf(" line %d", f.Line)
+	}
+	return fmt.Sprintf("%s: %s: %s [%s]", f.Module, where, f.Message, f.Rule)
+}
+
+// Allowlist maps a rule to the modules it doesn't apply to. Module patterns
+// use path.Match syntax and "*" allows the rule everywhere.
+type Allowlist map[string][]string
+
+// Allows reports whether a rule is allowlisted for a module
+func (a Allowlist) Allows(rule, module string) bool {
+	for _, pattern := range a[rule] {
+		if matched, _ := path.Match(pattern, module); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// Filter drops the findings allowlisted for their module
+func (a Allowlist) Filter(findings []Finding) []Finding {
+	var kept []Finding
+	for _, f := range findings {
+		if !a.Allows(f.Rule, f.Module) {
+			kept = append(kept, f)
+		}
+	}
+	return kept
+}
+
+// snippet is a piece of shell code from a module
+type snippet struct {
+	location string
+	shell    string // Shell the code is written for, empty when unknown
+	code     string
+}
+
+// snippets collects every piece of shell code in a module. Files without
+// inline content are read from dir when it is set.
+func snippets(module registry.Module, dir string) ([]snippet, error) {
+	var result []snippet
+
+	for _, alias := range module.Aliases {
+		result = append(result, snippet{location: "alias " + alias.Name, code: alias.Command})
+	}
+	for _, function := range module.Functions {
+		result = append(result, snippet{
+			location: "function " + function.Name,
+			shell:    function.Shell,
+			code:     strings.Join(function.Commands, "\n"),
+		})
+	}
+	for _, check := range module.Checks {
+		if len(check.OnSuccess) > 0 {
+			result = append(result, snippet{location: "check " + check.Name + " on_success", code: strings.Join(check.OnSuccess, "\n")})
+		}
+		if len(check.OnFailure) > 0 {
+			result = append(result, snippet{location: "check " + check.Name + " on_failure", code: strings.Join(check.OnFailure, "\n")})
+		}
+	}
+	for _, file := range module.Files {
+		content := file.Content
+		if content == "" && dir != "" && file.Path != "" && !filepath.IsAbs(file.Path) {
+			data, err := os.ReadFile(filepath.Join(dir, file.Path))
+			if err != nil && !os.IsNotExist(err) {
+				return nil, fmt.Errorf("reading %s of module %s: %w", file.Path, module.Name, err)
+			}
+			content = string(data)
+		}
+		if content != "" {
+			result = append(result, snippet{location: "file " + file.Path, code: content})
+		}
+	}
+
+	return result, nil
+}
@@ -0,0 +1,241 @@
+package lint
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/griffin/go-shellify/internal/registry"
+)
+
+// Security rule identifiers, used in findings and allowlists
+const (
+	RulePipeToShell       = "pipe-to-shell"
+	RuleRemoveVariable    = "rm-rf-variable"
+	RuleEvalRemote        = "eval-remote"
+	RuleSSHWrite          = "ssh-write"
+	RuleHistoryDisabled   = "history-disabled"
+	RuleWorldWritablePath = "world-writable-path"
+)
+
+// SecurityRule is a pattern in module code that needs a closer look
+type SecurityRule struct {
+	ID      string
+	Message string
+	match   func(line string) bool
+}
+
+const (
+	downloader = `(curl|wget|fetch|iwr|irm|invoke-webrequest|invoke-restmethod)\b`
+	homeDir    = `(~|\$home|\$\{home\}|\$env:userprofile|\$env:home)`
+)
+
+var (
+	pipeToShellPattern = regexp.MustCompile(
+		`(?i)\b` + downloader + `[^|;&]*\|\s*(sudo\s+(-\S+\s+)*)?(env\s+)?(sh|bash|zsh|dash|ksh|fish|python[0-9.]*|perl|ruby|iex|invoke-expression)\b` +
+			`|\b(sh|bash|zsh|dash|ksh)\s+(-\S+\s+)*(-c\s+)?["']?(\$\(|<\()\s*` + downloader +
+			`|(\bsource|^\s*\.|[;&|]\s*\.)\s+<\(\s*` + downloader)
+
+	evalRemotePattern = regexp.MustCompile(
+		`(?i)\b(eval|iex|invoke-expression)\b[^;&]*(\$\(|` + "`" + `|\()\s*` + downloader +
+			`|\b(eval|iex|invoke-expression)\b[^;&]*\b(downloadstring|downloadfile)\b`)
+
+	sshWritePattern = regexp.MustCompile(
+		`(?i)(>>?|\btee\b|\bcp\b|\bmv\b|\bln\b|\binstall\b|\brsync\b|\bscp\b|\bchmod\b|\bchown\b|\bset-content\b|\badd-content\b|\bout-file\b)[^;&|]*` +
+			homeDir + `[/\\]\.ssh\b`)
+
+	historyPattern = regexp.MustCompile(
+		`(?i)\bunset\s+(-v\s+)?HISTFILE\b` +
+			`|\bHISTFILE=("|')?(/dev/null)?("|')?(\s|;|$)` +
+			`|\bHIST(FILE)?SIZE=("|')?0("|')?(\s|;|$)` +
+			`|\bset\s+\+o\s+history\b` +
+			`|\bfish_private_mode\b` +
+			`|\bset\s+(-\S+\s+)*fish_history\s+(""|''|$)` +
+			`|\bhistory\s+-c\b` +
+			`|-HistorySaveStyle\s+SaveNothing\b`)
+)
+
+// SecurityRules are the rules applied by CheckSecurity
+var SecurityRules = []SecurityRule{
+	{
+		ID:      RulePipeToShell,
+		Message: "downloaded content is piped to an interpreter",
+		match:   pipeToShellPattern.MatchString,
+	},
+	{
+		ID:      RuleRemoveVariable,
+		Message: "recursive forced removal of a path built from a variable",
+		match:   removesVariable,
+	},
+	{
+		ID:      RuleEvalRemote,
+		Message: "downloaded content is evaluated",
+		match:   evalRemotePattern.MatchString,
+	},
+	{
+		ID:      RuleSSHWrite,
+		Message: "modifies files in ~/.ssh",
+		match:   sshWritePattern.MatchString,
+	},
+	{
+		ID:      RuleHistoryDisabled,
+		Message: "disables or clears shell history",
+		match:   historyPattern.MatchString,
+	},
+}
+
+// worldWritableDirs are always flagged in PATH, whatever their mode on this machine
+var worldWritableDirs = []string{"/tmp", "/var/tmp", "/dev/shm"}
+
+// CheckSecurity applies the security rules to every piece of shell code in
+// a module, reading sourced files from dir when it is set
+func CheckSecurity(module registry.Module, dir string) ([]Finding, error) {
+	code, err := snippets(module, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var findings []Finding
+	for _, s := range code {
+		for i, line := range strings.Split(s.code, "\n") {
+			if isComment(line) {
+				continue
+			}
+			for _, rule := range SecurityRules {
+				if rule.match(line) {
+					findings = append(findings, Finding{
+						Rule:     rule.ID,
+						Module:   module.Name,
+						Location: s.location,
+						Line:     i + 1,
+						Text:     strings.TrimSpace(line),
+						Message:  rule.Message,
+					})
+				}
+			}
+		}
+	}
+
+	for _, env := range module.Environment {
+		if disablesHistory(env) {
+			findings = append(findings, Finding{
+				Rule:     RuleHistoryDisabled,
+				Module:   module.Name,
+				Location: "environment " + env.Name,
+				Text:     env.Name + "=" + env.Value,
+				Message:  "disables shell history",
+			})
+		}
+	}
+
+	for _, entry := range module.PathEntries {
+		dir := entry.Directory
+		if dir == "" {
+			dir = entry.Path
+		}
+		if isWorldWritable(dir) {
+			findings = append(findings, Finding{
+				Rule:     RuleWorldWritablePath,
+				Module:   module.Name,
+				Location: "path entry",
+				Text:     dir,
+				Message:  "adds a world-writable directory to PATH",
+			})
+		}
+	}
+
+	return findings, nil
+}
+
+// isComment reports whether a line only holds a comment
+func isComment(line string) bool {
+	return strings.HasPrefix(strings.TrimSpace(line), "#")
+}
+
+// removesVariable reports whether a line runs rm with both the recursive and
+// force flags on an argument that expands a variable
+func removesVariable(line string) bool {
+	for _, command := range splitCommands(line) {
+		fields := strings.Fields(command)
+		for len(fields) > 0 && (fields[0] == "sudo" || fields[0] == "command" || fields[0] == "builtin") {
+			fields = fields[1:]
+		}
+		if len(fields) == 0 || (fields[0] != "rm" && filepath.Base(fields[0]) != "rm") {
+			continue
+		}
+
+		recursive, force, variable := false, false, false
+		for _, arg := range fields[1:] {
+			switch {
+			case arg == "--recursive":
+				recursive = true
+			case arg == "--force":
+				force = true
+			case strings.HasPrefix(arg, "-") && !strings.HasPrefix(arg, "--"):
+				recursive = recursive || strings.ContainsAny(arg, "rR")
+				force = force || strings.Contains(arg, "f")
+			case strings.Contains(arg, "$"):
+				variable = true
+			}
+		}
+		if recursive && force && variable {
+			return true
+		}
+	}
+	return false
+}
+
+// splitCommands splits a line on command separators
+func splitCommands(line string) []string {
+	return strings.FieldsFunc(line, func(r rune) bool {
+		return r == ';' || r == '&' || r == '|' || r == '(' || r == ')' || r == '`'
+	})
+}
+
+// disablesHistory reports whether an environment variable turns history off
+func disablesHistory(env registry.Environment) bool {
+	value := strings.Trim(strings.TrimSpace(env.Value), `"'`)
+	switch env.Name {
+	case "HISTFILE":
+		return value == "" || value == "/dev/null"
+	case "HISTSIZE", "HISTFILESIZE", "SAVEHIST":
+		return value == "0"
+	case "fish_history":
+		return value == ""
+	}
+	return false
+}
+
+// isWorldWritable reports whether a PATH directory can be written by any user
+func isWorldWritable(dir string) bool {
+	if dir == "" {
+		return false
+	}
+
+	clean := filepath.Clean(dir)
+	for _, known := range worldWritableDirs {
+		if clean == known || strings.HasPrefix(clean, known+"/") {
+			return true
+		}
+	}
+
+	expanded := os.Expand(dir, func(name string) string {
+		if name == "HOME" {
+			home, _ := os.UserHomeDir()
+			return home
+		}
+		return os.Getenv(name)
+	})
+	if strings.HasPrefix(expanded, "~/") {
+		if home, err := os.UserHomeDir(); err == nil {
+			expanded = filepath.Join(home, expanded[2:])
+		}
+	}
+
+	info, err := os.Stat(expanded)
+	if err != nil || !info.IsDir() {
+		return false
+	}
+	return info.Mode().Perm()&0002 != 0
+}
@@ -0,0 +1,146 @@
+package lint
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/griffin/go-shellify/internal/registry"
+)
+
+func TestSecurityRules(t *testing.T) {
+	tests := []struct {
+		line     string
+		expected string // Rule expected to match, empty for none
+	}{
+		{line: "curl -fsSL https://example.com/install.sh | sh", expected: RulePipeToShell},
+		{line: "wget -qO- https://example.com/i | sudo bash -s -- --yes", expected: RulePipeToShell},
+		{line: `sh -c "$(curl -fsSL https://example.com/install.sh)"`, expected: RulePipeToShell},
+		{line: "source <(curl -s https://example.com/env)", expected: RulePipeToShell},
+		{line: "iwr https://example.com/x.ps1 | iex", expected: RulePipeToShell},
+		{line: "curl -s https://example.com/data.json | jq .", expected: ""},
+		{line: "rm -rf $BUILD_DIR/out", expected: RuleRemoveVariable},
+		{line: `cd /tmp && rm -r -f "${TARGET}"`, expected: RuleRemoveVariable},
+		{line: "sudo rm --recursive --force $1", expected: RuleRemoveVariable},
+		{line: "rm -rf ./build", expected: ""},
+		{line: "rm -f $LOGFILE", expected: ""},
+		{line: `eval "$(curl -s https://example.com/init)"`, expected: RuleEvalRemote},
+		{line: "iex (New-Object Net.WebClient).DownloadString('https://example.com')", expected: RuleEvalRemote},
+		{line: `eval "$(starship init bash)"`, expected: ""},
+		{line: "echo ssh-rsa AAAA >> ~/.ssh/authorized_keys", expected: RuleSSHWrite},
+		{line: "cp id_rsa $HOME/.ssh/", expected: RuleSSHWrite},
+		{line: "ssh -i ~/.ssh/id_ed25519 host", expected: ""},
+		{line: "unset HISTFILE", expected: RuleHistoryDisabled},
+		{line: "export HISTFILE=/dev/null", expected: RuleHistoryDisabled},
+		{line: "HISTSIZE=0", expected: RuleHistoryDisabled},
+		{line: "set +o history", expected: RuleHistoryDisabled},
+		{line: "Set-PSReadLineOption -HistorySaveStyle SaveNothing", expected: RuleHistoryDisabled},
+		{line: "export HISTSIZE=10000", expected: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.line, func(t *testing.T) {
+			module := registry.Module{
+				Name:      "test",
+				Functions: []registry.Function{{Name: "f", Commands: []string{"# " + tt.line, tt.line}}},
+			}
+
+			findings, err := CheckSecurity(module, "")
+			if err != nil {
+				t.Fatalf("CheckSecurity() failed: %v", err)
+			}
+
+			if tt.expected == "" {
+				if len(findings) > 0 {
+					t.Errorf("CheckSecurity() = %v, expected no findings", findings)
+				}
+				return
+			}
+			if len(findings) != 1 || findings[0].Rule != tt.expected {
+				t.Fatalf("CheckSecurity() = %v, expected one %s finding", findings, tt.expected)
+			}
+			if findings[0].Location != "function f" || findings[0].Line != 2 {
+				t.Errorf("finding at %s line %d, expected function f line 2", findings[0].Location, findings[0].Line)
+			}
+		})
+	}
+}
+
+func TestCheckSecurityModuleItems(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "init.sh"), []byte("echo hi\ncurl https://example.com | bash\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	writable := filepath.Join(dir, "bin")
+	if err := os.Mkdir(writable, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chmod(writable, 0777); err != nil {
+		t.Fatal(err)
+	}
+
+	module := registry.Module{
+		Name:        "risky",
+		Aliases:     []registry.Alias{{Name: "nuke", Command: "rm -rf $1"}},
+		Environment: []registry.Environment{{Name: "HISTFILE", Value: ""}},
+		PathEntries: []registry.PathEntry{{Directory: "/tmp/bin"}, {Directory: writable}, {Directory: "$HOME/.local/bin"}},
+		Files:       []registry.File{{Path: "init.sh", Source: true}},
+		Checks:      []registry.Check{{Name: "git", OnFailure: []string{"echo ssh >> ~/.ssh/config"}}},
+	}
+
+	findings, err := CheckSecurity(module, dir)
+	if err != nil {
+		t.Fatalf("CheckSecurity() failed: %v", err)
+	}
+
+	expected := map[string]string{
+		"alias nuke":             RuleRemoveVariable,
+		"check git on_failure":   RuleSSHWrite,
+		"file init.sh":           RulePipeToShell,
+		"environment HISTFILE":   RuleHistoryDisabled,
+		"path entry /tmp/bin":    RuleWorldWritablePath,
+		"path entry " + writable: RuleWorldWritablePath,
+	}
+	if len(findings) != len(expected) {
+		t.Errorf("CheckSecurity() returned %d findings, expected %d: %v", len(findings), len(expected), findings)
+	}
+	for _, f := range findings {
+		location := f.Location
+		if f.Rule == RuleWorldWritablePath {
+			location += " " + f.Text
+		}
+		if expected[location] != f.Rule {
+			t.Errorf("unexpected finding %v", f)
+		}
+	}
+}
+
+func TestAllowlist(t *testing.T) {
+	allow := Allowlist{
+		RulePipeToShell: {"installer", "setup-*"},
+		RuleSSHWrite:    {"*"},
+	}
+
+	tests := []struct {
+		rule     string
+		module   string
+		expected bool
+	}{
+		{rule: RulePipeToShell, module: "installer", expected: true},
+		{rule: RulePipeToShell, module: "setup-node", expected: true},
+		{rule: RulePipeToShell, module: "git-helpers", expected: false},
+		{rule: RuleSSHWrite, module: "anything", expected: true},
+		{rule: RuleEvalRemote, module: "installer", expected: false},
+	}
+
+	for _, tt := range tests {
+		if result := allow.Allows(tt.rule, tt.module); result != tt.expected {
+			t.Errorf("Allows(%s, %s) = %v, expected %v", tt.rule, tt.module, result, tt.expected)
+		}
+	}
+
+	findings := []Finding{{Rule: RulePipeToShell, Module: "installer"}, {Rule: RulePipeToShell, Module: "other"}}
+	if kept := allow.Filter(findings); len(kept) != 1 || kept[0].Module != "other" {
+		t.Errorf("Filter() = %v, expected only the finding for other", kept)
+	}
+}
@@ -0,0 +1,149 @@
+package lint
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+
+	"github.com/griffin/go-shellify/internal/registry"
+)
+
+// Rules for aliases and functions that replace an existing command
+const (
+	RuleShadowsBuiltin = "shadows-builtin"
+	RuleShadowsCommand = "shadows-command"
+)
+
+// shadowShells are the shells undeclared modules are generated for
+var shadowShells = []string{"bash", "zsh", "fish", "powershell"}
+
+// posixReserved are the builtins and keywords shared by POSIX shells
+var posixReserved = []string{
+	"!", ".", ":", "[", "alias", "bg", "break", "case", "cd", "command",
+	"continue", "do", "done", "echo", "elif", "else", "esac", "eval", "exec",
+	"exit", "export", "false", "fc", "fg", "fi", "for", "getopts", "hash",
+	"if", "in", "jobs", "kill", "printf", "pwd", "read", "readonly", "return",
+	"set", "shift", "test", "then", "times", "trap", "true", "type", "ulimit",
+	"umask", "unalias", "unset", "until", "wait", "while",
+}
+
+// reservedNames lists the builtins and keywords of every shell
+var reservedNames = map[string]map[string]bool{
+	"sh": names(posixReserved),
+	"bash": names(posixReserved, []string{
+		"[[", "]]", "bind", "builtin", "caller", "compgen", "complete",
+		"compopt", "coproc", "declare", "dirs", "disown", "enable", "function",
+		"help", "history", "let", "local", "logout", "mapfile", "popd", "pushd",
+		"readarray", "select", "shopt", "source", "suspend", "time", "typeset",
+	}),
+	"zsh": names(posixReserved, []string{
+		"[[", "]]", "autoload", "bindkey", "builtin", "bye", "chdir", "compdef",
+		"declare", "dirs", "disable", "disown", "emulate", "enable", "end",
+		"float", "foreach", "function", "functions", "history", "integer", "let",
+		"local", "logout", "noglob", "popd", "print", "pushd", "rehash",
+		"repeat", "select", "setopt", "source", "time", "typeset", "unfunction",
+		"unhash", "unsetopt", "whence", "where", "which", "zle", "zmodload",
+		"zstyle",
+	}),
+	"fish": names([]string{
+		".", ":", "[", "abbr", "and", "argparse", "begin", "bg", "bind", "block",
+		"break", "builtin", "case", "cd", "command", "commandline", "complete",
+		"contains", "continue", "count", "echo", "else", "emit", "end", "eval",
+		"exec", "exit", "false", "fg", "for", "function", "functions", "history",
+		"if", "jobs", "math", "not", "or", "path", "printf", "pwd", "random",
+		"read", "realpath", "return", "set", "set_color", "source", "status",
+		"string", "switch", "test", "time", "true", "type", "ulimit", "wait",
+		"while",
+	}),
+	"powershell": names([]string{
+		"begin", "break", "catch", "class", "continue", "data", "define", "do",
+		"dynamicparam", "else", "elseif", "end", "enum", "exit", "filter",
+		"finally", "for", "foreach", "from", "function", "hidden", "if", "in",
+		"param", "process", "return", "static", "switch", "throw", "trap", "try",
+		"until", "using", "var", "while",
+		// Aliases PowerShell defines on every platform
+		"%", "?", "cd", "chdir", "clc", "clear", "cls", "copy", "del", "dir",
+		"echo", "erase", "gc", "gci", "gcm", "gl", "gm", "gps", "h", "history",
+		"iex", "kill", "md", "popd", "pushd", "pwd", "r", "rd", "ren", "rmdir",
+		"select", "set", "sl", "type", "where",
+	}),
+}
+
+// names builds a set from lists of names
+func names(lists ...[]string) map[string]bool {
+	set := make(map[string]bool)
+	for _, list := range lists {
+		for _, name := range list {
+			set[name] = true
+		}
+	}
+	return set
+}
+
+// CheckShadowing finds aliases and functions named like a builtin or keyword
+// of a shell they're generated for, or like an executable on $PATH. An empty
+// shell checks every shell the module declares. Items marked with shadows
+// acknowledge the replacement and aren't reported.
+func CheckShadowing(module registry.Module, shell string) []Finding {
+	var findings []Finding
+
+	for _, alias := range module.Aliases {
+		if !alias.Shadows {
+			targets := targetShells(module, "", shell, shadowShells)
+			findings = append(findings, checkName(module.Name, "alias "+alias.Name, alias.Name, targets)...)
+		}
+	}
+	for _, function := range module.Functions {
+		if !function.Shadows {
+			targets := targetShells(module, function.Shell, shell, shadowShells)
+			findings = append(findings, checkName(module.Name, "function "+function.Name, function.Name, targets)...)
+		}
+	}
+
+	return findings
+}
+
+// checkName reports a name that is reserved in any of the target shells or
+// found on $PATH
+func checkName(module, location, name string, targets []string) []Finding {
+	if len(targets) == 0 {
+		return nil
+	}
+
+	var shells []string
+	for _, target := range targets {
+		target = strings.ToLower(target)
+		if target == "pwsh" {
+			target = "powershell"
+		}
+		lookup := name
+		if target == "powershell" {
+			// PowerShell commands are case-insensitive
+			lookup = strings.ToLower(name)
+		}
+		if reservedNames[target][lookup] {
+			shells = append(shells, target)
+		}
+	}
+
+	var findings []Finding
+	if len(shells) > 0 {
+		findings = append(findings, Finding{
+			Rule:     RuleShadowsBuiltin,
+			Module:   module,
+			Location: location,
+			Message:  fmt.Sprintf("shadows the %s builtin or keyword %q", strings.Join(shells, ", "), name),
+		})
+	}
+	if !strings.ContainsAny(name, `/\`) {
+		if path, err := exec.LookPath(name); err == nil {
+			findings = append(findings, Finding{
+				Rule:     RuleShadowsCommand,
+				Module:   module,
+				Location: location,
+				Message:  fmt.Sprintf("shadows the command %s", path),
+			})
+		}
+	}
+	return findings
+}
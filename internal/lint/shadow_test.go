@@ -0,0 +1,112 @@
+package lint
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/griffin/go-shellify/internal/registry"
+)
+
+func TestCheckShadowing(t *testing.T) {
+	bin := t.TempDir()
+	if err := os.WriteFile(filepath.Join(bin, "mytool"), []byte("#!/bin/sh\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", bin)
+
+	tests := []struct {
+		name     string
+		module   registry.Module
+		shell    string
+		expected []string // "rule location" of each expected finding
+	}{
+		{
+			name: "no shadowing",
+			module: registry.Module{
+				Name:      "ok",
+				Aliases:   []registry.Alias{{Name: "ll", Command: "ls -la"}},
+				Functions: []registry.Function{{Name: "mkcd", Commands: []string{"mkdir -p \"$1\""}}},
+			},
+		},
+		{
+			name: "builtins in undeclared module",
+			module: registry.Module{
+				Name:      "bad",
+				Aliases:   []registry.Alias{{Name: "cd", Command: "pushd"}},
+				Functions: []registry.Function{{Name: "test", Commands: []string{"echo"}}},
+			},
+			expected: []string{"shadows-builtin alias cd", "shadows-builtin function test"},
+		},
+		{
+			name: "keyword of one shell",
+			module: registry.Module{
+				Name:    "bad",
+				Shells:  []string{"bash", "fish"},
+				Aliases: []registry.Alias{{Name: "end", Command: "echo"}},
+			},
+			expected: []string{"shadows-builtin alias end"},
+		},
+		{
+			name: "keyword of an undeclared shell",
+			module: registry.Module{
+				Name:    "ok",
+				Shells:  []string{"bash"},
+				Aliases: []registry.Alias{{Name: "end", Command: "echo"}},
+			},
+		},
+		{
+			name: "target shell",
+			module: registry.Module{
+				Name:    "bad",
+				Aliases: []registry.Alias{{Name: "function", Command: "echo"}, {Name: "end", Command: "echo"}},
+			},
+			shell:    "bash",
+			expected: []string{"shadows-builtin alias function"},
+		},
+		{
+			name: "powershell is case-insensitive",
+			module: registry.Module{
+				Name:      "bad",
+				Shell:     "powershell",
+				Functions: []registry.Function{{Name: "Foreach", Commands: []string{"echo"}}},
+			},
+			expected: []string{"shadows-builtin function Foreach"},
+		},
+		{
+			name: "command on PATH",
+			module: registry.Module{
+				Name:    "bad",
+				Aliases: []registry.Alias{{Name: "mytool", Command: "mytool --verbose"}},
+			},
+			expected: []string{"shadows-command alias mytool"},
+		},
+		{
+			name: "acknowledged",
+			module: registry.Module{
+				Name:      "ok",
+				Aliases:   []registry.Alias{{Name: "mytool", Command: "mytool --verbose", Shadows: true}},
+				Functions: []registry.Function{{Name: "cd", Commands: []string{"builtin cd \"$@\""}, Shadows: true}},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			findings := CheckShadowing(tt.module, tt.shell)
+
+			var got []string
+			for _, f := range findings {
+				got = append(got, f.Rule+" "+f.Location)
+			}
+			if len(got) != len(tt.expected) {
+				t.Fatalf("CheckShadowing() = %v, expected %v", findings, tt.expected)
+			}
+			for i := range got {
+				if got[i] != tt.expected[i] {
+					t.Errorf("finding %d = %q, expected %q", i, got[i], tt.expected[i])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,208 @@
+package lint
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/griffin/go-shellify/internal/logger"
+	"github.com/griffin/go-shellify/internal/registry"
+	"mvdan.cc/sh/v3/syntax"
+)
+
+// RuleSyntax identifies code that doesn't parse in its target shell
+const RuleSyntax = "syntax"
+
+// posixVariants maps the shells parsed in process to their parser variant
+var posixVariants = map[string]syntax.LangVariant{
+	"bash": syntax.LangBash,
+	"zsh":  syntax.LangZsh,
+	"sh":   syntax.LangPOSIX,
+}
+
+// powershellParseScript parses the code on stdin with the PowerShell parser
+// and prints "line: message" for every error
+const powershellParseScript = `$errors = $null; ` +
+	`[System.Management.Automation.Language.Parser]::ParseInput([Console]::In.ReadToEnd(), [ref]$null, [ref]$errors) | Out-Null; ` +
+	`foreach ($e in $errors) { '{0}: {1}' -f $e.Extent.StartLineNumber, $e.Message }`
+
+var (
+	fishLinePattern       = regexp.MustCompile(`\(line (\d+)\)`)
+	powershellLinePattern = regexp.MustCompile(`^(\d+): (.*)$`)
+)
+
+// CheckSyntax parses the functions and aliases of a module in the shells
+// they are written for. An empty shell checks every shell the module
+// declares; otherwise only code used by that shell is checked. fish and
+// PowerShell code is only checked when the shell is installed.
+func CheckSyntax(module registry.Module, shell string) []Finding {
+	var findings []Finding
+
+	for _, function := range module.Functions {
+		code := strings.Join(function.Commands, "\n")
+		for _, target := range syntaxTargets(module, function.Shell, shell) {
+			findings = append(findings, checkCode(module.Name, "function "+function.Name, target, code)...)
+		}
+	}
+	for _, alias := range module.Aliases {
+		for _, target := range syntaxTargets(module, "", shell) {
+			findings = append(findings, checkCode(module.Name, "alias "+alias.Name, target, alias.Command)...)
+		}
+	}
+
+	return findings
+}
+
+// syntaxTargets returns the shells to parse a piece of code with. Modules
+// that don't declare shells are written in POSIX shell.
+func syntaxTargets(module registry.Module, codeShell, shell string) []string {
+	return targetShells(module, codeShell, shell, []string{"bash"})
+}
+
+// targetShells returns the shells a piece of module code is used in, limited
+// to shell when it is set. undeclared is used for modules without shells.
+func targetShells(module registry.Module, codeShell, shell string, undeclared []string) []string {
+	var targets []string
+	declared := true
+	switch {
+	case codeShell != "":
+		targets = []string{codeShell}
+	case len(module.Shells) > 0:
+		targets = module.Shells
+	case module.Shell != "":
+		targets = []string{module.Shell}
+	default:
+		targets = undeclared
+		declared = false
+	}
+
+	if shell == "" {
+		return targets
+	}
+	if declared {
+		for _, target := range targets {
+			if strings.EqualFold(target, shell) {
+				return []string{shell}
+			}
+		}
+		return nil
+	}
+	// Undeclared code is generated for every shell, so check it in that one
+	return []string{shell}
+}
+
+// checkCode parses code in a shell and returns the errors as findings
+func checkCode(module, location, shell, code string) []Finding {
+	shell = strings.ToLower(shell)
+
+	var line int
+	var message string
+	var ok bool
+	if variant, posix := posixVariants[shell]; posix {
+		line, message, ok = parsePOSIX(variant, code)
+	} else {
+		switch shell {
+		case "fish":
+			line, message, ok = parseFish(code)
+		case "powershell", "pwsh":
+			line, message, ok = parsePowerShell(code)
+		default:
+			return nil
+		}
+	}
+	if ok {
+		return nil
+	}
+
+	finding := Finding{
+		Rule:     RuleSyntax,
+		Module:   module,
+		Location: location,
+		Line:     line,
+		Message:  fmt.Sprintf("%s syntax error: %s", shell, message),
+	}
+	if lines := strings.Split(code, "\n"); line > 0 && line <= len(lines) {
+		finding.Text = strings.TrimSpace(lines[line-1])
+	}
+	return []Finding{finding}
+}
+
+// parsePOSIX parses code with the in-process shell parser
+func parsePOSIX(variant syntax.LangVariant, code string) (int, string, bool) {
+	parser := syntax.NewParser(syntax.Variant(variant))
+	_, err := parser.Parse(strings.NewReader(code), "")
+	if err == nil {
+		return 0, "", true
+	}
+
+	var parseErr syntax.ParseError
+	if errors.As(err, &parseErr) {
+		return int(parseErr.Pos.Line()), parseErr.Text, false
+	}
+	var langErr syntax.LangError
+	if errors.As(err, &langErr) {
+		_, message, _ := strings.Cut(langErr.Error(), ": ")
+		return int(langErr.Pos.Line()), message, false
+	}
+	return 0, err.Error(), false
+}
+
+// parseFish checks code with `fish -n` when fish is installed
+func parseFish(code string) (int, string, bool) {
+	fish, err := exec.LookPath("fish")
+	if err != nil {
+		logger.Debug("fish not installed, skipping fish syntax check")
+		return 0, "", true
+	}
+
+	cmd := exec.Command(fish, "--no-config", "-n")
+	cmd.Stdin = strings.NewReader(code)
+	output, err := cmd.CombinedOutput()
+	if err == nil {
+		return 0, "", true
+	}
+	if _, exited := err.(*exec.ExitError); !exited {
+		logger.Debug("fish syntax check failed to run: %v", err)
+		return 0, "", true
+	}
+
+	text := strings.TrimSpace(string(output))
+	line := 0
+	if match := fishLinePattern.FindStringSubmatch(text); match != nil {
+		line, _ = strconv.Atoi(match[1])
+	}
+	message, _, _ := strings.Cut(text, "\n")
+	return line, strings.TrimPrefix(message, "fish: "), false
+}
+
+// parsePowerShell checks code with the PowerShell parser when pwsh is installed
+func parsePowerShell(code string) (int, string, bool) {
+	pwsh, err := exec.LookPath("pwsh")
+	if err != nil {
+		logger.Debug("pwsh not installed, skipping PowerShell syntax check")
+		return 0, "", true
+	}
+
+	cmd := exec.Command(pwsh, "-NoProfile", "-NonInteractive", "-Command", powershellParseScript)
+	cmd.Stdin = strings.NewReader(code)
+	var stdout bytes.Buffer
+	cmd.Stdout = &stdout
+	if err := cmd.Run(); err != nil {
+		logger.Debug("PowerShell syntax check failed to run: %v", err)
+		return 0, "", true
+	}
+
+	first, _, _ := strings.Cut(strings.TrimSpace(stdout.String()), "\n")
+	if first == "" {
+		return 0, "", true
+	}
+	if match := powershellLinePattern.FindStringSubmatch(strings.TrimSpace(first)); match != nil {
+		line, _ := strconv.Atoi(match[1])
+		return line, match[2], false
+	}
+	return 0, first, false
+}
@@ -0,0 +1,128 @@
+package lint
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+	"testing"
+
+	"github.com/griffin/go-shellify/internal/registry"
+)
+
+func TestCheckSyntax(t *testing.T) {
+	tests := []struct {
+		name     string
+		module   registry.Module
+		shell    string
+		expected []string // "location line" of each expected finding
+	}{
+		{
+			name: "valid bash",
+			module: registry.Module{
+				Name:      "ok",
+				Functions: []registry.Function{{Name: "f", Commands: []string{"for i in 1 2; do", "  echo \"$i\"", "done"}}},
+				Aliases:   []registry.Alias{{Name: "ll", Command: "ls -la | less"}},
+			},
+		},
+		{
+			name: "unterminated if",
+			module: registry.Module{
+				Name:      "broken",
+				Functions: []registry.Function{{Name: "f", Commands: []string{"echo start", "if true; then", "  echo yes"}}},
+			},
+			expected: []string{"function f 2"},
+		},
+		{
+			name: "broken alias",
+			module: registry.Module{
+				Name:    "broken",
+				Aliases: []registry.Alias{{Name: "q", Command: "echo 'unterminated"}},
+			},
+			expected: []string{"alias q 1"},
+		},
+		{
+			name: "bash only syntax in sh",
+			module: registry.Module{
+				Name:      "posix",
+				Shell:     "sh",
+				Functions: []registry.Function{{Name: "f", Commands: []string{"files=(a b)"}}},
+			},
+			expected: []string{"function f 1"},
+		},
+		{
+			name: "function for another shell is skipped",
+			module: registry.Module{
+				Name:      "multi",
+				Shells:    []string{"bash", "fish"},
+				Functions: []registry.Function{{Name: "f", Shell: "fish", Commands: []string{"if true; then"}}},
+			},
+			shell: "bash",
+		},
+		{
+			name: "undeclared module is checked for the target shell",
+			module: registry.Module{
+				Name:      "any",
+				Functions: []registry.Function{{Name: "f", Commands: []string{"echo ${"}}},
+			},
+			shell:    "zsh",
+			expected: []string{"function f 1"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			findings := CheckSyntax(tt.module, tt.shell)
+
+			var got []string
+			for _, f := range findings {
+				if f.Rule != RuleSyntax || f.Module != tt.module.Name {
+					t.Errorf("unexpected finding %v", f)
+				}
+				got = append(got, fmt.Sprintf("%s %d", f.Location, f.Line))
+			}
+			if strings.Join(got, ",") != strings.Join(tt.expected, ",") {
+				t.Errorf("CheckSyntax() = %v, expected %v", findings, tt.expected)
+			}
+		})
+	}
+}
+
+func TestCheckSyntaxFish(t *testing.T) {
+	if _, err := exec.LookPath("fish"); err != nil {
+		t.Skip("fish not available")
+	}
+
+	module := registry.Module{
+		Name:   "fishy",
+		Shells: []string{"fish"},
+		Functions: []registry.Function{
+			{Name: "ok", Commands: []string{"if true", "    echo yes", "end"}},
+			{Name: "broken", Commands: []string{"echo start", "if true; then"}},
+		},
+	}
+
+	findings := CheckSyntax(module, "")
+	if len(findings) != 1 || findings[0].Location != "function broken" {
+		t.Errorf("CheckSyntax() = %v, expected one finding for function broken", findings)
+	}
+}
+
+func TestCheckSyntaxPowerShell(t *testing.T) {
+	if _, err := exec.LookPath("pwsh"); err != nil {
+		t.Skip("pwsh not available")
+	}
+
+	module := registry.Module{
+		Name:   "pwshy",
+		Shells: []string{"powershell"},
+		Functions: []registry.Function{
+			{Name: "ok", Commands: []string{"Get-ChildItem | Select-Object -First 1"}},
+			{Name: "broken", Commands: []string{"Write-Host start", "if ($true) {"}},
+		},
+	}
+
+	findings := CheckSyntax(module, "")
+	if len(findings) != 1 || findings[0].Location != "function broken" || findings[0].Line != 2 {
+		t.Errorf("CheckSyntax() = %v, expected one finding for function broken line 2", findings)
+	}
+}
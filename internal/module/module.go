@@ -11,10 +11,21 @@ import (
 // ModuleInfo represents module information with registry context
 type ModuleInfo struct {
 	registry.Module
+	Key          string `json:"key"` // Key of the module in the registry index
 	RegistryName string `json:"registry_name"`
 	RegistryURL  string `json:"registry_url"`
 }
 
+// newModuleInfo wraps a registry module with its index key and registry context
+func newModuleInfo(key string, module registry.Module, reg registry.Registry) ModuleInfo {
+	return ModuleInfo{
+		Module:       module,
+		Key:          key,
+		RegistryName: reg.Name,
+		RegistryURL:  reg.URL,
+	}
+}
+
 // Service provides module discovery and management
 type Service struct {
 	registryClient *registry.Client
@@ -33,20 +44,15 @@ func (s *Service) ListAllModules() ([]ModuleInfo, error) {
 	registries := s.registryClient.ListRegistries()
 
 	for _, reg := range registries {
-		index, err := s.registryClient.GetRegistryIndex(reg.URL)
+		index, err := s.registryClient.GetRegistryIndex(reg.Name)
 		if err != nil {
 			// Log error but continue with other registries
 			fmt.Printf("Warning: Failed to fetch modules from registry %s: %v\n", reg.Name, err)
 			continue
 		}
 
-		for _, module := range index.Modules {
-			moduleInfo := ModuleInfo{
-				Module:       module,
-				RegistryName: reg.Name,
-				RegistryURL:  reg.URL,
-			}
-			allModules = append(allModules, moduleInfo)
+		for key, module := range index.Modules {
+			allModules = append(allModules, newModuleInfo(key, module, reg))
 		}
 	}
 
@@ -75,19 +81,14 @@ func (s *Service) ListModulesByRegistry(registryIdentifier string) ([]ModuleInfo
 		return nil, fmt.Errorf("registry not found: %s", registryIdentifier)
 	}
 
-	index, err := s.registryClient.GetRegistryIndex(targetRegistry.URL)
+	index, err := s.registryClient.GetRegistryIndex(targetRegistry.Name)
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch modules from registry %s: %w", targetRegistry.Name, err)
 	}
 
 	var modules []ModuleInfo
-	for _, module := range index.Modules {
-		moduleInfo := ModuleInfo{
-			Module:       module,
-			RegistryName: targetRegistry.Name,
-			RegistryURL:  targetRegistry.URL,
-		}
-		modules = append(modules, moduleInfo)
+	for key, module := range index.Modules {
+		modules = append(modules, newModuleInfo(key, module, *targetRegistry))
 	}
 
 	// Sort modules by name
@@ -109,9 +110,7 @@ func (s *Service) SearchModules(query string) ([]ModuleInfo, error) {
 	var matchingModules []ModuleInfo
 
 	for _, module := range allModules {
-		// Search in name and description
-		if strings.Contains(strings.ToLower(module.Name), query) ||
-			strings.Contains(strings.ToLower(module.Description), query) {
+		if s.moduleMatchesQuery(module, query) {
 			matchingModules = append(matchingModules, module)
 		}
 	}
@@ -119,6 +118,63 @@ func (s *Service) SearchModules(query string) ([]ModuleInfo, error) {
 	return matchingModules, nil
 }
 
+// moduleMatchesQuery checks a lowercase query against the module name,
+// description, category and tags
+func (s *Service) moduleMatchesQuery(module ModuleInfo, query string) bool {
+	if strings.Contains(strings.ToLower(module.Name), query) ||
+		strings.Contains(strings.ToLower(module.Description), query) ||
+		strings.Contains(strings.ToLower(module.Category), query) {
+		return true
+	}
+
+	for _, tag := range module.Tags {
+		if strings.Contains(strings.ToLower(tag), query) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// FilterModules filters modules by category, platform and shell.
+// Empty filters match every module.
+func (s *Service) FilterModules(modules []ModuleInfo, category, platform, shell string) []ModuleInfo {
+	var filtered []ModuleInfo
+	for _, module := range modules {
+		if category != "" && !strings.EqualFold(module.Category, category) {
+			continue
+		}
+		if platform != "" && !matchesListField("", module.Platforms, platform) {
+			continue
+		}
+		if shell != "" && !matchesListField(module.Shell, module.Shells, shell) {
+			continue
+		}
+		filtered = append(filtered, module)
+	}
+
+	return filtered
+}
+
+// matchesListField checks a value against a comma separated field and its
+// list form. Modules that declare neither support everything.
+func matchesListField(field string, list []string, value string) bool {
+	values := list
+	if field != "" {
+		values = append(strings.Split(field, ","), list...)
+	}
+	if len(values) == 0 {
+		return true
+	}
+
+	for _, v := range values {
+		if strings.EqualFold(strings.TrimSpace(v), value) {
+			return true
+		}
+	}
+	return false
+}
+
 // FilterModulesByShell filters modules by shell type
 func (s *Service) FilterModulesByShell(shellType string) ([]ModuleInfo, error) {
 	allModules, err := s.ListAllModules()
@@ -136,7 +192,8 @@ func (s *Service) FilterModulesByShell(shellType string) ([]ModuleInfo, error) {
 	return filteredModules, nil
 }
 
-// GetModuleDetails gets detailed information about a specific module
+// GetModuleDetails gets detailed information about a specific module,
+// including what its module.json adds to the index entry
 func (s *Service) GetModuleDetails(moduleName string) (*ModuleInfo, error) {
 	allModules, err := s.ListAllModules()
 	if err != nil {
@@ -145,6 +202,11 @@ func (s *Service) GetModuleDetails(moduleName string) (*ModuleInfo, error) {
 
 	for _, module := range allModules {
 		if module.Name == moduleName {
+			loaded, err := registry.LoadModule(s.registryClient.RepositoryPath(module.RegistryName), module.Module)
+			if err != nil {
+				return nil, err
+			}
+			module.Module = loaded
 			return &module, nil
 		}
 	}
@@ -1,6 +1,8 @@
 package module
 
 import (
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 
@@ -16,27 +18,27 @@ func TestFilterModules(t *testing.T) {
 				Name:        "git-helpers",
 				Description: "Git helper functions",
 				Shell:       "bash",
+				Category:    "development",
+				Platforms:   []string{"darwin"},
 			},
-			Category: "development",
-			Platform: "darwin",
 		},
 		{
 			Module: registry.Module{
 				Name:        "docker-tools",
 				Description: "Docker utilities",
 				Shell:       "zsh",
+				Category:    "devops",
+				Platforms:   []string{"linux"},
 			},
-			Category: "devops",
-			Platform: "linux",
 		},
 		{
 			Module: registry.Module{
 				Name:        "system-utils",
 				Description: "System utilities",
 				Shell:       "bash",
+				Category:    "utilities",
+				Platforms:   []string{"darwin"},
 			},
-			Category: "utilities",
-			Platform: "darwin",
 		},
 	}
 
@@ -96,9 +98,9 @@ func TestModuleMatchesQuery(t *testing.T) {
 		Module: registry.Module{
 			Name:        "git-helpers",
 			Description: "Git helper functions for development",
+			Category:    "development",
+			Tags:        []string{"git", "version-control", "productivity"},
 		},
-		Category: "development",
-		Tags:     []string{"git", "version-control", "productivity"},
 	}
 
 	tests := []struct {
@@ -153,4 +155,64 @@ func TestModuleMatchesQuery(t *testing.T) {
 			}
 		})
 	}
-}
\ No newline at end of file
+}
+func TestGetModuleDetailsReadsModuleJSON(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	writeCachedRegistry(t, home)
+
+	client, err := registry.NewClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mod, err := NewService(client).GetModuleDetails("git-helpers")
+	if err != nil {
+		t.Fatalf("GetModuleDetails() failed: %v", err)
+	}
+	if mod.Description != "Git helper functions" {
+		t.Errorf("Description = %q, expected the one from module.json", mod.Description)
+	}
+	if len(mod.Aliases) != 1 || mod.Aliases[0].Command != "git status -sb" {
+		t.Errorf("Aliases = %+v, expected the gs alias from module.json", mod.Aliases)
+	}
+	if len(mod.Functions) != 1 || len(mod.Environment) != 1 {
+		t.Errorf("GetModuleDetails() = %+v, expected the function and variable from module.json", mod.Module)
+	}
+	if mod.RegistryName != "test" {
+		t.Errorf("RegistryName = %q, expected test", mod.RegistryName)
+	}
+}
+
+// writeCachedRegistry sets up a registry named test in the cache under home
+// whose index only lists git-helpers, the details are in its module.json
+func writeCachedRegistry(t *testing.T, home string) {
+	t.Helper()
+
+	configDir := filepath.Join(home, ".go-shellify")
+	repoDir := filepath.Join(configDir, "cache", "test")
+	files := map[string]string{
+		filepath.Join(configDir, "registries.json"): `[{"name": "test", "url": "https://example.com/test.git"}]`,
+		filepath.Join(repoDir, "index.json"): `{
+  "name": "test",
+  "modules": {
+    "git-helpers": {"name": "git-helpers", "path": "modules/git-helpers"}
+  }
+}`,
+		filepath.Join(repoDir, "modules", "git-helpers", "module.json"): `{
+  "name": "git-helpers",
+  "description": "Git helper functions",
+  "aliases": [{"name": "gs", "command": "git status -sb"}],
+  "functions": [{"name": "gclean", "commands": ["git clean -n"]}],
+  "environment": [{"name": "GIT_EDITOR", "value": "vim"}]
+}`,
+	}
+	for path, content := range files {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
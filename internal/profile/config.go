@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+
+	"github.com/griffin/go-shellify/internal/shell"
 )
 
 // ProfileConfig represents the user's profile configuration
@@ -19,20 +21,37 @@ type ProfileConfig struct {
 		Filename  string `json:"filename"`
 	} `json:"output"`
 	Modules struct {
-		Enabled    []string `json:"enabled"`
-		Registries []string `json:"registries"`
+		Enabled    []string  `json:"enabled"`
+		Disabled   []string  `json:"disabled,omitempty"` // Modules left out even when "*" enables all
+		Registries []string  `json:"registries"`
+		Precedence []string  `json:"precedence,omitempty"` // Modules that win collisions, highest first
+		Overrides  Overrides `json:"overrides"`
 	} `json:"modules"`
 	Generation struct {
-		Verbose         bool   `json:"verbose"`
-		BackupExisting  bool   `json:"backup_existing"`
-		IntegrationMode string `json:"integration_mode"` // "source" or "manual"
+		Verbose         bool     `json:"verbose"`
+		BackupExisting  bool     `json:"backup_existing"`
+		IntegrationMode string   `json:"integration_mode"` // "source" or "manual"
+		Shells          []string `json:"shells,omitempty"` // Shells to generate for, or "all-installed"
 	} `json:"generation"`
+	Environment   map[string]string `json:"environment,omitempty"` // Values replacing those the modules set
+	HostOverrides []HostOverride    `json:"host_overrides,omitempty"`
+}
+
+// Overrides picks the module that wins when several enabled modules define
+// the same alias, function or environment variable
+type Overrides struct {
+	Aliases     map[string]string `json:"aliases,omitempty"`
+	Functions   map[string]string `json:"functions,omitempty"`
+	Environment map[string]string `json:"environment,omitempty"`
 }
 
 const (
 	ConfigVersion = "1.0.0"
 	ConfigDir     = ".go-shellify"
-	ConfigFile    = "config.json"
+	ConfigFile    = "profile.json"
+
+	// AllInstalled in generation.shells generates for every installed shell
+	AllInstalled = "all-installed"
 )
 
 // DefaultConfig returns a new ProfileConfig with default values
@@ -56,16 +75,20 @@ func DefaultConfig() *ProfileConfig {
 			Filename:  "go-shellify",
 		},
 		Modules: struct {
-			Enabled    []string `json:"enabled"`
-			Registries []string `json:"registries"`
+			Enabled    []string  `json:"enabled"`
+			Disabled   []string  `json:"disabled,omitempty"`
+			Registries []string  `json:"registries"`
+			Precedence []string  `json:"precedence,omitempty"`
+			Overrides  Overrides `json:"overrides"`
 		}{
 			Enabled:    []string{},
 			Registries: []string{},
 		},
 		Generation: struct {
-			Verbose         bool   `json:"verbose"`
-			BackupExisting  bool   `json:"backup_existing"`
-			IntegrationMode string `json:"integration_mode"`
+			Verbose         bool     `json:"verbose"`
+			BackupExisting  bool     `json:"backup_existing"`
+			IntegrationMode string   `json:"integration_mode"`
+			Shells          []string `json:"shells,omitempty"`
 		}{
 			Verbose:         false,
 			BackupExisting:  true,
@@ -169,6 +192,26 @@ func (c *ProfileConfig) validate() error {
 		return fmt.Errorf("invalid integration_mode '%s', must be 'source' or 'manual'", c.Generation.IntegrationMode)
 	}
 	
+	// Validate generation shells
+	for _, name := range c.Generation.Shells {
+		if name == AllInstalled {
+			if len(c.Generation.Shells) > 1 {
+				return fmt.Errorf("generation shells can't combine '%s' with other shells", AllInstalled)
+			}
+			continue
+		}
+		if !shell.IsSupported(name) {
+			return fmt.Errorf("invalid generation shell '%s', must be bash, zsh, fish, powershell, nu, elvish, xonsh, tcsh, csh, cmd or '%s'", name, AllInstalled)
+		}
+	}
+	
+	// Validate host overrides
+	for i, override := range c.HostOverrides {
+		if err := override.validate(); err != nil {
+			return fmt.Errorf("host override %d: %w", i+1, err)
+		}
+	}
+	
 	// Ensure output directory is set
 	if c.Output.Directory == "" {
 		homeDir, _ := os.UserHomeDir()
@@ -185,6 +228,7 @@ func (c *ProfileConfig) validate() error {
 
 // AddModule adds a module to the enabled list if not already present
 func (c *ProfileConfig) AddModule(moduleName string) {
+	c.Modules.Disabled = removeString(c.Modules.Disabled, moduleName)
 	for _, existing := range c.Modules.Enabled {
 		if existing == moduleName {
 			return // Already enabled
@@ -193,18 +237,25 @@ func (c *ProfileConfig) AddModule(moduleName string) {
 	c.Modules.Enabled = append(c.Modules.Enabled, moduleName)
 }
 
-// RemoveModule removes a module from the enabled list
+// RemoveModule removes a module from the enabled list, or adds it to the
+// disabled list when "*" still enables it
 func (c *ProfileConfig) RemoveModule(moduleName string) {
 	for i, existing := range c.Modules.Enabled {
 		if existing == moduleName {
 			c.Modules.Enabled = append(c.Modules.Enabled[:i], c.Modules.Enabled[i+1:]...)
-			return
+			break
 		}
 	}
+	if containsString(c.Modules.Enabled, "*") && !containsString(c.Modules.Disabled, moduleName) {
+		c.Modules.Disabled = append(c.Modules.Disabled, moduleName)
+	}
 }
 
 // IsModuleEnabled checks if a module is enabled
 func (c *ProfileConfig) IsModuleEnabled(moduleName string) bool {
+	if containsString(c.Modules.Disabled, moduleName) {
+		return false
+	}
 	for _, enabled := range c.Modules.Enabled {
 		if enabled == moduleName || enabled == "*" {
 			return true
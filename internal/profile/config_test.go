@@ -42,9 +42,10 @@ func TestConfigValidation(t *testing.T) {
 			config: &ProfileConfig{
 				Version: "1.0.0",
 				Generation: struct {
-					Verbose         bool   `json:"verbose"`
-					BackupExisting  bool   `json:"backup_existing"`
-					IntegrationMode string `json:"integration_mode"`
+					Verbose         bool     `json:"verbose"`
+					BackupExisting  bool     `json:"backup_existing"`
+					IntegrationMode string   `json:"integration_mode"`
+					Shells          []string `json:"shells,omitempty"`
 				}{
 					IntegrationMode: "source",
 				},
@@ -62,15 +63,36 @@ func TestConfigValidation(t *testing.T) {
 			name: "invalid integration mode",
 			config: &ProfileConfig{
 				Generation: struct {
-					Verbose         bool   `json:"verbose"`
-					BackupExisting  bool   `json:"backup_existing"`
-					IntegrationMode string `json:"integration_mode"`
+					Verbose         bool     `json:"verbose"`
+					BackupExisting  bool     `json:"backup_existing"`
+					IntegrationMode string   `json:"integration_mode"`
+					Shells          []string `json:"shells,omitempty"`
 				}{
 					IntegrationMode: "invalid",
 				},
 			},
 			expectErr: true,
 		},
+		{
+			name:      "generation shells",
+			config:    withGenerationShells("bash", "zsh"),
+			expectErr: false,
+		},
+		{
+			name:      "all installed shells",
+			config:    withGenerationShells(AllInstalled),
+			expectErr: false,
+		},
+		{
+			name:      "unsupported generation shell",
+			config:    withGenerationShells("bash", "ksh"),
+			expectErr: true,
+		},
+		{
+			name:      "all installed mixed with shells",
+			config:    withGenerationShells(AllInstalled, "bash"),
+			expectErr: true,
+		},
 	}
 	
 	for _, tt := range tests {
@@ -86,6 +108,13 @@ func TestConfigValidation(t *testing.T) {
 	}
 }
 
+// withGenerationShells returns the default config generating for shells
+func withGenerationShells(shells ...string) *ProfileConfig {
+	config := DefaultConfig()
+	config.Generation.Shells = shells
+	return config
+}
+
 func TestConfigSaveLoad(t *testing.T) {
 	// Create a temporary directory for testing
 	tempDir, err := os.MkdirTemp("", "go-shellify-test")
@@ -188,6 +217,24 @@ func TestModuleManagement(t *testing.T) {
 	if !config.IsModuleEnabled("any-module") {
 		t.Error("Expected any module to be enabled with wildcard")
 	}
+
+	// Test removing a module the wildcard enables
+	config.RemoveModule("node")
+	if config.IsModuleEnabled("node") {
+		t.Error("Expected node module to be disabled under the wildcard")
+	}
+	if !config.IsModuleEnabled("any-module") {
+		t.Error("Expected other modules to stay enabled with wildcard")
+	}
+	config.RemoveModule("node")
+	if len(config.Modules.Disabled) != 1 {
+		t.Errorf("Expected node to be disabled once, got %v", config.Modules.Disabled)
+	}
+
+	config.AddModule("node")
+	if !config.IsModuleEnabled("node") || len(config.Modules.Disabled) != 0 {
+		t.Errorf("Expected node to be enabled again, disabled %v", config.Modules.Disabled)
+	}
 }
 
 func TestRegistryManagement(t *testing.T) {
@@ -0,0 +1,140 @@
+package profile
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"runtime"
+	"strings"
+)
+
+// EnvTagVariable names the environment variable holding the machine's
+// environment tag, such as SHELLIFY_ENV=work
+const EnvTagVariable = "SHELLIFY_ENV"
+
+// HostOverride changes the profile on the machines it matches. Every key
+// that is set must match: a hostname glob, an OS and an environment tag.
+type HostOverride struct {
+	Hostname    string            `json:"hostname,omitempty"` // Glob such as "work-*", case-insensitive
+	OS          string            `json:"os,omitempty"`       // darwin, linux or windows
+	Env         string            `json:"env,omitempty"`      // Matched against $SHELLIFY_ENV
+	Enable      []string          `json:"enable,omitempty"`
+	Disable     []string          `json:"disable,omitempty"`
+	Environment map[string]string `json:"environment,omitempty"` // Values replacing those the modules set
+}
+
+// Machine describes the machine a profile is resolved for
+type Machine struct {
+	Hostname string
+	OS       string
+	Env      string
+}
+
+// CurrentMachine returns the machine go-shellify runs on
+func CurrentMachine() Machine {
+	hostname, _ := os.Hostname()
+	return Machine{Hostname: hostname, OS: runtime.GOOS, Env: os.Getenv(EnvTagVariable)}
+}
+
+func (m Machine) String() string {
+	env := EnvTagVariable + " unset"
+	if m.Env != "" {
+		env = EnvTagVariable + "=" + m.Env
+	}
+	return fmt.Sprintf("%s (%s, %s)", m.Hostname, m.OS, env)
+}
+
+// Matches reports whether the override applies to a machine
+func (o HostOverride) Matches(m Machine) bool {
+	if o.Hostname != "" {
+		matched, err := path.Match(strings.ToLower(o.Hostname), strings.ToLower(m.Hostname))
+		if err != nil || !matched {
+			return false
+		}
+	}
+	if o.OS != "" && !strings.EqualFold(o.OS, m.OS) {
+		return false
+	}
+	return o.Env == "" || o.Env == m.Env
+}
+
+func (o HostOverride) String() string {
+	var keys []string
+	if o.Hostname != "" {
+		keys = append(keys, "hostname "+o.Hostname)
+	}
+	if o.OS != "" {
+		keys = append(keys, "os "+o.OS)
+	}
+	if o.Env != "" {
+		keys = append(keys, "env "+o.Env)
+	}
+	return strings.Join(keys, ", ")
+}
+
+// validate ensures the override has keys to match and a valid hostname glob
+func (o HostOverride) validate() error {
+	if o.Hostname == "" && o.OS == "" && o.Env == "" {
+		return fmt.Errorf("needs a hostname, os or env to match")
+	}
+	if _, err := path.Match(o.Hostname, ""); err != nil {
+		return fmt.Errorf("invalid hostname glob '%s': %w", o.Hostname, err)
+	}
+	return nil
+}
+
+// Resolve returns the profile with the host overrides matching a machine
+// applied in order, and the overrides that matched. The profile itself is
+// left as is so it can still be saved.
+func (c *ProfileConfig) Resolve(m Machine) (*ProfileConfig, []HostOverride) {
+	resolved := *c
+	resolved.Modules.Enabled = append([]string{}, c.Modules.Enabled...)
+	resolved.Modules.Disabled = append([]string{}, c.Modules.Disabled...)
+	resolved.Environment = make(map[string]string, len(c.Environment))
+	for name, value := range c.Environment {
+		resolved.Environment[name] = value
+	}
+	resolved.HostOverrides = nil
+
+	var matched []HostOverride
+	for _, o := range c.HostOverrides {
+		if !o.Matches(m) {
+			continue
+		}
+		matched = append(matched, o)
+		for _, name := range o.Enable {
+			resolved.AddModule(name)
+		}
+		for _, name := range o.Disable {
+			resolved.RemoveModule(name)
+			if !containsString(resolved.Modules.Disabled, name) {
+				resolved.Modules.Disabled = append(resolved.Modules.Disabled, name)
+			}
+		}
+		for name, value := range o.Environment {
+			resolved.Environment[name] = value
+		}
+	}
+	return &resolved, matched
+}
+
+// containsString checks if a list contains a value
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// removeString returns a list without a value
+func removeString(values []string, value string) []string {
+	var kept []string
+	for _, v := range values {
+		if v != value {
+			kept = append(kept, v)
+		}
+	}
+	return kept
+}
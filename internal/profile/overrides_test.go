@@ -0,0 +1,128 @@
+package profile
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestHostOverrideMatches(t *testing.T) {
+	machine := Machine{Hostname: "Work-Laptop.local", OS: "darwin", Env: "work"}
+
+	tests := []struct {
+		name     string
+		override HostOverride
+		expected bool
+	}{
+		{name: "hostname glob", override: HostOverride{Hostname: "work-*"}, expected: true},
+		{name: "other hostname", override: HostOverride{Hostname: "build-?"}, expected: false},
+		{name: "os", override: HostOverride{OS: "Darwin"}, expected: true},
+		{name: "env tag", override: HostOverride{Env: "work"}, expected: true},
+		{name: "other env tag", override: HostOverride{Env: "home"}, expected: false},
+		{name: "all keys", override: HostOverride{Hostname: "*.local", OS: "darwin", Env: "work"}, expected: true},
+		{name: "one key differs", override: HostOverride{Hostname: "*.local", OS: "linux"}, expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if matched := tt.override.Matches(machine); matched != tt.expected {
+				t.Errorf("Matches() = %v, expected %v", matched, tt.expected)
+			}
+		})
+	}
+}
+
+func TestResolve(t *testing.T) {
+	config := DefaultConfig()
+	config.Modules.Enabled = []string{"git", "kube"}
+	config.Environment = map[string]string{"EDITOR": "vim"}
+	config.HostOverrides = []HostOverride{
+		{Env: "work", Enable: []string{"vpn"}, Environment: map[string]string{"HTTP_PROXY": "http://proxy:3128"}},
+		{Hostname: "srv-*", Disable: []string{"kube"}, Environment: map[string]string{"EDITOR": "nano"}},
+		{OS: "windows", Enable: []string{"winget"}},
+	}
+
+	tests := []struct {
+		name        string
+		machine     Machine
+		enabled     []string
+		environment map[string]string
+		matched     int
+	}{
+		{
+			name:        "no overrides match",
+			machine:     Machine{Hostname: "home", OS: "linux"},
+			enabled:     []string{"git", "kube"},
+			environment: map[string]string{"EDITOR": "vim"},
+		},
+		{
+			name:        "work laptop",
+			machine:     Machine{Hostname: "laptop", OS: "darwin", Env: "work"},
+			enabled:     []string{"git", "kube", "vpn"},
+			environment: map[string]string{"EDITOR": "vim", "HTTP_PROXY": "http://proxy:3128"},
+			matched:     1,
+		},
+		{
+			name:        "work server",
+			machine:     Machine{Hostname: "srv-01", OS: "linux", Env: "work"},
+			enabled:     []string{"git", "vpn"},
+			environment: map[string]string{"EDITOR": "nano", "HTTP_PROXY": "http://proxy:3128"},
+			matched:     2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resolved, matched := config.Resolve(tt.machine)
+			if !reflect.DeepEqual(resolved.Modules.Enabled, tt.enabled) {
+				t.Errorf("Resolve() enabled = %v, expected %v", resolved.Modules.Enabled, tt.enabled)
+			}
+			if !reflect.DeepEqual(resolved.Environment, tt.environment) {
+				t.Errorf("Resolve() environment = %v, expected %v", resolved.Environment, tt.environment)
+			}
+			if len(matched) != tt.matched {
+				t.Errorf("Resolve() matched %d overrides, expected %d", len(matched), tt.matched)
+			}
+		})
+	}
+
+	if len(config.Modules.Enabled) != 2 || config.Environment["EDITOR"] != "vim" {
+		t.Errorf("Resolve() changed the profile: %v %v", config.Modules.Enabled, config.Environment)
+	}
+}
+
+func TestResolveDisablesWildcard(t *testing.T) {
+	config := DefaultConfig()
+	config.Modules.Enabled = []string{"*"}
+	config.HostOverrides = []HostOverride{{OS: "linux", Disable: []string{"brew"}}}
+
+	resolved, _ := config.Resolve(Machine{OS: "linux"})
+	if resolved.IsModuleEnabled("brew") || !resolved.IsModuleEnabled("git") {
+		t.Errorf("Resolve() with * enabled: brew %v, git %v, expected only git", resolved.IsModuleEnabled("brew"), resolved.IsModuleEnabled("git"))
+	}
+	if !config.IsModuleEnabled("brew") {
+		t.Error("Resolve() disabled brew in the profile itself")
+	}
+}
+
+func TestHostOverrideValidation(t *testing.T) {
+	tests := []struct {
+		name      string
+		override  HostOverride
+		expectErr bool
+	}{
+		{name: "hostname", override: HostOverride{Hostname: "work-*", Enable: []string{"vpn"}}},
+		{name: "no keys", override: HostOverride{Enable: []string{"vpn"}}, expectErr: true},
+		{name: "invalid glob", override: HostOverride{Hostname: "work-[", Enable: []string{"vpn"}}, expectErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := DefaultConfig()
+			config.HostOverrides = []HostOverride{tt.override}
+			err := config.validate()
+			if (err != nil) != tt.expectErr {
+				t.Errorf("validate() error = %v, expectErr %v", err, tt.expectErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,440 @@
+// Package quote quotes values for safe use in generated shell code.
+//
+// Every shell gets two functions. The plain one quotes a value literally:
+// the shell sees exactly the given string, whatever it contains. The Expand
+// variant keeps portable variable references, $NAME and ${NAME}, working in
+// the target shell's own syntax and quotes everything else literally, so
+// command substitutions, globs and escapes in a value are never evaluated.
+//
+// NUL bytes can't be passed to any shell and are removed.
+package quote
+
+import (
+	"errors"
+	"strings"
+	"unicode/utf8"
+)
+
+// ErrUnquotable is returned for values a shell has no way to represent
+var ErrUnquotable = errors.New("value cannot be quoted for this shell")
+
+// part is a piece of a value: literal text or a variable reference
+type part struct {
+	text     string
+	variable string
+}
+
+// split breaks a value into literal text and $NAME or ${NAME} references.
+// Any other use of $ is literal.
+func split(value string) []part {
+	value = strings.ReplaceAll(value, "\x00", "")
+
+	var parts []part
+	var text strings.Builder
+	flush := func() {
+		if text.Len() > 0 {
+			parts = append(parts, part{text: text.String()})
+			text.Reset()
+		}
+	}
+
+	for i := 0; i < len(value); i++ {
+		if value[i] != '$' || i+1 == len(value) {
+			text.WriteByte(value[i])
+			continue
+		}
+
+		if value[i+1] == '{' {
+			end := strings.IndexByte(value[i+2:], '}')
+			if end > 0 && IsName(value[i+2:i+2+end]) {
+				flush()
+				parts = append(parts, part{variable: value[i+2 : i+2+end]})
+				i += 2 + end
+				continue
+			}
+		} else if isNameStart(value[i+1]) {
+			end := i + 2
+			for end < len(value) && isNameChar(value[end]) {
+				end++
+			}
+			flush()
+			parts = append(parts, part{variable: value[i+1 : end]})
+			i = end - 1
+			continue
+		}
+
+		text.WriteByte('$')
+	}
+	flush()
+
+	return parts
+}
+
+// IsName reports whether a string is a valid portable variable name
+func IsName(name string) bool {
+	if name == "" || !isNameStart(name[0]) {
+		return false
+	}
+	for i := 1; i < len(name); i++ {
+		if !isNameChar(name[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func isNameStart(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isNameChar(c byte) bool {
+	return isNameStart(c) || (c >= '0' && c <= '9')
+}
+
+// continuesName reports whether the text after the variable reference at
+// index i would be read as part of the variable name
+func continuesName(parts []part, i int) bool {
+	return i+1 < len(parts) && parts[i+1].variable == "" && isNameChar(parts[i+1].text[0])
+}
+
+// escapeBytes prefixes every byte of text found in special with escape.
+// Working on bytes keeps invalid UTF-8 intact.
+func escapeBytes(text, special string, escape byte) string {
+	var b strings.Builder
+	for i := 0; i < len(text); i++ {
+		if strings.IndexByte(special, text[i]) >= 0 {
+			b.WriteByte(escape)
+		}
+		b.WriteByte(text[i])
+	}
+	return b.String()
+}
+
+// POSIX quotes a value literally for bash, zsh and other POSIX shells
+func POSIX(value string) string {
+	value = strings.ReplaceAll(value, "\x00", "")
+	return "'" + strings.ReplaceAll(value, "'", `'\''`) + "'"
+}
+
+// POSIXExpand quotes a value for POSIX shells, expanding variable references
+func POSIXExpand(value string) string {
+	parts := split(value)
+
+	var b strings.Builder
+	b.WriteByte('"')
+	for i, p := range parts {
+		if p.variable == "" {
+			b.WriteString(escapeBytes(p.text, "\\\"`$", '\\'))
+			continue
+		}
+
+		if continuesName(parts, i) {
+			b.WriteString("${" + p.variable + "}")
+		} else {
+			b.WriteString("$" + p.variable)
+		}
+	}
+	b.WriteByte('"')
+	return b.String()
+}
+
+// Fish quotes a value literally for fish
+func Fish(value string) string {
+	value = strings.ReplaceAll(value, "\x00", "")
+	value = strings.ReplaceAll(value, `\`, `\\`)
+	return "'" + strings.ReplaceAll(value, "'", `\'`) + "'"
+}
+
+// FishExpand quotes a value for fish, expanding variable references. fish
+// has no ${NAME} form, so a reference followed by a name character ends the
+// quoted string and a new one starts after it.
+func FishExpand(value string) string {
+	parts := split(value)
+
+	var b strings.Builder
+	b.WriteByte('"')
+	for i, p := range parts {
+		if p.variable == "" {
+			b.WriteString(escapeBytes(p.text, "\\\"$", '\\'))
+			continue
+		}
+
+		b.WriteString("$" + p.variable)
+		if continuesName(parts, i) {
+			b.WriteString(`""`)
+		}
+	}
+	b.WriteByte('"')
+	return b.String()
+}
+
+// powershellSingleQuotes are the characters PowerShell treats as single quotes
+const powershellSingleQuotes = "'‘’‚‛"
+
+// powershellDoubleQuotes are the characters PowerShell treats as double quotes
+const powershellDoubleQuotes = "\"“”„"
+
+// PowerShell quotes a value literally for PowerShell
+func PowerShell(value string) string {
+	value = strings.ReplaceAll(value, "\x00", "")
+
+	var b strings.Builder
+	b.WriteByte('\'')
+	for len(value) > 0 {
+		r, size := utf8.DecodeRuneInString(value)
+		if strings.ContainsRune(powershellSingleQuotes, r) {
+			b.WriteString(value[:size])
+		}
+		b.WriteString(value[:size])
+		value = value[size:]
+	}
+	b.WriteByte('\'')
+	return b.String()
+}
+
+// PowerShellExpand quotes a value for PowerShell, expanding variable
+// references as environment variables. $HOME keeps referring to
+// PowerShell's automatic variable, which is set on every platform.
+func PowerShellExpand(value string) string {
+	var b strings.Builder
+	b.WriteByte('"')
+	for _, p := range split(value) {
+		if p.variable == "" {
+			for text := p.text; len(text) > 0; {
+				r, size := utf8.DecodeRuneInString(text)
+				if r == '`' || r == '$' || strings.ContainsRune(powershellDoubleQuotes, r) {
+					b.WriteByte('`')
+				}
+				b.WriteString(text[:size])
+				text = text[size:]
+			}
+			continue
+		}
+
+		if p.variable == "HOME" {
+			b.WriteString("${HOME}")
+		} else {
+			b.WriteString("${env:" + p.variable + "}")
+		}
+	}
+	b.WriteByte('"')
+	return b.String()
+}
+
+// Nu quotes a value literally for Nushell, as a single-quoted string or a
+// raw string when it contains single quotes
+func Nu(value string) string {
+	value = strings.ReplaceAll(value, "\x00", "")
+	if !strings.Contains(value, "'") {
+		return "'" + value + "'"
+	}
+
+	hashes := "#"
+	for strings.Contains(value, "'"+hashes) {
+		hashes += "#"
+	}
+	return "r" + hashes + "'" + value + "'" + hashes
+}
+
+// NuExpand quotes a value for Nushell, expanding variable references from
+// $env in an interpolated string. Unset variables expand to nothing.
+func NuExpand(value string) string {
+	parts := split(value)
+	if len(parts) == 0 || (len(parts) == 1 && parts[0].variable == "") {
+		return Nu(value)
+	}
+
+	var b strings.Builder
+	b.WriteString(`$"`)
+	for _, p := range parts {
+		if p.variable == "" {
+			b.WriteString(escapeBytes(p.text, "\\\"(", '\\'))
+			continue
+		}
+		b.WriteString("($env." + p.variable + "? | default '')")
+	}
+	b.WriteByte('"')
+	return b.String()
+}
+
+// Elvish quotes a value literally for Elvish
+func Elvish(value string) string {
+	value = strings.ReplaceAll(value, "\x00", "")
+	return "'" + strings.ReplaceAll(value, "'", "''") + "'"
+}
+
+// ElvishExpand quotes a value for Elvish, expanding variable references from
+// the E: namespace in a compound expression. Unset variables expand to
+// nothing.
+func ElvishExpand(value string) string {
+	parts := split(value)
+	if len(parts) == 0 {
+		return "''"
+	}
+
+	var b strings.Builder
+	for _, p := range parts {
+		if p.variable != "" {
+			b.WriteString("$E:" + p.variable)
+			continue
+		}
+		b.WriteString(Elvish(p.text))
+	}
+	return b.String()
+}
+
+// Xonsh quotes a value literally as a Python string for xonsh
+func Xonsh(value string) string {
+	value = strings.ReplaceAll(value, "\x00", "")
+	value = escapeBytes(value, `\'`, '\\')
+	value = strings.ReplaceAll(value, "\n", `\n`)
+	return "'" + strings.ReplaceAll(value, "\r", `\r`) + "'"
+}
+
+// XonshExpand quotes a value for xonsh, concatenating the literal text with
+// the referenced environment variables. Unset variables expand to nothing.
+func XonshExpand(value string) string {
+	parts := split(value)
+	if len(parts) == 0 {
+		return "''"
+	}
+
+	quoted := make([]string, len(parts))
+	for i, p := range parts {
+		if p.variable != "" {
+			// detype gives the string form of list variables such as $PATH
+			quoted[i] = "${...}.detype().get(" + Xonsh(p.variable) + ", '')"
+			continue
+		}
+		quoted[i] = Xonsh(p.text)
+	}
+	return strings.Join(quoted, " + ")
+}
+
+// Csh quotes a value literally for csh and tcsh. ! starts a history
+// substitution even inside single quotes and is escaped; a newline is
+// continued with a backslash.
+func Csh(value string) string {
+	value = strings.ReplaceAll(value, "\x00", "")
+	return "'" + cshText(value) + "'"
+}
+
+// CshExpand quotes a value for csh and tcsh, expanding variable references.
+// csh fails on unset variables, so the referenced variables must be set.
+func CshExpand(value string) string {
+	parts := split(value)
+	if len(parts) == 0 {
+		return "''"
+	}
+
+	var b strings.Builder
+	for _, p := range parts {
+		if p.variable != "" {
+			b.WriteString(`"${` + p.variable + `}"`)
+			continue
+		}
+		b.WriteString("'" + cshText(p.text) + "'")
+	}
+	return b.String()
+}
+
+// cshText escapes text for csh single quotes
+func cshText(text string) string {
+	text = strings.ReplaceAll(text, "'", `'\''`)
+	text = strings.ReplaceAll(text, "!", `\!`)
+	return strings.ReplaceAll(text, "\n", "\\\n")
+}
+
+// cmdSpecial are the characters cmd.exe interprets outside quotes
+const cmdSpecial = "^&|<>()"
+
+// Cmd quotes a value literally as an argument in a cmd.exe batch file.
+// Double quotes and line breaks can't be represented. Delayed expansion must
+// be off, as it is by default, or ! is interpreted too.
+func Cmd(value string) (string, error) {
+	return cmdArgument([]part{{text: value}})
+}
+
+// CmdExpand quotes an argument for a cmd.exe batch file, expanding variable
+// references as %NAME%. $HOME refers to %USERPROFILE%, as cmd.exe doesn't
+// set HOME.
+func CmdExpand(value string) (string, error) {
+	return cmdArgument(split(value))
+}
+
+// CmdSet returns a batch file command setting a variable to a literal value
+func CmdSet(name, value string) (string, error) {
+	return cmdSet(name, []part{{text: value}})
+}
+
+// CmdSetExpand returns a batch file command setting a variable to a value,
+// expanding variable references as %NAME% like CmdExpand
+func CmdSetExpand(name, value string) (string, error) {
+	return cmdSet(name, split(value))
+}
+
+// cmdArgument wraps a value in double quotes for cmd.exe
+func cmdArgument(parts []part) (string, error) {
+	for _, p := range parts {
+		if strings.ContainsRune(p.text, '"') {
+			return "", ErrUnquotable
+		}
+	}
+
+	text, err := cmdText(parts)
+	if err != nil {
+		return "", err
+	}
+	return `"` + text + `"`, nil
+}
+
+// cmdSet builds a set "NAME=value" command. set takes everything up to the
+// last double quote, so quotes inside the value are kept as they are.
+func cmdSet(name string, parts []part) (string, error) {
+	if !IsName(name) {
+		return "", ErrUnquotable
+	}
+
+	text, err := cmdText(parts)
+	if err != nil {
+		return "", err
+	}
+	return `set "` + name + "=" + text + `"`, nil
+}
+
+// cmdText escapes the text between an opening double quote and the closing
+// one. cmd.exe has no escape for a double quote inside quotes, each one just
+// toggles quoting, so special characters that end up outside quotes are
+// escaped with ^ instead.
+func cmdText(parts []part) (string, error) {
+	var b strings.Builder
+	quoted := true
+	for _, p := range parts {
+		if p.variable == "HOME" {
+			b.WriteString("%USERPROFILE%")
+			continue
+		}
+		if p.variable != "" {
+			b.WriteString("%" + p.variable + "%")
+			continue
+		}
+
+		text := strings.ReplaceAll(p.text, "\x00", "")
+		for i := 0; i < len(text); i++ {
+			c := text[i]
+			switch {
+			case c == '\n' || c == '\r':
+				return "", ErrUnquotable
+			case c == '%':
+				b.WriteString("%%")
+				continue
+			case c == '"':
+				quoted = !quoted
+			case !quoted && strings.IndexByte(cmdSpecial, c) >= 0:
+				b.WriteByte('^')
+			}
+			b.WriteByte(c)
+		}
+	}
+	return b.String(), nil
+}
@@ -0,0 +1,425 @@
+package quote
+
+import (
+	"encoding/base64"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+// values is the round-trip corpus: every printable ASCII character on its
+// own and in context, plus the cases that break naive quoting
+func values() []string {
+	result := []string{
+		"",
+		"simple",
+		"with space",
+		"  leading and trailing  ",
+		"it's",
+		`say "hi"`,
+		`'both' "kinds"`,
+		"$HOME",
+		"${HOME}",
+		"$",
+		"$$",
+		"cost: 5$",
+		"${",
+		"${not a name}",
+		"$(whoami)",
+		"`id`",
+		`back\slash`,
+		`trailing\`,
+		`\n is not a newline`,
+		"new\nline",
+		"windows\r\nline",
+		"tab\there",
+		"!bang !! !$",
+		"*glob?[a]",
+		"~tilde",
+		"a;b&c|d>e<f",
+		"#hash",
+		"%PATH%",
+		"^caret",
+		"(paren)",
+		"{a,b}",
+		"-n",
+		"--",
+		"héllo 世界",
+		"‘smart’ “quotes”",
+		"'\\''",
+		"\x01\x02\x1b[31m",
+		"invalid \xff utf8",
+		"nul\x00byte",
+	}
+
+	var all strings.Builder
+	for c := byte(0x20); c < 0x7f; c++ {
+		result = append(result, string(c), "a"+string(c)+"b")
+		all.WriteByte(c)
+	}
+	return append(result, all.String())
+}
+
+func TestSplit(t *testing.T) {
+	tests := []struct {
+		value    string
+		expected []part
+	}{
+		{value: "plain", expected: []part{{text: "plain"}}},
+		{value: "$HOME/bin", expected: []part{{variable: "HOME"}, {text: "/bin"}}},
+		{value: "${A}b$C", expected: []part{{variable: "A"}, {text: "b"}, {variable: "C"}}},
+		{value: "$A$B", expected: []part{{variable: "A"}, {variable: "B"}}},
+		{value: "$(cmd) ${1} ${a b} $", expected: []part{{text: "$(cmd) ${1} ${a b} $"}}},
+		{value: "5$ and $_x1!", expected: []part{{text: "5$ and "}, {variable: "_x1"}, {text: "!"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			if got := split(tt.value); fmt.Sprint(got) != fmt.Sprint(tt.expected) {
+				t.Errorf("split() = %v, expected %v", got, tt.expected)
+			}
+		})
+	}
+}
+
+// shell describes how to print a quoted value in a locally installed shell
+type shell struct {
+	name    string
+	binary  string
+	args    []string
+	literal func(string) string
+	expand  func(string) string
+	print   string // Format of a command printing one quoted value
+	utf8    bool   // Whether the shell only reads valid UTF-8
+	strict  bool   // Whether referencing an unset variable is an error
+}
+
+var shells = []shell{
+	{name: "bash", binary: "bash", args: []string{"--norc", "--noprofile", "-c"}, literal: POSIX, expand: POSIXExpand, print: "printf '%%s' %s"},
+	{name: "sh", binary: "sh", args: []string{"-c"}, literal: POSIX, expand: POSIXExpand, print: "printf '%%s' %s"},
+	{name: "zsh", binary: "zsh", args: []string{"-f", "-c"}, literal: POSIX, expand: POSIXExpand, print: "printf '%%s' %s"},
+	{name: "fish", binary: "fish", args: []string{"--no-config", "-c"}, literal: Fish, expand: FishExpand, print: "printf '%%s' %s"},
+	{name: "nu", binary: "nu", args: []string{"--no-config-file", "-c"}, literal: Nu, expand: NuExpand, print: "print --no-newline %s", utf8: true},
+	{name: "elvish", binary: "elvish", args: []string{"-norc", "-c"}, literal: Elvish, expand: ElvishExpand, print: "print %s", utf8: true},
+	{name: "xonsh", binary: "xonsh", args: []string{"--no-rc", "-c"}, literal: Xonsh, expand: XonshExpand, print: "print(%s, end='')", utf8: true},
+	{name: "tcsh", binary: "tcsh", args: []string{"-f", "-c"}, literal: Csh, expand: CshExpand, print: "printf '%%s' %s", strict: true},
+}
+
+// run executes a script in a shell and returns its output
+func run(t *testing.T, path string, args []string, script string, env []string) string {
+	t.Helper()
+
+	cmd := exec.Command(path, append(args, script)...)
+	cmd.Env = append(os.Environ(), env...)
+	output, err := cmd.Output()
+	if err != nil {
+		t.Fatalf("script failed: %v\n%s", err, script)
+	}
+	return string(output)
+}
+
+// expected returns what a value should expand to with the given variables
+func expected(value string, vars map[string]string) string {
+	var b strings.Builder
+	for _, p := range split(value) {
+		if p.variable != "" {
+			b.WriteString(vars[p.variable])
+		} else {
+			b.WriteString(p.text)
+		}
+	}
+	return b.String()
+}
+
+// expandValues are values with variable references for the expand round trip
+var expandValues = []string{
+	"$QT_A",
+	"${QT_A}suffix",
+	"$QT_A$QT_B",
+	"pre $QT_A/$QT_B post",
+	"$QT_A_suffix_is_part_of_the_name",
+	"${QT_A}_x and ${QT_B}1",
+	"$QT_UNSET end",
+	"$(echo no) `echo no` $QT_A",
+}
+
+var expandVars = map[string]string{
+	"QT_A": `a 'v"al $x`,
+	"QT_B": "b*",
+}
+
+func expandEnv() []string {
+	return []string{"QT_A=" + expandVars["QT_A"], "QT_B=" + expandVars["QT_B"]}
+}
+
+func TestRoundTrip(t *testing.T) {
+	for _, sh := range shells {
+		t.Run(sh.name, func(t *testing.T) {
+			path, err := exec.LookPath(sh.binary)
+			if err != nil {
+				t.Skipf("%s not available", sh.binary)
+			}
+
+			for _, value := range values() {
+				if sh.utf8 && !utf8.ValidString(value) {
+					continue
+				}
+				want := strings.ReplaceAll(value, "\x00", "")
+
+				if got := run(t, path, sh.args, fmt.Sprintf(sh.print, sh.literal(value)), nil); got != want {
+					t.Errorf("literal %q printed %q", value, got)
+				}
+				if strings.Contains(value, "$") {
+					// Expand keeps references to variables from the environment
+					continue
+				}
+				if got := run(t, path, sh.args, fmt.Sprintf(sh.print, sh.expand(value)), nil); got != want {
+					t.Errorf("expand %q printed %q", value, got)
+				}
+			}
+
+			for _, value := range expandValues {
+				if sh.strict && strings.Contains(value, "$QT_UNSET") {
+					continue
+				}
+				if got := run(t, path, sh.args, fmt.Sprintf(sh.print, sh.expand(value)), expandEnv()); got != expected(value, expandVars) {
+					t.Errorf("expand %q printed %q, expected %q", value, got, expected(value, expandVars))
+				}
+			}
+		})
+	}
+}
+
+func TestRoundTripPowerShell(t *testing.T) {
+	path, err := exec.LookPath("pwsh")
+	if err != nil {
+		t.Skip("pwsh not available")
+	}
+
+	// Starting pwsh is slow, so print every value in one script as base64
+	var script strings.Builder
+	var want []string
+	emit := func(quoted, value string) {
+		fmt.Fprintf(&script, "[Convert]::ToBase64String([Text.Encoding]::UTF8.GetBytes(%s))\n", quoted)
+		want = append(want, value)
+	}
+	for _, value := range values() {
+		if !utf8.ValidString(value) {
+			// .NET strings can't carry invalid UTF-8
+			continue
+		}
+		emit(PowerShell(value), strings.ReplaceAll(value, "\x00", ""))
+		if !strings.Contains(value, "$") {
+			emit(PowerShellExpand(value), strings.ReplaceAll(value, "\x00", ""))
+		}
+	}
+	for _, value := range expandValues {
+		emit(PowerShellExpand(value), expected(value, expandVars))
+	}
+
+	file := filepath.Join(t.TempDir(), "quote.ps1")
+	if err := os.WriteFile(file, []byte(script.String()), 0644); err != nil {
+		t.Fatal(err)
+	}
+	output := run(t, path, []string{"-NoProfile", "-NonInteractive", "-File"}, file, expandEnv())
+
+	lines := strings.Fields(output)
+	if len(lines) != len(want) {
+		t.Fatalf("printed %d values, expected %d", len(lines), len(want))
+	}
+	for i, line := range lines {
+		got, _ := base64.StdEncoding.DecodeString(line)
+		if string(got) != want[i] {
+			t.Errorf("value %d printed %q, expected %q", i, got, want[i])
+		}
+	}
+}
+
+func TestNu(t *testing.T) {
+	tests := []struct {
+		value    string
+		expand   bool
+		expected string
+	}{
+		{value: `plain \n "x"`, expected: `'plain \n "x"'`},
+		{value: "it's", expected: `r#'it's'#`},
+		{value: "a'#b'", expected: `r##'a'#b''##`},
+		{value: "$HOME/(x) \"q\"", expand: true, expected: `$"($env.HOME? | default '')/\(x) \"q\""`},
+		{value: "no variables's", expand: true, expected: `r#'no variables's'#`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			quoted := Nu(tt.value)
+			if tt.expand {
+				quoted = NuExpand(tt.value)
+			}
+			if quoted != tt.expected {
+				t.Errorf("quoted %q as %s, expected %s", tt.value, quoted, tt.expected)
+			}
+		})
+	}
+}
+
+func TestElvish(t *testing.T) {
+	tests := []struct {
+		value    string
+		expand   bool
+		expected string
+	}{
+		{value: "", expected: "''"},
+		{value: `it's "$HOME" \n`, expected: `'it''s "$HOME" \n'`},
+		{value: "", expand: true, expected: "''"},
+		{value: "$HOME/bin:${PATH}x", expand: true, expected: `$E:HOME'/bin:'$E:PATH'x'`},
+		{value: "$A$B", expand: true, expected: `$E:A$E:B`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			quoted := Elvish(tt.value)
+			if tt.expand {
+				quoted = ElvishExpand(tt.value)
+			}
+			if quoted != tt.expected {
+				t.Errorf("quoted %q as %s, expected %s", tt.value, quoted, tt.expected)
+			}
+		})
+	}
+}
+
+func TestXonsh(t *testing.T) {
+	tests := []struct {
+		value    string
+		expand   bool
+		expected string
+	}{
+		{value: "", expected: "''"},
+		{value: "it's \\ {x}\n\r", expected: `'it\'s \\ {x}\n\r'`},
+		{value: "", expand: true, expected: "''"},
+		{value: "$HOME/bin", expand: true, expected: `${...}.detype().get('HOME', '') + '/bin'`},
+		{value: "no $ here", expand: true, expected: `'no $ here'`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			quoted := Xonsh(tt.value)
+			if tt.expand {
+				quoted = XonshExpand(tt.value)
+			}
+			if quoted != tt.expected {
+				t.Errorf("quoted %q as %s, expected %s", tt.value, quoted, tt.expected)
+			}
+		})
+	}
+}
+
+func TestCsh(t *testing.T) {
+	tests := []struct {
+		value    string
+		expand   bool
+		expected string
+	}{
+		{value: "", expected: "''"},
+		{value: `it's $HOME`, expected: `'it'\''s $HOME'`},
+		{value: "bang! and\nline", expected: "'bang\\! and\\\nline'"},
+		{value: "", expand: true, expected: "''"},
+		{value: "$HOME/bin:${PATH}x", expand: true, expected: `"${HOME}"'/bin:'"${PATH}"'x'`},
+		{value: "cost: 5$!", expand: true, expected: `'cost: 5$\!'`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			quoted := Csh(tt.value)
+			if tt.expand {
+				quoted = CshExpand(tt.value)
+			}
+			if quoted != tt.expected {
+				t.Errorf("quoted %q as %s, expected %s", tt.value, quoted, tt.expected)
+			}
+		})
+	}
+}
+
+func TestCmd(t *testing.T) {
+	tests := []struct {
+		name     string
+		value    string
+		expand   bool
+		expected string
+		err      bool
+	}{
+		{name: "plain", value: "plain", expected: `set "QT=plain"`},
+		{name: "specials inside quotes", value: "a&b|c<d>e^f(g)", expected: `set "QT=a&b|c<d>e^f(g)"`},
+		{name: "percent", value: "100%", expected: `set "QT=100%%"`},
+		{name: "embedded quotes", value: `a"b&c"d&e`, expected: `set "QT=a"b^&c"d&e"`},
+		{name: "expand", value: "$HOME\\bin;%x%;$APPDATA", expand: true, expected: `set "QT=%USERPROFILE%\bin;%%x%%;%APPDATA%"`},
+		{name: "newline", value: "a\nb", err: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			set := CmdSet
+			if tt.expand {
+				set = CmdSetExpand
+			}
+
+			got, err := set("QT", tt.value)
+			if tt.err {
+				if err == nil {
+					t.Errorf("CmdSet() = %q, expected an error", got)
+				}
+				return
+			}
+			if err != nil || got != tt.expected {
+				t.Errorf("CmdSet() = %q, %v, expected %q", got, err, tt.expected)
+			}
+		})
+	}
+
+	if _, err := CmdSet("bad name", "x"); err == nil {
+		t.Error("CmdSet() should reject invalid variable names")
+	}
+	if _, err := Cmd(`say "hi"`); err == nil {
+		t.Error("Cmd() should reject values with double quotes")
+	}
+}
+
+func TestRoundTripCmd(t *testing.T) {
+	if runtime.GOOS != "windows" {
+		t.Skip("cmd.exe is only available on Windows")
+	}
+
+	for _, value := range values() {
+		if strings.ContainsAny(value, "\r\n!") || !utf8.ValidString(value) {
+			continue
+		}
+
+		set, err := CmdSet("QT_VALUE", value)
+		if err != nil {
+			t.Errorf("CmdSet(%q) failed: %v", value, err)
+			continue
+		}
+
+		file := filepath.Join(t.TempDir(), "quote.cmd")
+		script := "@echo off\r\nset QT_VALUE=\r\n" + set + "\r\nset QT_VALUE\r\n"
+		if err := os.WriteFile(file, []byte(script), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		output, _ := exec.Command("cmd.exe", "/d", "/c", file).Output()
+		got := strings.TrimSuffix(strings.TrimPrefix(string(output), "QT_VALUE="), "\r\n")
+		want := strings.ReplaceAll(value, "\x00", "")
+		if want == "" {
+			// Setting an empty value removes the variable
+			continue
+		}
+		if got != want {
+			t.Errorf("CmdSet(%q) set %q", value, got)
+		}
+	}
+}
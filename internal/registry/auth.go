@@ -0,0 +1,350 @@
+package registry
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+
+	"github.com/griffin/go-shellify/internal/config"
+	"github.com/griffin/go-shellify/internal/logger"
+	"github.com/griffin/go-shellify/internal/quote"
+)
+
+// Auth describes how to authenticate against a private registry.
+// Only references to credentials are stored, never the secrets themselves.
+type Auth struct {
+	TokenEnv         string `json:"token_env,omitempty"`         // Environment variable holding an access token
+	TokenFile        string `json:"token_file,omitempty"`        // File containing an access token
+	Username         string `json:"username,omitempty"`          // Username sent along with a token
+	Netrc            bool   `json:"netrc,omitempty"`             // Look up credentials in ~/.netrc
+	CredentialHelper string `json:"credential_helper,omitempty"` // git credential helper, e.g. "osxkeychain" or "store"
+	SSHKey           string `json:"ssh_key,omitempty"`           // Private key used for SSH remotes
+}
+
+// defaultTokenUsername is sent with tokens when no username is configured.
+// GitHub, GitLab and Bitbucket accept any non-empty username for access tokens.
+const defaultTokenUsername = "git"
+
+// credentials holds resolved HTTP credentials. It must never be persisted or logged.
+type credentials struct {
+	username string
+	password string
+}
+
+// IsZero reports whether no authentication method is configured
+func (a *Auth) IsZero() bool {
+	return a == nil || *a == Auth{}
+}
+
+// Validate checks that the auth settings are consistent
+func (a *Auth) Validate() error {
+	if a.IsZero() {
+		return nil
+	}
+
+	methods := 0
+	for _, set := range []bool{a.TokenEnv != "", a.TokenFile != "", a.Netrc, a.CredentialHelper != ""} {
+		if set {
+			methods++
+		}
+	}
+	if methods > 1 {
+		return fmt.Errorf("only one of token env, token file, netrc or credential helper can be used")
+	}
+
+	if a.TokenFile != "" {
+		if _, err := os.Stat(config.ExpandHome(a.TokenFile)); err != nil {
+			return fmt.Errorf("token file not accessible: %w", err)
+		}
+	}
+
+	if a.SSHKey != "" {
+		if _, err := os.Stat(config.ExpandHome(a.SSHKey)); err != nil {
+			return fmt.Errorf("SSH key not accessible: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// Describe returns a human readable summary of the auth method without secrets
+func (a *Auth) Describe() string {
+	if a.IsZero() {
+		return "none"
+	}
+
+	var parts []string
+	switch {
+	case a.TokenEnv != "":
+		parts = append(parts, "token from $"+a.TokenEnv)
+	case a.TokenFile != "":
+		parts = append(parts, "token from "+a.TokenFile)
+	case a.Netrc:
+		parts = append(parts, "netrc")
+	case a.CredentialHelper != "":
+		parts = append(parts, "credential helper "+a.CredentialHelper)
+	}
+	if a.SSHKey != "" {
+		parts = append(parts, "SSH key "+a.SSHKey)
+	}
+
+	return strings.Join(parts, ", ")
+}
+
+// resolveCredentials returns the HTTP credentials for a remote, or nil if
+// the auth settings don't provide any
+func (a *Auth) resolveCredentials(rawURL string) (*credentials, error) {
+	if a.IsZero() || isSSHURL(rawURL) {
+		return nil, nil
+	}
+
+	username := a.Username
+	if username == "" {
+		username = defaultTokenUsername
+	}
+
+	switch {
+	case a.TokenEnv != "":
+		token := strings.TrimSpace(os.Getenv(a.TokenEnv))
+		if token == "" {
+			return nil, fmt.Errorf("environment variable %s is not set", a.TokenEnv)
+		}
+		return &credentials{username: username, password: token}, nil
+
+	case a.TokenFile != "":
+		path := config.ExpandHome(a.TokenFile)
+		if info, err := os.Stat(path); err == nil && info.Mode().Perm()&0077 != 0 {
+			logger.Warn("Token file %s is readable by other users", a.TokenFile)
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read token file: %w", err)
+		}
+		token := strings.TrimSpace(string(data))
+		if token == "" {
+			return nil, fmt.Errorf("token file %s is empty", a.TokenFile)
+		}
+		return &credentials{username: username, password: token}, nil
+
+	case a.Netrc:
+		parsedURL, err := url.Parse(rawURL)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse URL: %w", err)
+		}
+		creds, err := lookupNetrc(netrcPath(), parsedURL.Hostname())
+		if err != nil {
+			return nil, err
+		}
+		if creds == nil {
+			return nil, fmt.Errorf("no netrc entry for %s", parsedURL.Hostname())
+		}
+		return creds, nil
+
+	case a.CredentialHelper != "":
+		return a.fillFromCredentialHelper(rawURL)
+	}
+
+	return nil, nil
+}
+
+// applyToRequest adds the resolved credentials to an HTTP request
+func (a *Auth) applyToRequest(req *http.Request) error {
+	creds, err := a.resolveCredentials(req.URL.String())
+	if err != nil {
+		return fmt.Errorf("failed to resolve credentials: %w", err)
+	}
+	if creds != nil {
+		req.SetBasicAuth(creds.username, creds.password)
+	}
+	return nil
+}
+
+// gitEnv returns the environment entries that apply the auth settings to a
+// git subprocess. Secrets are passed through the environment so they never
+// show up in the process arguments or in git's own output.
+func (a *Auth) gitEnv(rawURL string) ([]string, error) {
+	if a.IsZero() {
+		return nil, nil
+	}
+
+	var env []string
+	var gitConfig [][2]string
+
+	if a.SSHKey != "" && isSSHURL(rawURL) {
+		// git runs GIT_SSH_COMMAND through sh
+		env = append(env, fmt.Sprintf("GIT_SSH_COMMAND=ssh -i %s -o IdentitiesOnly=yes", quote.POSIX(config.ExpandHome(a.SSHKey))))
+	}
+
+	if a.CredentialHelper != "" {
+		// Reset inherited helpers so only the configured one is consulted
+		gitConfig = append(gitConfig, [2]string{"credential.helper", ""})
+		gitConfig = append(gitConfig, [2]string{"credential.helper", a.CredentialHelper})
+	} else {
+		creds, err := a.resolveCredentials(rawURL)
+		if err != nil {
+			return nil, err
+		}
+		if creds != nil {
+			header := "Authorization: Basic " + base64.StdEncoding.EncodeToString([]byte(creds.username+":"+creds.password))
+			gitConfig = append(gitConfig, [2]string{"http.extraHeader", header})
+		}
+	}
+
+	return append(env, gitConfigEnv(gitConfig)...), nil
+}
+
+// fillFromCredentialHelper asks git's credential machinery for credentials
+func (a *Auth) fillFromCredentialHelper(rawURL string) (*credentials, error) {
+	parsedURL, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse URL: %w", err)
+	}
+
+	var input bytes.Buffer
+	fmt.Fprintf(&input, "protocol=%s\n", parsedURL.Scheme)
+	fmt.Fprintf(&input, "host=%s\n", parsedURL.Host)
+	if a.Username != "" {
+		fmt.Fprintf(&input, "username=%s\n", a.Username)
+	}
+	input.WriteString("\n")
+
+	cmd := exec.Command("git", "-c", "credential.helper=", "-c", "credential.helper="+a.CredentialHelper, "credential", "fill")
+	cmd.Stdin = &input
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("git credential helper %s returned no credentials: %w", a.CredentialHelper, err)
+	}
+
+	creds := &credentials{}
+	scanner := bufio.NewScanner(bytes.NewReader(output))
+	for scanner.Scan() {
+		key, value, ok := strings.Cut(scanner.Text(), "=")
+		if !ok {
+			continue
+		}
+		switch key {
+		case "username":
+			creds.username = value
+		case "password":
+			creds.password = value
+		}
+	}
+
+	if creds.password == "" {
+		return nil, fmt.Errorf("git credential helper %s returned no password", a.CredentialHelper)
+	}
+
+	return creds, nil
+}
+
+// gitConfigEnv encodes config entries as GIT_CONFIG_COUNT/KEY/VALUE variables,
+// appending to any entries already present in the environment
+func gitConfigEnv(config [][2]string) []string {
+	if len(config) == 0 {
+		return nil
+	}
+
+	offset, _ := strconv.Atoi(os.Getenv("GIT_CONFIG_COUNT"))
+
+	var env []string
+	for i, entry := range config {
+		env = append(env, fmt.Sprintf("GIT_CONFIG_KEY_%d=%s", offset+i, entry[0]))
+		env = append(env, fmt.Sprintf("GIT_CONFIG_VALUE_%d=%s", offset+i, entry[1]))
+	}
+	env = append(env, fmt.Sprintf("GIT_CONFIG_COUNT=%d", offset+len(config)))
+
+	return env
+}
+
+// netrcPath returns the netrc file location, honoring $NETRC
+func netrcPath() string {
+	if path := os.Getenv("NETRC"); path != "" {
+		return path
+	}
+
+	homeDir, _ := os.UserHomeDir()
+	if runtime.GOOS == "windows" {
+		return filepath.Join(homeDir, "_netrc")
+	}
+	return filepath.Join(homeDir, ".netrc")
+}
+
+// lookupNetrc finds the credentials for a host in a netrc file
+func lookupNetrc(path, host string) (*credentials, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read netrc file: %w", err)
+	}
+
+	var current, fallback *credentials
+	var match *credentials
+	tokens := strings.Fields(string(data))
+
+	for i := 0; i < len(tokens); i++ {
+		switch tokens[i] {
+		case "machine":
+			if match != nil {
+				return match, nil
+			}
+			current = &credentials{}
+			if i+1 < len(tokens) {
+				i++
+				if tokens[i] == host {
+					match = current
+				}
+			}
+		case "default":
+			if match != nil {
+				return match, nil
+			}
+			current = &credentials{}
+			fallback = current
+		case "login":
+			if i+1 < len(tokens) && current != nil {
+				i++
+				current.username = tokens[i]
+			}
+		case "password":
+			if i+1 < len(tokens) && current != nil {
+				i++
+				current.password = tokens[i]
+			}
+		case "account":
+			i++
+		case "macdef":
+			// Macro definitions run until the end of the file for our purposes
+			i = len(tokens)
+		}
+	}
+
+	if match != nil {
+		return match, nil
+	}
+	return fallback, nil
+}
+
+// isSSHURL reports whether a URL is an SSH remote
+func isSSHURL(rawURL string) bool {
+	return strings.HasPrefix(rawURL, "git@") || strings.HasPrefix(rawURL, "ssh://")
+}
+
+// RedactURL removes any userinfo from a URL so it can be logged safely
+func RedactURL(rawURL string) string {
+	parsedURL, err := url.Parse(rawURL)
+	if err != nil || parsedURL.User == nil {
+		return rawURL
+	}
+	parsedURL.User = nil
+	return parsedURL.String()
+}
@@ -0,0 +1,242 @@
+package registry
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// maxChangeSets is the number of sync change sets kept per registry
+const maxChangeSets = 20
+
+// ChangeSet describes how a registry's modules changed during one sync
+type ChangeSet struct {
+	Registry string         `json:"registry"`
+	SyncedAt time.Time      `json:"synced_at"`
+	Added    []ModuleChange `json:"added,omitempty"`
+	Removed  []ModuleChange `json:"removed,omitempty"`
+	Updated  []ModuleChange `json:"updated,omitempty"`
+}
+
+// ModuleChange describes a module that was added, removed or updated
+type ModuleChange struct {
+	Name       string       `json:"name"`
+	OldVersion string       `json:"old_version,omitempty"`
+	NewVersion string       `json:"new_version,omitempty"`
+	Aliases    []ItemChange `json:"aliases,omitempty"`
+	Functions  []ItemChange `json:"functions,omitempty"`
+}
+
+// ItemChange describes a changed alias command or function body.
+// Old is empty for added items and New is empty for removed ones.
+type ItemChange struct {
+	Name string `json:"name"`
+	Old  string `json:"old,omitempty"`
+	New  string `json:"new,omitempty"`
+}
+
+// IsEmpty reports whether the sync changed nothing
+func (cs *ChangeSet) IsEmpty() bool {
+	return len(cs.Added) == 0 && len(cs.Removed) == 0 && len(cs.Updated) == 0
+}
+
+// Bumped reports whether the module version changed
+func (mc ModuleChange) Bumped() bool {
+	return mc.OldVersion != mc.NewVersion
+}
+
+// LoadModule returns the full definition of an index entry, overlaying the
+// module.json in its directory on the entry when there is one
+func LoadModule(repoPath string, entry Module) (Module, error) {
+	module := entry
+	if entry.Path == "" {
+		return module, nil
+	}
+
+	data, err := os.ReadFile(filepath.Join(repoPath, entry.Path, "module.json"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return module, nil
+		}
+		return module, fmt.Errorf("failed to read module.json for %s: %w", entry.Name, err)
+	}
+
+	if err := json.Unmarshal(data, &module); err != nil {
+		return module, fmt.Errorf("failed to decode module.json for %s: %w", entry.Name, err)
+	}
+	// The checksum covers module.json itself, so only the index can carry it
+	module.Checksum = entry.Checksum
+
+	return module, nil
+}
+
+// LoadModules reads the index of a cloned registry and the full definition
+// of every module in it
+func LoadModules(repoPath string) (map[string]Module, error) {
+	data, err := os.ReadFile(filepath.Join(repoPath, "index.json"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read registry index: %w", err)
+	}
+
+	var index RegistryIndex
+	if err := json.Unmarshal(data, &index); err != nil {
+		return nil, fmt.Errorf("failed to decode registry index: %w", err)
+	}
+
+	modules := make(map[string]Module, len(index.Modules))
+	for key, entry := range index.Modules {
+		module, err := LoadModule(repoPath, entry)
+		if err != nil {
+			return nil, err
+		}
+		modules[key] = module
+	}
+
+	return modules, nil
+}
+
+// DiffModules compares the modules of a registry before and after a sync
+func DiffModules(registryName string, before, after map[string]Module) *ChangeSet {
+	changes := &ChangeSet{
+		Registry: registryName,
+		SyncedAt: time.Now(),
+	}
+
+	for _, name := range sortedModuleNames(after) {
+		newModule := after[name]
+		oldModule, existed := before[name]
+		if !existed {
+			changes.Added = append(changes.Added, ModuleChange{Name: name, NewVersion: newModule.Version})
+			continue
+		}
+
+		change := ModuleChange{
+			Name:       name,
+			OldVersion: oldModule.Version,
+			NewVersion: newModule.Version,
+			Aliases:    diffItems(aliasCommands(oldModule.Aliases), aliasCommands(newModule.Aliases)),
+			Functions:  diffItems(functionBodies(oldModule.Functions), functionBodies(newModule.Functions)),
+		}
+		if change.Bumped() || len(change.Aliases) > 0 || len(change.Functions) > 0 {
+			changes.Updated = append(changes.Updated, change)
+		}
+	}
+
+	for _, name := range sortedModuleNames(before) {
+		if _, exists := after[name]; !exists {
+			changes.Removed = append(changes.Removed, ModuleChange{Name: name, OldVersion: before[name].Version})
+		}
+	}
+
+	return changes
+}
+
+// diffItems compares named items, returning the added, removed and changed ones
+func diffItems(before, after map[string]string) []ItemChange {
+	var changes []ItemChange
+
+	names := make(map[string]bool)
+	for name := range before {
+		names[name] = true
+	}
+	for name := range after {
+		names[name] = true
+	}
+
+	sorted := make([]string, 0, len(names))
+	for name := range names {
+		sorted = append(sorted, name)
+	}
+	sort.Strings(sorted)
+
+	for _, name := range sorted {
+		if before[name] != after[name] {
+			changes = append(changes, ItemChange{Name: name, Old: before[name], New: after[name]})
+		}
+	}
+
+	return changes
+}
+
+// aliasCommands maps alias names to their commands
+func aliasCommands(aliases []Alias) map[string]string {
+	commands := make(map[string]string, len(aliases))
+	for _, alias := range aliases {
+		commands[alias.Name] = alias.Command
+	}
+	return commands
+}
+
+// functionBodies maps function names to their bodies
+func functionBodies(functions []Function) map[string]string {
+	bodies := make(map[string]string, len(functions))
+	for _, function := range functions {
+		bodies[function.Name] = strings.Join(function.Commands, "\n")
+	}
+	return bodies
+}
+
+// sortedModuleNames returns the module keys in sorted order
+func sortedModuleNames(modules map[string]Module) []string {
+	names := make([]string, 0, len(modules))
+	for name := range modules {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// RegistryChanges returns the recorded change sets for a registry, oldest first
+func (c *Client) RegistryChanges(name string) ([]ChangeSet, error) {
+	data, err := os.ReadFile(c.changesPath(name))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read registry changes: %w", err)
+	}
+
+	var changeSets []ChangeSet
+	if err := json.Unmarshal(data, &changeSets); err != nil {
+		return nil, fmt.Errorf("failed to parse registry changes: %w", err)
+	}
+
+	return changeSets, nil
+}
+
+// recordChanges appends a change set to the registry's change history
+func (c *Client) recordChanges(changes *ChangeSet) error {
+	changeSets, err := c.RegistryChanges(changes.Registry)
+	if err != nil {
+		return err
+	}
+
+	changeSets = append(changeSets, *changes)
+	if len(changeSets) > maxChangeSets {
+		changeSets = changeSets[len(changeSets)-maxChangeSets:]
+	}
+
+	if err := os.MkdirAll(filepath.Dir(c.changesPath(changes.Registry)), 0755); err != nil {
+		return fmt.Errorf("failed to create changes directory: %w", err)
+	}
+
+	data, err := json.MarshalIndent(changeSets, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal registry changes: %w", err)
+	}
+
+	if err := os.WriteFile(c.changesPath(changes.Registry), data, 0644); err != nil {
+		return fmt.Errorf("failed to write registry changes: %w", err)
+	}
+
+	return nil
+}
+
+// changesPath returns the file a registry's change history is stored in
+func (c *Client) changesPath(name string) string {
+	return filepath.Join(c.configDir, "changes", name+".json")
+}
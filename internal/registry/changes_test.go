@@ -0,0 +1,202 @@
+package registry
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+func TestDiffModules(t *testing.T) {
+	before := map[string]Module{
+		"git-helpers": {
+			Name:    "git-helpers",
+			Version: "1.0.0",
+			Aliases: []Alias{{Name: "gs", Command: "git status"}, {Name: "gd", Command: "git diff"}},
+			Functions: []Function{
+				{Name: "gclean", Commands: []string{"git clean -fd"}},
+			},
+		},
+		"docker-tools": {Name: "docker-tools", Version: "2.0.0"},
+		"unchanged":    {Name: "unchanged", Version: "1.0.0"},
+	}
+	after := map[string]Module{
+		"git-helpers": {
+			Name:    "git-helpers",
+			Version: "1.1.0",
+			Aliases: []Alias{{Name: "gs", Command: "git status -sb"}, {Name: "gl", Command: "git log"}},
+			Functions: []Function{
+				{Name: "gclean", Commands: []string{"git clean -fd"}},
+			},
+		},
+		"unchanged":  {Name: "unchanged", Version: "1.0.0"},
+		"kube-tools": {Name: "kube-tools", Version: "0.1.0"},
+	}
+
+	changes := DiffModules("test", before, after)
+
+	if len(changes.Added) != 1 || changes.Added[0].Name != "kube-tools" {
+		t.Errorf("Added = %+v, expected kube-tools", changes.Added)
+	}
+	if len(changes.Removed) != 1 || changes.Removed[0].Name != "docker-tools" {
+		t.Errorf("Removed = %+v, expected docker-tools", changes.Removed)
+	}
+	if len(changes.Updated) != 1 {
+		t.Fatalf("Updated = %+v, expected git-helpers only", changes.Updated)
+	}
+
+	updated := changes.Updated[0]
+	if !updated.Bumped() || updated.OldVersion != "1.0.0" || updated.NewVersion != "1.1.0" {
+		t.Errorf("expected a version bump from 1.0.0 to 1.1.0, got %s -> %s", updated.OldVersion, updated.NewVersion)
+	}
+
+	expectedAliases := []ItemChange{
+		{Name: "gd", Old: "git diff"},
+		{Name: "gl", New: "git log"},
+		{Name: "gs", Old: "git status", New: "git status -sb"},
+	}
+	if len(updated.Aliases) != len(expectedAliases) {
+		t.Fatalf("Aliases = %+v, expected %+v", updated.Aliases, expectedAliases)
+	}
+	for i, expected := range expectedAliases {
+		if updated.Aliases[i] != expected {
+			t.Errorf("Aliases[%d] = %+v, expected %+v", i, updated.Aliases[i], expected)
+		}
+	}
+	if len(updated.Functions) != 0 {
+		t.Errorf("Functions = %+v, expected no changes", updated.Functions)
+	}
+}
+
+func TestDiffModulesDetectsUnversionedChanges(t *testing.T) {
+	before := map[string]Module{
+		"prompt": {Name: "prompt", Version: "1.0.0", Functions: []Function{{Name: "ps1", Commands: []string{"echo old"}}}},
+	}
+	after := map[string]Module{
+		"prompt": {Name: "prompt", Version: "1.0.0", Functions: []Function{{Name: "ps1", Commands: []string{"echo new"}}}},
+	}
+
+	changes := DiffModules("test", before, after)
+	if len(changes.Updated) != 1 || changes.Updated[0].Bumped() {
+		t.Fatalf("Updated = %+v, expected a content change without a version bump", changes.Updated)
+	}
+	if len(changes.Updated[0].Functions) != 1 || changes.Updated[0].Functions[0].New != "echo new" {
+		t.Errorf("Functions = %+v, expected the ps1 body change", changes.Updated[0].Functions)
+	}
+}
+
+func TestLoadModule(t *testing.T) {
+	repoPath := t.TempDir()
+	moduleDir := filepath.Join(repoPath, "modules", "git-helpers")
+	if err := os.MkdirAll(moduleDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	moduleJSON := `{"name": "git-helpers", "type": "aliases", "aliases": [{"name": "gs", "command": "git status"}]}`
+	if err := os.WriteFile(filepath.Join(moduleDir, "module.json"), []byte(moduleJSON), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	entry := Module{Name: "git-helpers", Version: "1.0.0", Path: "modules/git-helpers"}
+	module, err := LoadModule(repoPath, entry)
+	if err != nil {
+		t.Fatalf("LoadModule() failed: %v", err)
+	}
+	if module.Version != "1.0.0" {
+		t.Errorf("Version = %q, expected the index entry value to be kept", module.Version)
+	}
+	if len(module.Aliases) != 1 || module.Aliases[0].Command != "git status" {
+		t.Errorf("Aliases = %+v, expected the module.json aliases", module.Aliases)
+	}
+}
+
+func TestRecordChangesKeepsRecentHistory(t *testing.T) {
+	client := &Client{configDir: t.TempDir()}
+
+	for i := 0; i < maxChangeSets+5; i++ {
+		changes := &ChangeSet{Registry: "test", Added: []ModuleChange{{Name: "module", NewVersion: "1.0.0"}}}
+		if err := client.recordChanges(changes); err != nil {
+			t.Fatalf("recordChanges() failed: %v", err)
+		}
+	}
+
+	changeSets, err := client.RegistryChanges("test")
+	if err != nil {
+		t.Fatalf("RegistryChanges() failed: %v", err)
+	}
+	if len(changeSets) != maxChangeSets {
+		t.Errorf("RegistryChanges() returned %d change sets, expected %d", len(changeSets), maxChangeSets)
+	}
+
+	if changeSets, err := client.RegistryChanges("unknown"); err != nil || changeSets != nil {
+		t.Errorf("RegistryChanges() for an unknown registry = %v, %v, expected no changes", changeSets, err)
+	}
+}
+
+func TestSyncRegistryRecordsChanges(t *testing.T) {
+	sourceDir := createSourceRepository(t)
+	if err := createValidRegistry(sourceDir); err != nil {
+		t.Fatal(err)
+	}
+	commitAll(t, sourceDir, "add registry")
+
+	configDir := t.TempDir()
+	client := &Client{configDir: configDir, gitClient: NewGitClient(filepath.Join(configDir, "cache"))}
+	ctx := context.Background()
+
+	if err := client.AddRegistry(ctx, Registry{URL: "file://" + sourceDir, Name: "test"}); err != nil {
+		t.Fatalf("AddRegistry() failed: %v", err)
+	}
+
+	// Publish a new module version with an extra alias
+	moduleConfig := map[string]interface{}{
+		"name":        "git-helpers",
+		"description": "Git helper functions",
+		"type":        "aliases",
+		"aliases":     []map[string]string{{"name": "gs", "command": "git status"}},
+	}
+	if err := writeJSON(filepath.Join(sourceDir, "modules", "git-helpers", "module.json"), moduleConfig); err != nil {
+		t.Fatal(err)
+	}
+	commitAll(t, sourceDir, "add alias")
+
+	if err := client.SyncRegistry(ctx, "test"); err != nil {
+		t.Fatalf("SyncRegistry() failed: %v", err)
+	}
+
+	changeSets, err := client.RegistryChanges("test")
+	if err != nil {
+		t.Fatalf("RegistryChanges() failed: %v", err)
+	}
+	if len(changeSets) != 1 || len(changeSets[0].Updated) != 1 {
+		t.Fatalf("RegistryChanges() = %+v, expected one update", changeSets)
+	}
+	if aliases := changeSets[0].Updated[0].Aliases; len(aliases) != 1 || aliases[0].New != "git status" {
+		t.Errorf("Aliases = %+v, expected the added gs alias", aliases)
+	}
+
+	// Syncing again without upstream changes records nothing
+	if err := client.SyncRegistry(ctx, "test"); err != nil {
+		t.Fatalf("second SyncRegistry() failed: %v", err)
+	}
+	if changeSets, _ := client.RegistryChanges("test"); len(changeSets) != 1 {
+		t.Errorf("expected no change set for an unchanged sync, got %d", len(changeSets))
+	}
+}
+
+// commitAll commits every file in a test repository
+func commitAll(t *testing.T, dir, message string) {
+	t.Helper()
+
+	commands := [][]string{
+		{"add", "-A"},
+		{"-c", "user.name=test", "-c", "user.email=test@example.com", "commit", "--quiet", "-m", message},
+	}
+	for _, args := range commands {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		if output, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v failed: %v, output: %s", args, err, output)
+		}
+	}
+}
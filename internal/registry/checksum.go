@@ -0,0 +1,230 @@
+package registry
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// ErrChecksumMismatch is returned when a module directory doesn't match the
+// checksum published in the registry index
+var ErrChecksumMismatch = errors.New("module checksum mismatch")
+
+// ModuleChecksum returns the sha256 over the contents of a module directory.
+// It covers the relative path and content hash of every file, so renamed,
+// added and removed files change it as well as edited ones.
+func ModuleChecksum(dir string) (string, error) {
+	hashes := make(map[string]string)
+
+	err := filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() {
+			return nil
+		}
+
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+
+		hash, err := hashEntry(path, entry)
+		if err != nil {
+			return err
+		}
+		hashes[filepath.ToSlash(rel)] = hash
+		return nil
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to hash module directory %s: %w", dir, err)
+	}
+
+	paths := make([]string, 0, len(hashes))
+	for path := range hashes {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	hasher := sha256.New()
+	for _, path := range paths {
+		fmt.Fprintf(hasher, "%s %s\n", hashes[path], path)
+	}
+	return hex.EncodeToString(hasher.Sum(nil)), nil
+}
+
+// VerifyModuleChecksum checks a module directory against the checksum from
+// the registry index. Modules without a published checksum always pass.
+func VerifyModuleChecksum(repoPath string, module Module) error {
+	if module.Checksum == "" {
+		return nil
+	}
+
+	actual, err := ModuleChecksum(filepath.Join(repoPath, module.Path))
+	if err != nil {
+		return err
+	}
+	if !strings.EqualFold(actual, module.Checksum) {
+		return fmt.Errorf("%w: %s is %s, index lists %s", ErrChecksumMismatch, module.Name, actual, module.Checksum)
+	}
+
+	return nil
+}
+
+// UpdateIndexChecksums recomputes the checksum of every module in a
+// registry's index.json. Only the sha256 fields change: keys keep their
+// order, unknown fields are kept and the file keeps its indentation.
+func UpdateIndexChecksums(repoPath string) (int, error) {
+	indexFile := filepath.Join(repoPath, "index.json")
+
+	data, err := os.ReadFile(indexFile)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read registry index: %w", err)
+	}
+
+	var index orderedObject
+	if err := json.Unmarshal(data, &index); err != nil {
+		return 0, fmt.Errorf("failed to decode registry index: %w", err)
+	}
+
+	var modules orderedObject
+	if raw, ok := index.values["modules"]; ok {
+		if err := json.Unmarshal(raw, &modules); err != nil {
+			return 0, fmt.Errorf("failed to decode registry index modules: %w", err)
+		}
+	}
+
+	updated := 0
+	for _, name := range modules.keys {
+		var entry orderedObject
+		if err := json.Unmarshal(modules.values[name], &entry); err != nil {
+			continue
+		}
+		var path, current string
+		json.Unmarshal(entry.values["path"], &path)
+		json.Unmarshal(entry.values["sha256"], &current)
+		if path == "" {
+			continue
+		}
+
+		checksum, err := ModuleChecksum(filepath.Join(repoPath, path))
+		if err != nil {
+			return 0, fmt.Errorf("module %s: %w", name, err)
+		}
+		if current == checksum {
+			continue
+		}
+		entry.set("sha256", checksum)
+		if modules.values[name], err = entry.MarshalJSON(); err != nil {
+			return 0, fmt.Errorf("failed to marshal module %s: %w", name, err)
+		}
+		updated++
+	}
+	if updated == 0 {
+		return 0, nil
+	}
+
+	if index.values["modules"], err = modules.MarshalJSON(); err != nil {
+		return 0, fmt.Errorf("failed to marshal registry index: %w", err)
+	}
+	compact, err := index.MarshalJSON()
+	if err != nil {
+		return 0, fmt.Errorf("failed to marshal registry index: %w", err)
+	}
+
+	var out bytes.Buffer
+	if err := json.Indent(&out, compact, "", jsonIndent(data)); err != nil {
+		return 0, fmt.Errorf("failed to format registry index: %w", err)
+	}
+	out.WriteByte('\n')
+	if err := os.WriteFile(indexFile, out.Bytes(), 0644); err != nil {
+		return 0, fmt.Errorf("failed to write registry index: %w", err)
+	}
+
+	return updated, nil
+}
+
+// jsonIndent returns the indentation of the first nested line of a JSON
+// document, or two spaces
+func jsonIndent(data []byte) string {
+	lines := strings.SplitN(string(data), "\n", 3)
+	if len(lines) > 1 {
+		line := lines[1]
+		if indent := line[:len(line)-len(strings.TrimLeft(line, " \t"))]; indent != "" {
+			return indent
+		}
+	}
+	return "  "
+}
+
+// orderedObject is a JSON object whose keys keep their order when it is
+// written back. Values are kept as they were read, so write it with its
+// MarshalJSON rather than json.Marshal, which would escape <, > and &.
+type orderedObject struct {
+	keys   []string
+	values map[string]json.RawMessage
+}
+
+// set replaces the value of a key, adding it at the end when missing
+func (o *orderedObject) set(key string, value interface{}) {
+	raw, _ := json.Marshal(value)
+	if _, ok := o.values[key]; !ok {
+		o.keys = append(o.keys, key)
+	}
+	o.values[key] = raw
+}
+
+func (o *orderedObject) UnmarshalJSON(data []byte) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	if token, err := decoder.Token(); err != nil || token != json.Delim('{') {
+		return fmt.Errorf("expected a JSON object")
+	}
+
+	o.keys, o.values = nil, make(map[string]json.RawMessage)
+	for decoder.More() {
+		token, err := decoder.Token()
+		if err != nil {
+			return err
+		}
+		key := token.(string)
+		var value json.RawMessage
+		if err := decoder.Decode(&value); err != nil {
+			return err
+		}
+		if _, ok := o.values[key]; !ok {
+			o.keys = append(o.keys, key)
+		}
+		o.values[key] = value
+	}
+	_, err := decoder.Token()
+	return err
+}
+
+func (o *orderedObject) MarshalJSON() ([]byte, error) {
+	var b bytes.Buffer
+	b.WriteByte('{')
+	for i, key := range o.keys {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		// Unlike json.Marshal, the encoder can leave <, > and & as they are
+		encoder := json.NewEncoder(&b)
+		encoder.SetEscapeHTML(false)
+		if err := encoder.Encode(key); err != nil {
+			return nil, err
+		}
+		b.Truncate(b.Len() - 1) // Encode ends with a newline
+		b.WriteByte(':')
+		b.Write(o.values[key])
+	}
+	b.WriteByte('}')
+	return b.Bytes(), nil
+}
@@ -0,0 +1,126 @@
+package registry
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestModuleChecksum(t *testing.T) {
+	dir := t.TempDir()
+	if err := createValidRegistry(dir); err != nil {
+		t.Fatal(err)
+	}
+	moduleDir := filepath.Join(dir, "modules", "git-helpers")
+
+	original, err := ModuleChecksum(moduleDir)
+	if err != nil {
+		t.Fatalf("ModuleChecksum() failed: %v", err)
+	}
+	again, _ := ModuleChecksum(moduleDir)
+	if original != again {
+		t.Errorf("ModuleChecksum() is not stable: %s != %s", original, again)
+	}
+
+	if err := os.WriteFile(filepath.Join(moduleDir, "extra.sh"), []byte("echo"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	added, _ := ModuleChecksum(moduleDir)
+	if added == original {
+		t.Error("ModuleChecksum() should change when a file is added")
+	}
+
+	if err := os.Rename(filepath.Join(moduleDir, "extra.sh"), filepath.Join(moduleDir, "other.sh")); err != nil {
+		t.Fatal(err)
+	}
+	renamed, _ := ModuleChecksum(moduleDir)
+	if renamed == added {
+		t.Error("ModuleChecksum() should change when a file is renamed")
+	}
+}
+
+func TestStructureValidatorVerifiesChecksums(t *testing.T) {
+	dir := t.TempDir()
+	if err := createValidRegistry(dir); err != nil {
+		t.Fatal(err)
+	}
+
+	updated, err := UpdateIndexChecksums(dir)
+	if err != nil || updated != 1 {
+		t.Fatalf("UpdateIndexChecksums() = %d, %v, expected one checksum", updated, err)
+	}
+	if err := NewStructureValidator(dir).ValidateStructure(); err != nil {
+		t.Fatalf("ValidateStructure() with matching checksums failed: %v", err)
+	}
+
+	index, err := NewStructureValidator(dir).validateIndexJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if index.Modules["git-helpers"].Checksum == "" {
+		t.Error("index entry should carry the checksum")
+	}
+
+	writeModuleJSON(t, dir, "rm -rf ~")
+	if err := NewStructureValidator(dir).ValidateStructure(); !errors.Is(err, ErrChecksumMismatch) {
+		t.Errorf("ValidateStructure() error = %v, expected ErrChecksumMismatch", err)
+	}
+}
+
+func TestUpdateIndexChecksumsKeepsLayout(t *testing.T) {
+	dir := t.TempDir()
+	if err := createValidRegistry(dir); err != nil {
+		t.Fatal(err)
+	}
+	checksum, err := ModuleChecksum(filepath.Join(dir, "modules", "git-helpers"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	index := `{
+    "version": "1.0.0",
+    "name": "test-registry",
+    "x-maintainer": {"team": "platform"},
+    "modules": {
+        "git-helpers": {
+            "path": "modules/git-helpers",
+            "name": "git-helpers",
+            "description": "Use && and <pipes>"
+        }
+    }
+}
+`
+	expected := `{
+    "version": "1.0.0",
+    "name": "test-registry",
+    "x-maintainer": {
+        "team": "platform"
+    },
+    "modules": {
+        "git-helpers": {
+            "path": "modules/git-helpers",
+            "name": "git-helpers",
+            "description": "Use && and <pipes>",
+            "sha256": "` + checksum + `"
+        }
+    }
+}
+`
+	indexFile := filepath.Join(dir, "index.json")
+	if err := os.WriteFile(indexFile, []byte(index), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if updated, err := UpdateIndexChecksums(dir); err != nil || updated != 1 {
+		t.Fatalf("UpdateIndexChecksums() = %d, %v, expected one checksum", updated, err)
+	}
+	data, _ := os.ReadFile(indexFile)
+	if string(data) != expected {
+		t.Errorf("index.json after update:\n%s\nexpected:\n%s", data, expected)
+	}
+
+	if updated, err := UpdateIndexChecksums(dir); err != nil || updated != 0 {
+		t.Errorf("UpdateIndexChecksums() again = %d, %v, expected no changes", updated, err)
+	}
+}
@@ -1,6 +1,7 @@
 package registry
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"os/exec"
@@ -9,23 +10,46 @@ import (
 	"strings"
 	"time"
 
+	"github.com/griffin/go-shellify/internal/config"
 	"github.com/griffin/go-shellify/internal/logger"
 )
 
 // GitClient handles git repository operations
 type GitClient struct {
 	cacheDir string
+	network  config.NetworkConfig
+	timeouts config.TimeoutConfig
+	retry    retryPolicy
 }
 
 // NewGitClient creates a new git client
 func NewGitClient(cacheDir string) *GitClient {
 	return &GitClient{
 		cacheDir: cacheDir,
+		retry:    newRetryPolicy(config.RetryConfig{}),
 	}
 }
 
-// CloneRepository clones a git repository to the cache directory
-func (g *GitClient) CloneRepository(url, name string) error {
+// SetNetwork sets the proxy and TLS settings passed to git subprocesses
+func (g *GitClient) SetNetwork(network config.NetworkConfig) {
+	g.network = network
+}
+
+// SetTimeouts sets the per-operation timeouts for clone and fetch
+func (g *GitClient) SetTimeouts(timeouts config.TimeoutConfig) {
+	g.timeouts = timeouts
+}
+
+// SetRetry sets the retry policy for clone and fetch operations
+func (g *GitClient) SetRetry(retry config.RetryConfig) {
+	g.retry = newRetryPolicy(retry)
+}
+
+// CloneRepository clones a git repository to the cache directory,
+// checking out the given branch or tag when ref is not empty.
+// The clone is staged in a temporary directory and only moved into place
+// once complete, so a cancelled or failed clone never looks like a valid one.
+func (g *GitClient) CloneRepository(ctx context.Context, url, name, ref string, auth *Auth) error {
 	// Ensure cache directory exists
 	if err := os.MkdirAll(g.cacheDir, 0755); err != nil {
 		return fmt.Errorf("failed to create cache directory: %w", err)
@@ -36,42 +60,179 @@ func (g *GitClient) CloneRepository(url, name string) error {
 	// Check if repository already exists
 	if _, err := os.Stat(targetDir); err == nil {
 		logger.Debug("Repository already exists, updating: %s", targetDir)
-		return g.updateRepository(targetDir)
+		return g.updateRepository(ctx, targetDir, ref, auth)
 	}
 
-	logger.Info("Cloning repository: %s to %s", url, targetDir)
+	logger.Info("Cloning repository: %s to %s", RedactURL(url), targetDir)
+
+	env, err := g.commandEnv(url, auth)
+	if err != nil {
+		return err
+	}
+
+	// Leftovers from a process that was killed outright are never valid
+	if stale, _ := filepath.Glob(filepath.Join(g.cacheDir, "."+name+".partial-*")); len(stale) > 0 {
+		for _, dir := range stale {
+			os.RemoveAll(dir)
+		}
+	}
 
 	// Perform shallow clone for performance
-	cmd := exec.Command("git", "clone", "--depth", "1", url, targetDir)
-	cmd.Env = os.Environ()
-	
-	output, err := cmd.CombinedOutput()
+	args := []string{"clone", "--depth", "1"}
+	if ref != "" {
+		args = append(args, "--branch", ref)
+	}
+	args = append(args, url)
+
+	err = g.retry.do(ctx, "git clone", func() error {
+		return g.cloneOnce(ctx, args, env, targetDir)
+	})
 	if err != nil {
-		return fmt.Errorf("git clone failed: %w, output: %s", err, string(output))
+		return err
 	}
 
 	logger.Debug("Repository cloned successfully: %s", targetDir)
 	return nil
 }
 
-// updateRepository updates an existing repository
-func (g *GitClient) updateRepository(repoDir string) error {
+// cloneOnce performs a single clone attempt into a fresh staging directory
+// and moves it to targetDir on success
+func (g *GitClient) cloneOnce(ctx context.Context, args, env []string, targetDir string) error {
+	stagingDir, err := os.MkdirTemp(g.cacheDir, "."+filepath.Base(targetDir)+".partial-")
+	if err != nil {
+		return permanent(fmt.Errorf("failed to create staging directory: %w", err))
+	}
+	defer os.RemoveAll(stagingDir)
+
+	ctx, cancel := context.WithTimeout(ctx, g.timeouts.CloneTimeout())
+	defer cancel()
+
+	if output, err := runGit(ctx, "", env, append(args, stagingDir)...); err != nil {
+		return fmt.Errorf("git clone failed: %w, output: %s", classifyGitError(err, output), string(output))
+	}
+
+	if err := os.Rename(stagingDir, targetDir); err != nil {
+		return permanent(fmt.Errorf("failed to move clone into place: %w", err))
+	}
+
+	return nil
+}
+
+// updateRepository updates an existing repository, tracking ref when it is not empty
+func (g *GitClient) updateRepository(ctx context.Context, repoDir, ref string, auth *Auth) error {
 	logger.Debug("Updating repository: %s", repoDir)
 
-	// Change to repository directory and pull latest changes
-	cmd := exec.Command("git", "pull", "--depth", "1")
-	cmd.Dir = repoDir
-	cmd.Env = os.Environ()
+	env, err := g.commandEnv("", nil)
+	if err != nil {
+		return err
+	}
+	if !auth.IsZero() {
+		// Credentials are resolved per remote, so look up where origin points
+		output, err := runGit(ctx, repoDir, os.Environ(), "remote", "get-url", "origin")
+		if err != nil {
+			return fmt.Errorf("failed to determine remote URL: %w", err)
+		}
+
+		if env, err = g.commandEnv(strings.TrimSpace(string(output)), auth); err != nil {
+			return err
+		}
+	}
 
-	output, err := cmd.CombinedOutput()
+	// Shallow clones can't be pulled once upstream moves on, and pinned refs
+	// may be tags, so fetch the tracked ref and reset to it instead. The cache
+	// never carries local changes worth keeping.
+	if ref == "" {
+		ref = "HEAD"
+	}
+	args := []string{"fetch", "--depth", "1", "origin", ref}
+
+	err = g.retry.do(ctx, "git "+args[0], func() error {
+		ctx, cancel := context.WithTimeout(ctx, g.timeouts.FetchTimeout())
+		defer cancel()
+
+		if output, err := runGit(ctx, repoDir, env, args...); err != nil {
+			return fmt.Errorf("git %s failed: %w, output: %s", args[0], classifyGitError(err, output), string(output))
+		}
+		return nil
+	})
 	if err != nil {
-		return fmt.Errorf("git pull failed: %w, output: %s", err, string(output))
+		return err
+	}
+
+	if output, err := runGit(ctx, repoDir, env, "reset", "--hard", "FETCH_HEAD"); err != nil {
+		return fmt.Errorf("git reset failed: %w, output: %s", err, string(output))
 	}
 
 	logger.Debug("Repository updated successfully: %s", repoDir)
 	return nil
 }
 
+// headCommit returns the commit a repository is checked out at
+func (g *GitClient) headCommit(ctx context.Context, repoDir string) (string, error) {
+	output, err := runGit(ctx, repoDir, os.Environ(), "rev-parse", "HEAD")
+	if err != nil {
+		return "", fmt.Errorf("git rev-parse failed: %w, output: %s", err, string(output))
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
+// resetRepository checks a repository out at a commit it already has
+func (g *GitClient) resetRepository(ctx context.Context, repoDir, commit string) error {
+	if output, err := runGit(ctx, repoDir, os.Environ(), "reset", "--hard", commit); err != nil {
+		return fmt.Errorf("git reset failed: %w, output: %s", err, string(output))
+	}
+	return nil
+}
+
+// commandEnv builds the environment for a git command talking to a remote
+func (g *GitClient) commandEnv(url string, auth *Auth) ([]string, error) {
+	env := append(os.Environ(), networkGitEnv(g.network)...)
+
+	authEnv, err := auth.gitEnv(url)
+	if err != nil {
+		return nil, fmt.Errorf("failed to apply registry authentication: %w", err)
+	}
+	if len(authEnv) > 0 {
+		logger.Debug("Using registry authentication: %s", auth.Describe())
+	}
+
+	return nonInteractiveGitEnv(append(env, authEnv...)), nil
+}
+
+// runGit runs a git command, reporting cancellation and timeouts explicitly
+func runGit(ctx context.Context, dir string, env []string, args ...string) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+	cmd.Env = env
+
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		switch ctx.Err() {
+		case context.Canceled:
+			return output, fmt.Errorf("git %s cancelled: %w", args[0], ctx.Err())
+		case context.DeadlineExceeded:
+			return output, fmt.Errorf("git %s timed out: %w", args[0], ctx.Err())
+		}
+		return output, err
+	}
+
+	return output, nil
+}
+
+// nonInteractiveGitEnv makes sure git never waits for input that nobody can
+// provide: credential prompts, SSH passphrases or host key confirmations
+func nonInteractiveGitEnv(env []string) []string {
+	env = append(env, "GIT_TERMINAL_PROMPT=0", "GCM_INTERACTIVE=never")
+
+	if sshCommand := lookupEnv(env, "GIT_SSH_COMMAND"); sshCommand == "" {
+		env = append(env, "GIT_SSH_COMMAND=ssh -o BatchMode=yes")
+	} else if !strings.Contains(sshCommand, "BatchMode") {
+		env = append(env, "GIT_SSH_COMMAND="+sshCommand+" -o BatchMode=yes")
+	}
+
+	return env
+}
+
 // GetRepositoryPath returns the local path for a repository
 func (g *GitClient) GetRepositoryPath(name string) string {
 	return filepath.Join(g.cacheDir, name)
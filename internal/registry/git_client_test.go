@@ -1,9 +1,14 @@
 package registry
 
 import (
+	"context"
 	"os"
+	"os/exec"
 	"path/filepath"
+	"strings"
 	"testing"
+
+	"github.com/griffin/go-shellify/internal/config"
 )
 
 func TestGitClient_GetRepositoryPath(t *testing.T) {
@@ -156,4 +161,104 @@ func TestParseUnixTimestamp(t *testing.T) {
 			}
 		})
 	}
-}
\ No newline at end of file
+}
+// createSourceRepository creates a local git repository with a single commit
+func createSourceRepository(t *testing.T) string {
+	t.Helper()
+
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+
+	sourceDir := t.TempDir()
+	commands := [][]string{
+		{"init", "--quiet", "--initial-branch", "main"},
+		{"-c", "user.name=test", "-c", "user.email=test@example.com", "commit", "--quiet", "--allow-empty", "-m", "initial"},
+	}
+	for _, args := range commands {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = sourceDir
+		if output, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v failed: %v, output: %s", args, err, output)
+		}
+	}
+
+	return sourceDir
+}
+
+func TestGitClient_CloneRepository(t *testing.T) {
+	sourceDir := createSourceRepository(t)
+	client := NewGitClient(t.TempDir())
+
+	if err := client.CloneRepository(context.Background(), "file://"+sourceDir, "test-repo", "", nil); err != nil {
+		t.Fatalf("CloneRepository() failed: %v", err)
+	}
+
+	if !client.IsRepositoryCloned("test-repo") {
+		t.Error("IsRepositoryCloned() should return true after a successful clone")
+	}
+
+	partials, _ := filepath.Glob(filepath.Join(client.cacheDir, ".test-repo.partial-*"))
+	if len(partials) != 0 {
+		t.Errorf("CloneRepository() left staging directories behind: %v", partials)
+	}
+}
+
+func TestGitClient_CloneRepositoryCancelled(t *testing.T) {
+	sourceDir := createSourceRepository(t)
+	client := NewGitClient(t.TempDir())
+
+	// Simulate a partial clone left behind by a killed process
+	stale := filepath.Join(client.cacheDir, ".test-repo.partial-123")
+	if err := os.MkdirAll(filepath.Join(stale, ".git"), 0755); err != nil {
+		t.Fatalf("Failed to create stale partial clone: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err := client.CloneRepository(ctx, "file://"+sourceDir, "test-repo", "", nil)
+	if err == nil || !strings.Contains(err.Error(), "cancelled") {
+		t.Fatalf("CloneRepository() error = %v, want cancellation error", err)
+	}
+
+	if client.IsRepositoryCloned("test-repo") {
+		t.Error("IsRepositoryCloned() should return false after a cancelled clone")
+	}
+
+	partials, _ := filepath.Glob(filepath.Join(client.cacheDir, ".test-repo.partial-*"))
+	if len(partials) != 0 {
+		t.Errorf("CloneRepository() left staging directories behind: %v", partials)
+	}
+}
+
+func TestGitClient_CloneRepositoryTimeout(t *testing.T) {
+	sourceDir := createSourceRepository(t)
+	client := NewGitClient(t.TempDir())
+	client.SetTimeouts(config.TimeoutConfig{Clone: "1ns"})
+	client.SetRetry(config.RetryConfig{Attempts: 1})
+
+	err := client.CloneRepository(context.Background(), "file://"+sourceDir, "test-repo", "", nil)
+	if err == nil || !strings.Contains(err.Error(), "timed out") {
+		t.Fatalf("CloneRepository() error = %v, want timeout error", err)
+	}
+
+	if client.IsRepositoryCloned("test-repo") {
+		t.Error("IsRepositoryCloned() should return false after a timed out clone")
+	}
+}
+
+func TestNonInteractiveGitEnv(t *testing.T) {
+	env := nonInteractiveGitEnv([]string{"PATH=/usr/bin"})
+	if lookupEnv(env, "GIT_TERMINAL_PROMPT") != "0" {
+		t.Error("nonInteractiveGitEnv() should disable terminal prompts")
+	}
+	if lookupEnv(env, "GIT_SSH_COMMAND") != "ssh -o BatchMode=yes" {
+		t.Errorf("nonInteractiveGitEnv() GIT_SSH_COMMAND = %q", lookupEnv(env, "GIT_SSH_COMMAND"))
+	}
+
+	env = nonInteractiveGitEnv([]string{"GIT_SSH_COMMAND=ssh -i key"})
+	if lookupEnv(env, "GIT_SSH_COMMAND") != "ssh -i key -o BatchMode=yes" {
+		t.Errorf("nonInteractiveGitEnv() should extend an existing SSH command, got %q", lookupEnv(env, "GIT_SSH_COMMAND"))
+	}
+}
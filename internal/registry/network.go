@@ -0,0 +1,161 @@
+package registry
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/griffin/go-shellify/internal/config"
+	"github.com/griffin/go-shellify/internal/logger"
+)
+
+// newHTTPTransport builds an HTTP transport that honors the network settings
+func newHTTPTransport(network config.NetworkConfig) (*http.Transport, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = proxyFunc(network)
+
+	if network.CABundle == "" && !network.Insecure {
+		return transport, nil
+	}
+
+	tlsConfig := &tls.Config{}
+
+	if network.CABundle != "" {
+		pool, err := loadCABundle(network.CABundle)
+		if err != nil {
+			return nil, err
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	if network.Insecure {
+		logger.Warn("TLS certificate verification is disabled")
+		tlsConfig.InsecureSkipVerify = true
+	}
+
+	transport.TLSClientConfig = tlsConfig
+	return transport, nil
+}
+
+// loadCABundle returns a certificate pool holding only the certificates of a
+// PEM bundle. Like GIT_SSL_CAINFO for git, the bundle replaces the system
+// certificate authorities, so probes and git trust the same hosts.
+func loadCABundle(path string) (*x509.CertPool, error) {
+	data, err := os.ReadFile(config.ExpandHome(path))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CA bundle: %w", err)
+	}
+
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(data) {
+		return nil, fmt.Errorf("CA bundle %s contains no PEM certificates", path)
+	}
+
+	return pool, nil
+}
+
+// proxyFunc returns an http.Transport proxy function for the network settings
+func proxyFunc(network config.NetworkConfig) func(*http.Request) (*url.URL, error) {
+	return func(req *http.Request) (*url.URL, error) {
+		proxy := network.HTTPSProxy
+		if req.URL.Scheme == "http" {
+			proxy = network.HTTPProxy
+		}
+
+		if proxy == "" || bypassProxy(req.URL.Host, network.NoProxy) {
+			return nil, nil
+		}
+
+		proxyURL, err := url.Parse(proxy)
+		if err != nil {
+			return nil, fmt.Errorf("invalid proxy URL: %w", err)
+		}
+		return proxyURL, nil
+	}
+}
+
+// bypassProxy reports whether a host matches a NO_PROXY list. Entries may be
+// "*", a host name (matching subdomains too), ".domain", host:port, an IP or a CIDR.
+func bypassProxy(hostport, noProxy string) bool {
+	host, port, err := net.SplitHostPort(hostport)
+	if err != nil {
+		host = hostport
+	}
+	host = strings.ToLower(strings.Trim(host, "[]"))
+
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	if ip != nil && ip.IsLoopback() {
+		return true
+	}
+
+	for _, entry := range strings.Split(noProxy, ",") {
+		entry = strings.ToLower(strings.TrimSpace(entry))
+		if entry == "" {
+			continue
+		}
+		if entry == "*" {
+			return true
+		}
+
+		if _, cidr, err := net.ParseCIDR(entry); err == nil {
+			if ip != nil && cidr.Contains(ip) {
+				return true
+			}
+			continue
+		}
+
+		entryHost, entryPort, err := net.SplitHostPort(entry)
+		if err != nil {
+			entryHost, entryPort = entry, ""
+		}
+		if entryPort != "" && entryPort != port {
+			continue
+		}
+
+		entryHost = strings.TrimPrefix(entryHost, "*")
+		if strings.HasPrefix(entryHost, ".") {
+			if strings.HasSuffix(host, entryHost) || host == entryHost[1:] {
+				return true
+			}
+			continue
+		}
+		if host == entryHost || strings.HasSuffix(host, "."+entryHost) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// networkGitEnv returns the environment entries that apply the network
+// settings to git subprocesses
+func networkGitEnv(network config.NetworkConfig) []string {
+	var env []string
+
+	if network.HTTPSProxy != "" {
+		env = append(env, "https_proxy="+network.HTTPSProxy, "HTTPS_PROXY="+network.HTTPSProxy)
+	}
+	if network.HTTPProxy != "" {
+		env = append(env, "http_proxy="+network.HTTPProxy)
+	}
+	if network.NoProxy != "" {
+		env = append(env, "no_proxy="+network.NoProxy, "NO_PROXY="+network.NoProxy)
+	}
+	if network.CABundle != "" {
+		// git replaces its default bundle with this one, as loadCABundle does
+		env = append(env, "GIT_SSL_CAINFO="+config.ExpandHome(network.CABundle))
+	}
+	if network.Insecure {
+		env = append(env, "GIT_SSL_NO_VERIFY=true")
+	}
+
+	return env
+}
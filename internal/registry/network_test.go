@@ -0,0 +1,180 @@
+package registry
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/griffin/go-shellify/internal/config"
+)
+
+func TestBypassProxy(t *testing.T) {
+	tests := []struct {
+		name     string
+		host     string
+		noProxy  string
+		expected bool
+	}{
+		{"empty list", "github.com", "", false},
+		{"wildcard", "github.com", "*", true},
+		{"exact host", "git.corp.example", "git.corp.example", true},
+		{"subdomain of host entry", "git.corp.example", "corp.example", true},
+		{"leading dot domain", "git.corp.example", ".corp.example", true},
+		{"leading dot matches bare domain", "corp.example", ".corp.example", true},
+		{"unrelated suffix", "notcorp.example", "corp.example", false},
+		{"port must match", "git.corp.example:8443", "git.corp.example:443", false},
+		{"matching port", "git.corp.example:443", "git.corp.example:443", true},
+		{"cidr", "10.1.2.3", "10.0.0.0/8", true},
+		{"ip outside cidr", "192.168.1.1", "10.0.0.0/8", false},
+		{"loopback always bypasses", "127.0.0.1:8080", "", true},
+		{"list with spaces", "github.com", "corp.example, github.com", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if result := bypassProxy(tt.host, tt.noProxy); result != tt.expected {
+				t.Errorf("bypassProxy(%s, %s) = %v, expected %v", tt.host, tt.noProxy, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestProxyFunc(t *testing.T) {
+	proxy := proxyFunc(config.NetworkConfig{
+		HTTPSProxy: "http://proxy.corp.example:3128",
+		NoProxy:    ".corp.example",
+	})
+
+	tests := []struct {
+		url      string
+		expected string
+	}{
+		{"https://github.com/user/repo", "http://proxy.corp.example:3128"},
+		{"https://git.corp.example/team/repo", ""},
+		{"http://github.com/user/repo", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			reqURL, _ := url.Parse(tt.url)
+			proxyURL, err := proxy(&http.Request{URL: reqURL})
+			if err != nil {
+				t.Fatalf("proxy() unexpected error: %v", err)
+			}
+
+			got := ""
+			if proxyURL != nil {
+				got = proxyURL.String()
+			}
+			if got != tt.expected {
+				t.Errorf("proxy(%s) = %q, expected %q", tt.url, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestNetworkGitEnv(t *testing.T) {
+	env := networkGitEnv(config.NetworkConfig{
+		HTTPSProxy: "http://proxy:3128",
+		NoProxy:    "corp.example",
+		CABundle:   "/etc/corp/ca.pem",
+		Insecure:   true,
+	})
+
+	expected := map[string]string{
+		"https_proxy":       "http://proxy:3128",
+		"HTTPS_PROXY":       "http://proxy:3128",
+		"no_proxy":          "corp.example",
+		"NO_PROXY":          "corp.example",
+		"GIT_SSL_CAINFO":    "/etc/corp/ca.pem",
+		"GIT_SSL_NO_VERIFY": "true",
+	}
+	for key, value := range expected {
+		if got := lookupEnv(env, key); got != value {
+			t.Errorf("networkGitEnv() %s = %q, expected %q", key, got, value)
+		}
+	}
+
+	if len(networkGitEnv(config.NetworkConfig{})) != 0 {
+		t.Error("networkGitEnv() should be empty for default settings")
+	}
+}
+
+func TestURLValidator_SetNetworkTLS(t *testing.T) {
+	advertisement := buildRefAdvertisement(t,
+		"1111111111111111111111111111111111111111 refs/heads/main\x00symref=HEAD:refs/heads/main",
+	)
+
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/x-git-upload-pack-advertisement")
+		w.Write([]byte(advertisement))
+	}))
+	defer server.Close()
+
+	caBundle := filepath.Join(t.TempDir(), "ca.pem")
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	if err := os.WriteFile(caBundle, certPEM, 0644); err != nil {
+		t.Fatalf("Failed to write CA bundle: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		network config.NetworkConfig
+		wantErr bool
+	}{
+		{
+			name:    "untrusted certificate",
+			network: config.NetworkConfig{},
+			wantErr: true,
+		},
+		{
+			name:    "custom CA bundle",
+			network: config.NetworkConfig{CABundle: caBundle},
+			wantErr: false,
+		},
+		{
+			name:    "insecure mode",
+			network: config.NetworkConfig{Insecure: true},
+			wantErr: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			validator := NewURLValidator()
+			if err := validator.SetNetwork(tt.network); err != nil {
+				t.Fatalf("SetNetwork() unexpected error: %v", err)
+			}
+
+			_, err := validator.checkHTTPSAccessibility(context.Background(), server.URL+"/team/registry")
+			if (err != nil) != tt.wantErr {
+				t.Errorf("checkHTTPSAccessibility() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	// The bundle replaces the system pool, as GIT_SSL_CAINFO does for git
+	pool, err := loadCABundle(caBundle)
+	if err != nil {
+		t.Fatalf("loadCABundle() unexpected error: %v", err)
+	}
+	expected := x509.NewCertPool()
+	expected.AddCert(server.Certificate())
+	if !pool.Equal(expected) {
+		t.Error("loadCABundle() should only trust the bundle's certificates")
+	}
+
+	invalidBundle := filepath.Join(t.TempDir(), "invalid.pem")
+	if err := os.WriteFile(invalidBundle, []byte("not a certificate"), 0644); err != nil {
+		t.Fatalf("Failed to write invalid CA bundle: %v", err)
+	}
+	if err := NewURLValidator().SetNetwork(config.NetworkConfig{CABundle: invalidBundle}); err == nil {
+		t.Error("SetNetwork() should reject a CA bundle without certificates")
+	}
+}
@@ -0,0 +1,63 @@
+package registry
+
+import (
+	"errors"
+	"net"
+	"strings"
+	"syscall"
+	"time"
+)
+
+var (
+	// ErrOffline is returned for operations that need the network while offline mode is enabled
+	ErrOffline = errors.New("network access is disabled in offline mode")
+
+	// ErrNetworkUnavailable wraps failures caused by missing network connectivity,
+	// so callers can fall back to the cached registries
+	ErrNetworkUnavailable = errors.New("network unavailable")
+)
+
+// offlineGitOutput lists git and ssh messages that indicate missing connectivity
+var offlineGitOutput = []string{
+	"could not resolve host",
+	"could not resolve hostname",
+	"temporary failure in name resolution",
+	"name or service not known",
+	"network is unreachable",
+	"no route to host",
+}
+
+// CacheAge returns how long ago the registry was last synced
+func (r Registry) CacheAge() time.Duration {
+	if r.LastSync.IsZero() {
+		return time.Since(r.AddedAt)
+	}
+	return time.Since(r.LastSync)
+}
+
+// isNetworkUnavailable reports whether an error was caused by missing
+// connectivity rather than a problem with the remote itself
+func isNetworkUnavailable(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) {
+		return true
+	}
+
+	if errors.Is(err, syscall.ENETUNREACH) || errors.Is(err, syscall.EHOSTUNREACH) {
+		return true
+	}
+
+	// git reports connectivity problems only through its output
+	message := strings.ToLower(err.Error())
+	for _, pattern := range offlineGitOutput {
+		if strings.Contains(message, pattern) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,114 @@
+package registry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestIsNetworkUnavailable(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		expected bool
+	}{
+		{
+			name:     "nil error",
+			err:      nil,
+			expected: false,
+		},
+		{
+			name:     "DNS failure",
+			err:      &net.DNSError{Err: "no such host", Name: "github.com"},
+			expected: true,
+		},
+		{
+			name:     "network unreachable",
+			err:      &net.OpError{Op: "dial", Net: "tcp", Err: os.NewSyscallError("connect", syscall.ENETUNREACH)},
+			expected: true,
+		},
+		{
+			name:     "git could not resolve host",
+			err:      fmt.Errorf("git clone failed: exit status 128, output: fatal: unable to access 'https://github.com/x/y/': Could not resolve host: github.com"),
+			expected: true,
+		},
+		{
+			name:     "ssh could not resolve hostname",
+			err:      fmt.Errorf("git ls-remote failed: ssh: Could not resolve hostname github.com: Name or service not known"),
+			expected: true,
+		},
+		{
+			name:     "authentication failure",
+			err:      fmt.Errorf("git clone failed: exit status 128, output: fatal: Authentication failed"),
+			expected: false,
+		},
+		{
+			name:     "server error",
+			err:      errors.New("HTTP 503 Service Unavailable"),
+			expected: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if result := isNetworkUnavailable(tt.err); result != tt.expected {
+				t.Errorf("isNetworkUnavailable(%v) = %v, expected %v", tt.err, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestCacheAge(t *testing.T) {
+	now := time.Now()
+
+	neverSynced := Registry{AddedAt: now.Add(-48 * time.Hour)}
+	if age := neverSynced.CacheAge(); age < 47*time.Hour || age > 49*time.Hour {
+		t.Errorf("CacheAge() for a never synced registry = %s, expected about 48h", age)
+	}
+
+	synced := Registry{AddedAt: now.Add(-48 * time.Hour), LastSync: now.Add(-time.Hour)}
+	if age := synced.CacheAge(); age < 59*time.Minute || age > 61*time.Minute {
+		t.Errorf("CacheAge() for a synced registry = %s, expected about 1h", age)
+	}
+}
+
+func TestOfflineClient(t *testing.T) {
+	tempDir := t.TempDir()
+	client := &Client{
+		configDir:  tempDir,
+		gitClient:  NewGitClient(tempDir),
+		registries: []Registry{{Name: "cached", URL: "https://github.com/user/cached"}},
+	}
+	client.SetOffline(true)
+
+	err := client.AddRegistry(context.Background(), Registry{URL: "https://github.com/user/registry", Name: "registry"})
+	if !errors.Is(err, ErrOffline) {
+		t.Errorf("AddRegistry() error = %v, expected ErrOffline", err)
+	}
+
+	err = client.SyncRegistry(context.Background(), "cached")
+	if !errors.Is(err, ErrOffline) {
+		t.Errorf("SyncRegistry() error = %v, expected ErrOffline", err)
+	}
+}
+
+func TestOfflineValidator(t *testing.T) {
+	validator := NewURLValidator()
+	validator.SetOffline(true)
+
+	_, err := validator.ValidateRemote(context.Background(), "https://github.com/user/registry")
+	if !errors.Is(err, ErrOffline) {
+		t.Errorf("ValidateRemote() error = %v, expected ErrOffline", err)
+	}
+
+	// Format checks don't need the network and still apply
+	_, err = validator.ValidateRemote(context.Background(), "ftp://github.com/user/registry")
+	if err == nil || errors.Is(err, ErrOffline) {
+		t.Errorf("ValidateRemote() error = %v, expected a format error", err)
+	}
+}
@@ -0,0 +1,217 @@
+package registry
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// RemoteRefs holds the refs advertised by a remote git repository
+type RemoteRefs struct {
+	Head string            // Symbolic target of HEAD, e.g. refs/heads/main
+	Refs map[string]string // Full ref name -> object id
+}
+
+// newRemoteRefs creates an empty ref set
+func newRemoteRefs() *RemoteRefs {
+	return &RemoteRefs{
+		Refs: make(map[string]string),
+	}
+}
+
+// HasRef checks if a branch, tag or full ref name is advertised by the remote
+func (r *RemoteRefs) HasRef(ref string) bool {
+	_, ok := r.Resolve(ref)
+	return ok
+}
+
+// Resolve returns the full ref name for a branch, tag or full ref name
+func (r *RemoteRefs) Resolve(ref string) (string, bool) {
+	candidates := []string{ref, "refs/heads/" + ref, "refs/tags/" + ref}
+	for _, candidate := range candidates {
+		if _, ok := r.Refs[candidate]; ok {
+			return candidate, true
+		}
+	}
+	return "", false
+}
+
+// ShortRef strips refs/heads/ or refs/tags/ from a full branch or tag name
+func ShortRef(ref string) string {
+	for _, prefix := range []string{"refs/heads/", "refs/tags/"} {
+		if strings.HasPrefix(ref, prefix) {
+			return strings.TrimPrefix(ref, prefix)
+		}
+	}
+	return ref
+}
+
+// Branches returns the sorted short names of all advertised branches
+func (r *RemoteRefs) Branches() []string {
+	return r.shortNames("refs/heads/")
+}
+
+// Tags returns the sorted short names of all advertised tags
+func (r *RemoteRefs) Tags() []string {
+	return r.shortNames("refs/tags/")
+}
+
+// shortNames returns the sorted ref names below a prefix with the prefix removed
+func (r *RemoteRefs) shortNames(prefix string) []string {
+	var names []string
+	for ref := range r.Refs {
+		if strings.HasPrefix(ref, prefix) {
+			names = append(names, strings.TrimPrefix(ref, prefix))
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// addRef records a single advertised ref, ignoring peeled tag entries
+func (r *RemoteRefs) addRef(objectID, ref string) {
+	if strings.HasSuffix(ref, "^{}") || ref == "capabilities^{}" {
+		return
+	}
+	r.Refs[ref] = objectID
+}
+
+// parseSmartHTTPAdvertisement parses a git-upload-pack ref advertisement
+// as returned by GET /info/refs?service=git-upload-pack
+func parseSmartHTTPAdvertisement(body io.Reader) (*RemoteRefs, error) {
+	reader := bufio.NewReader(body)
+
+	// The advertisement starts with a service announcement followed by a flush packet
+	line, flush, err := readPktLine(reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read service announcement: %w", err)
+	}
+	if flush || strings.TrimSuffix(line, "\n") != "# service=git-upload-pack" {
+		return nil, fmt.Errorf("missing git-upload-pack service announcement")
+	}
+	if _, flush, err = readPktLine(reader); err != nil || !flush {
+		return nil, fmt.Errorf("missing flush packet after service announcement")
+	}
+
+	refs := newRemoteRefs()
+	first := true
+	for {
+		line, flush, err := readPktLine(reader)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read ref advertisement: %w", err)
+		}
+		if flush {
+			break
+		}
+
+		line = strings.TrimSuffix(line, "\n")
+
+		// The first ref carries the capability list after a NUL byte
+		if first {
+			first = false
+			if idx := strings.IndexByte(line, 0); idx >= 0 {
+				refs.Head = parseSymrefCapability(line[idx+1:])
+				line = line[:idx]
+			}
+		}
+
+		parts := strings.SplitN(line, " ", 2)
+		if len(parts) != 2 || !isObjectID(parts[0]) {
+			return nil, fmt.Errorf("malformed ref line: %q", line)
+		}
+		refs.addRef(parts[0], parts[1])
+	}
+
+	return refs, nil
+}
+
+// readPktLine reads a single pkt-line, reporting flush packets separately
+func readPktLine(reader *bufio.Reader) (string, bool, error) {
+	header := make([]byte, 4)
+	if _, err := io.ReadFull(reader, header); err != nil {
+		return "", false, err
+	}
+
+	length, err := strconv.ParseUint(string(header), 16, 16)
+	if err != nil {
+		return "", false, fmt.Errorf("invalid pkt-line length %q", string(header))
+	}
+
+	if length == 0 {
+		return "", true, nil
+	}
+	if length < 4 {
+		return "", false, fmt.Errorf("invalid pkt-line length %d", length)
+	}
+
+	payload := make([]byte, length-4)
+	if _, err := io.ReadFull(reader, payload); err != nil {
+		return "", false, err
+	}
+
+	return string(payload), false, nil
+}
+
+// parseSymrefCapability extracts the HEAD target from a capability list
+func parseSymrefCapability(capabilities string) string {
+	for _, capability := range strings.Fields(capabilities) {
+		if target, ok := strings.CutPrefix(capability, "symref=HEAD:"); ok {
+			return target
+		}
+	}
+	return ""
+}
+
+// parseLsRemoteOutput parses the output of `git ls-remote --symref`
+func parseLsRemoteOutput(output []byte) (*RemoteRefs, error) {
+	refs := newRemoteRefs()
+
+	scanner := bufio.NewScanner(bytes.NewReader(output))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		parts := strings.SplitN(line, "\t", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("malformed ls-remote line: %q", line)
+		}
+
+		// Symbolic refs are reported as "ref: refs/heads/main<TAB>HEAD"
+		if target, ok := strings.CutPrefix(parts[0], "ref: "); ok {
+			if parts[1] == "HEAD" {
+				refs.Head = target
+			}
+			continue
+		}
+
+		if !isObjectID(parts[0]) {
+			return nil, fmt.Errorf("malformed ls-remote line: %q", line)
+		}
+		refs.addRef(parts[0], parts[1])
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read ls-remote output: %w", err)
+	}
+
+	return refs, nil
+}
+
+// isObjectID checks if a string looks like a SHA-1 or SHA-256 object id
+func isObjectID(value string) bool {
+	if len(value) != 40 && len(value) != 64 {
+		return false
+	}
+	for _, c := range value {
+		if !strings.ContainsRune("0123456789abcdef", c) {
+			return false
+		}
+	}
+	return true
+}
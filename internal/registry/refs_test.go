@@ -0,0 +1,138 @@
+package registry
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// buildRefAdvertisement encodes ref lines as a smart HTTP upload-pack advertisement
+func buildRefAdvertisement(t *testing.T, lines ...string) string {
+	t.Helper()
+
+	var b strings.Builder
+	pkt := func(payload string) {
+		fmt.Fprintf(&b, "%04x%s", len(payload)+4, payload)
+	}
+
+	pkt("# service=git-upload-pack\n")
+	b.WriteString("0000")
+	for _, line := range lines {
+		pkt(line + "\n")
+	}
+	b.WriteString("0000")
+
+	return b.String()
+}
+
+func TestParseSmartHTTPAdvertisement(t *testing.T) {
+	body := buildRefAdvertisement(t,
+		"1111111111111111111111111111111111111111 HEAD\x00multi_ack symref=HEAD:refs/heads/main agent=git/2.43.0",
+		"1111111111111111111111111111111111111111 refs/heads/main",
+		"3333333333333333333333333333333333333333 refs/heads/develop",
+		"2222222222222222222222222222222222222222 refs/tags/v1.0.0",
+		"4444444444444444444444444444444444444444 refs/tags/v1.0.0^{}",
+	)
+
+	refs, err := parseSmartHTTPAdvertisement(strings.NewReader(body))
+	if err != nil {
+		t.Fatalf("parseSmartHTTPAdvertisement() unexpected error: %v", err)
+	}
+
+	if refs.Head != "refs/heads/main" {
+		t.Errorf("Head = %q, want %q", refs.Head, "refs/heads/main")
+	}
+
+	if got := refs.Branches(); !reflect.DeepEqual(got, []string{"develop", "main"}) {
+		t.Errorf("Branches() = %v, want [develop main]", got)
+	}
+
+	if got := refs.Tags(); !reflect.DeepEqual(got, []string{"v1.0.0"}) {
+		t.Errorf("Tags() = %v, want [v1.0.0]", got)
+	}
+
+	if refs.Refs["refs/tags/v1.0.0"] != "2222222222222222222222222222222222222222" {
+		t.Errorf("peeled tag entry should not replace the tag object id")
+	}
+}
+
+func TestParseSmartHTTPAdvertisement_Invalid(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+	}{
+		{
+			name: "empty body",
+			body: "",
+		},
+		{
+			name: "html page",
+			body: "<!DOCTYPE html><html></html>",
+		},
+		{
+			name: "wrong service",
+			body: "001f# service=git-receive-pack\n0000",
+		},
+		{
+			name: "missing trailing flush",
+			body: "001e# service=git-upload-pack\n0000",
+		},
+		{
+			name: "malformed ref line",
+			body: buildRefAdvertisement(t, "not-a-sha refs/heads/main"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := parseSmartHTTPAdvertisement(strings.NewReader(tt.body)); err == nil {
+				t.Error("parseSmartHTTPAdvertisement() expected error but got none")
+			}
+		})
+	}
+}
+
+func TestParseLsRemoteOutput(t *testing.T) {
+	output := "ref: refs/heads/main\tHEAD\n" +
+		"1111111111111111111111111111111111111111\tHEAD\n" +
+		"1111111111111111111111111111111111111111\trefs/heads/main\n" +
+		"2222222222222222222222222222222222222222\trefs/tags/v2.0.0\n"
+
+	refs, err := parseLsRemoteOutput([]byte(output))
+	if err != nil {
+		t.Fatalf("parseLsRemoteOutput() unexpected error: %v", err)
+	}
+
+	if refs.Head != "refs/heads/main" {
+		t.Errorf("Head = %q, want %q", refs.Head, "refs/heads/main")
+	}
+
+	for _, ref := range []string{"HEAD", "main", "v2.0.0", "refs/tags/v2.0.0"} {
+		if !refs.HasRef(ref) {
+			t.Errorf("HasRef(%s) = false, want true", ref)
+		}
+	}
+
+	if refs.HasRef("develop") {
+		t.Error("HasRef(develop) = true, want false")
+	}
+
+	if _, err := parseLsRemoteOutput([]byte("garbage line\n")); err == nil {
+		t.Error("parseLsRemoteOutput() expected error for malformed output")
+	}
+}
+
+func TestShortRef(t *testing.T) {
+	tests := map[string]string{
+		"refs/heads/main":  "main",
+		"refs/tags/v1.0.0": "v1.0.0",
+		"feature/x":        "feature/x",
+		"refs/pull/1/head": "refs/pull/1/head",
+	}
+	for ref, expected := range tests {
+		if short := ShortRef(ref); short != expected {
+			t.Errorf("ShortRef(%s) = %s, want %s", ref, short, expected)
+		}
+	}
+}
@@ -1,11 +1,15 @@
 package registry
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
 	"time"
+
+	"github.com/griffin/go-shellify/internal/config"
+	"github.com/griffin/go-shellify/internal/logger"
 )
 
 // Registry represents a shellify registry
@@ -13,6 +17,9 @@ type Registry struct {
 	URL         string    `json:"url"`
 	Name        string    `json:"name"`
 	Description string    `json:"description,omitempty"`
+	Ref         string    `json:"ref,omitempty"`  // Branch or tag to track, empty for the default branch
+	Auth        *Auth     `json:"auth,omitempty"` // Credential references for private registries
+	TrustedKeys []string  `json:"trusted_keys,omitempty"` // Base64 ed25519 keys the registry must be signed with
 	AddedAt     time.Time `json:"added_at"`
 	LastSync    time.Time `json:"last_sync,omitempty"`
 }
@@ -31,19 +38,48 @@ type Module struct {
 	Description  string        `json:"description,omitempty"`
 	Version      string        `json:"version,omitempty"`
 	Path         string        `json:"path,omitempty"`
+	Checksum     string        `json:"sha256,omitempty"` // sha256 over the module directory, see ModuleChecksum
 	Shell        string        `json:"shell,omitempty"` // Legacy field for backward compatibility
 	Author       string        `json:"author,omitempty"`
 	Category     string        `json:"category,omitempty"`
+	Tags         []string      `json:"tags,omitempty"`
 	Dependencies []string      `json:"dependencies,omitempty"`
 	Conflicts    []string      `json:"conflicts,omitempty"`
 	Platforms    []string      `json:"platforms,omitempty"`    // darwin, linux, windows
 	Shells       []string      `json:"shells,omitempty"`       // bash, zsh, fish, powershell
+	ShellVersions []string     `json:"shell_versions,omitempty"` // e.g. "bash >= 4.0", see ParseShellVersion
 	Environment  []Environment `json:"environment,omitempty"`   // Environment variables
 	Aliases      []Alias       `json:"aliases,omitempty"`      // Shell aliases
 	Functions    []Function    `json:"functions,omitempty"`    // Shell functions
 	PathEntries  []PathEntry   `json:"path_entries,omitempty"` // PATH modifications
 	Files        []File        `json:"files,omitempty"`        // Files to source/execute
 	Checks       []Check       `json:"checks,omitempty"`       // System requirements
+	When         string        `json:"when,omitempty"`         // Condition for generating the module, see package condition
+}
+
+// Conditions returns the when conditions set in a module and its items
+func Conditions(module Module) []string {
+	var conditions []string
+	add := func(when string) {
+		if when != "" {
+			conditions = append(conditions, when)
+		}
+	}
+
+	add(module.When)
+	for _, env := range module.Environment {
+		add(env.When)
+	}
+	for _, alias := range module.Aliases {
+		add(alias.When)
+	}
+	for _, function := range module.Functions {
+		add(function.When)
+	}
+	for _, entry := range module.PathEntries {
+		add(entry.When)
+	}
+	return conditions
 }
 
 // Environment represents an environment variable
@@ -51,12 +87,15 @@ type Environment struct {
 	Name   string `json:"name"`
 	Value  string `json:"value"`
 	Export bool   `json:"export,omitempty"`
+	When   string `json:"when,omitempty"`
 }
 
 // Alias represents a shell alias
 type Alias struct {
 	Name    string `json:"name"`
 	Command string `json:"command"`
+	Shadows bool   `json:"shadows,omitempty"` // Acknowledges replacing a builtin or command of the same name
+	When    string `json:"when,omitempty"`
 }
 
 // Function represents a shell function
@@ -66,6 +105,8 @@ type Function struct {
 	Commands    []string `json:"commands"`
 	Parameters  []string `json:"parameters,omitempty"`
 	Shell       string   `json:"shell,omitempty"`
+	Shadows     bool     `json:"shadows,omitempty"` // Acknowledges replacing a builtin or command of the same name
+	When        string   `json:"when,omitempty"`
 }
 
 // PathEntry represents a PATH modification
@@ -75,6 +116,7 @@ type PathEntry struct {
 	Prepend     bool   `json:"prepend,omitempty"`
 	Description string `json:"description,omitempty"`
 	Priority    int    `json:"priority,omitempty"`
+	When        string `json:"when,omitempty"`
 }
 
 // File represents a file to be created or managed
@@ -108,6 +150,9 @@ type Client struct {
 	configDir string
 	registries []Registry
 	gitClient *GitClient
+	offline   bool
+	trusted   func(module string) bool // Modules gated by trust mode, nil when disabled
+	requireSignatures bool
 }
 
 // NewClient creates a new registry client
@@ -137,37 +182,88 @@ func NewClient() (*Client, error) {
 	return client, nil
 }
 
-// AddRegistry adds a new registry after verification and cloning
-func (c *Client) AddRegistry(url, name string) error {
+// SetNetwork applies proxy and TLS settings to all git operations
+func (c *Client) SetNetwork(network config.NetworkConfig) {
+	c.gitClient.SetNetwork(network)
+}
+
+// SetOffline disables every operation that needs network access
+func (c *Client) SetOffline(offline bool) {
+	c.offline = offline
+}
+
+// IsOffline reports whether offline mode is enabled
+func (c *Client) IsOffline() bool {
+	return c.offline
+}
+
+// SetRequireSignatures refuses registries without pinned keys and a valid
+// signature. Registries with pinned keys are always verified.
+func (c *Client) SetRequireSignatures(require bool) {
+	c.requireSignatures = require
+}
+
+// RepositoryPath returns the local clone directory of a registry
+func (c *Client) RepositoryPath(name string) string {
+	return c.gitClient.GetRepositoryPath(name)
+}
+
+// IsCached reports whether a registry has a local clone to work from
+func (c *Client) IsCached(name string) bool {
+	return c.gitClient.IsRepositoryCloned(name)
+}
+
+// SetTimeouts sets the per-operation timeouts for git operations
+func (c *Client) SetTimeouts(timeouts config.TimeoutConfig) {
+	c.gitClient.SetTimeouts(timeouts)
+}
+
+// SetRetry sets the retry policy for git clone and fetch operations
+func (c *Client) SetRetry(retry config.RetryConfig) {
+	c.gitClient.SetRetry(retry)
+}
+
+// AddRegistry adds a new registry after verification and cloning.
+// An empty Ref tracks the remote's default branch, a nil Auth uses
+// whatever credentials git picks up from the environment and pinned
+// TrustedKeys require every sync to be signed by one of them.
+func (c *Client) AddRegistry(ctx context.Context, registry Registry) error {
+	if c.offline {
+		return fmt.Errorf("cannot add registry: %w", ErrOffline)
+	}
+
 	// Check if registry already exists
 	for _, reg := range c.registries {
-		if reg.URL == url {
-			return fmt.Errorf("registry already exists: %s", url)
+		if reg.URL == registry.URL {
+			return fmt.Errorf("registry already exists: %s", registry.URL)
 		}
-		if reg.Name == name {
-			return fmt.Errorf("registry name already exists: %s", name)
+		if reg.Name == registry.Name {
+			return fmt.Errorf("registry name already exists: %s", registry.Name)
 		}
 	}
 
+	if _, err := ParsePublicKeys(registry.TrustedKeys); err != nil {
+		return err
+	}
+
+	// git clone --branch only takes short branch and tag names
+	registry.Ref = ShortRef(registry.Ref)
+
 	// Clone the repository
-	if err := c.gitClient.CloneRepository(url, name); err != nil {
+	if err := c.gitClient.CloneRepository(ctx, registry.URL, registry.Name, registry.Ref, registry.Auth); err != nil {
 		return fmt.Errorf("failed to clone registry: %w", err)
 	}
 
 	// Verify the cloned registry has valid structure
-	if err := c.verifyLocalRegistry(name); err != nil {
+	if err := c.verifyLocalRegistry(registry); err != nil {
 		// Clean up the failed clone
-		c.gitClient.RemoveRepository(name)
+		c.gitClient.RemoveRepository(registry.Name)
 		return fmt.Errorf("registry structure validation failed: %w", err)
 	}
 
 	// Add registry to configuration
-	registry := Registry{
-		URL:      url,
-		Name:     name,
-		AddedAt:  time.Now(),
-		LastSync: time.Now(),
-	}
+	registry.AddedAt = time.Now()
+	registry.LastSync = time.Now()
 
 	c.registries = append(c.registries, registry)
 	return c.saveRegistries()
@@ -183,6 +279,9 @@ func (c *Client) RemoveRegistry(identifier string) error {
 				fmt.Printf("Warning: failed to remove cached repository: %v\n", err)
 			}
 			
+			os.Remove(c.changesPath(reg.Name))
+			os.Remove(c.approvedPath(reg.Name))
+
 			// Remove from configuration
 			c.registries = append(c.registries[:i], c.registries[i+1:]...)
 			return c.saveRegistries()
@@ -197,11 +296,19 @@ func (c *Client) ListRegistries() []Registry {
 }
 
 // verifyLocalRegistry checks if a locally cloned registry has valid structure
-func (c *Client) verifyLocalRegistry(name string) error {
-	repoPath := c.gitClient.GetRepositoryPath(name)
+// and, when verification is required, a valid signature
+func (c *Client) verifyLocalRegistry(registry Registry) error {
+	repoPath := c.gitClient.GetRepositoryPath(registry.Name)
 	
 	// Use comprehensive structure validator
 	validator := NewStructureValidator(repoPath)
+	if c.requireSignatures || len(registry.TrustedKeys) > 0 {
+		keys, err := ParsePublicKeys(registry.TrustedKeys)
+		if err != nil {
+			return err
+		}
+		validator.RequireSignature(keys)
+	}
 	if err := validator.ValidateStructure(); err != nil {
 		return fmt.Errorf("registry structure validation failed: %w", err)
 	}
@@ -241,8 +348,9 @@ func (c *Client) GetRegistryIndex(registryName string) (*RegistryIndex, error) {
 	return &index, nil
 }
 
-// SyncRegistry updates a registry by pulling latest changes
-func (c *Client) SyncRegistry(name string) error {
+// SyncRegistry updates a registry by pulling latest changes and records
+// how its modules changed
+func (c *Client) SyncRegistry(ctx context.Context, name string) error {
 	// Find the registry
 	var registryIndex int = -1
 	for i, reg := range c.registries {
@@ -256,29 +364,102 @@ func (c *Client) SyncRegistry(name string) error {
 		return fmt.Errorf("registry not found: %s", name)
 	}
 
+	if c.offline {
+		return fmt.Errorf("cannot sync registry %s: %w", name, ErrOffline)
+	}
+
+	// Remember the current modules so the sync can be summarized
+	var previous map[string]Module
+	if c.gitClient.IsRepositoryCloned(name) {
+		var err error
+		if previous, err = LoadModules(c.gitClient.GetRepositoryPath(name)); err != nil {
+			logger.Debug("Not recording changes for registry %s: %v", name, err)
+		}
+		c.snapshotEnabledModules(name, previous)
+	}
+
+	registry := c.registries[registryIndex]
+
 	// Check if repository is cloned
+	var rollback string
 	if !c.gitClient.IsRepositoryCloned(name) {
 		// Repository not cloned, clone it
-		registry := c.registries[registryIndex]
-		if err := c.gitClient.CloneRepository(registry.URL, name); err != nil {
+		if err := c.gitClient.CloneRepository(ctx, registry.URL, name, registry.Ref, registry.Auth); err != nil {
+			if isNetworkUnavailable(err) {
+				return fmt.Errorf("failed to clone registry during sync: %w: %w", ErrNetworkUnavailable, err)
+			}
 			return fmt.Errorf("failed to clone registry during sync: %w", err)
 		}
 	} else {
-		// Update existing repository
+		// Update existing repository, remembering the verified commit so
+		// content that fails verification never replaces it
 		repoPath := c.gitClient.GetRepositoryPath(name)
-		if err := c.gitClient.updateRepository(repoPath); err != nil {
+		head, err := c.gitClient.headCommit(ctx, repoPath)
+		if err != nil {
+			logger.Debug("Cannot roll back registry %s if verification fails: %v", name, err)
+		}
+		rollback = head
+		if err := c.gitClient.updateRepository(ctx, repoPath, registry.Ref, registry.Auth); err != nil {
+			if isNetworkUnavailable(err) {
+				return fmt.Errorf("failed to update registry: %w: %w", ErrNetworkUnavailable, err)
+			}
 			return fmt.Errorf("failed to update registry: %w", err)
 		}
 	}
 
 	// Verify the registry structure after sync
-	if err := c.verifyLocalRegistry(name); err != nil {
+	if err := c.verifyLocalRegistry(registry); err != nil {
+		if rollback != "" {
+			if resetErr := c.gitClient.resetRepository(ctx, c.gitClient.GetRepositoryPath(name), rollback); resetErr != nil {
+				logger.Warn("Failed to restore registry %s: %v", name, resetErr)
+			}
+		} else {
+			c.gitClient.RemoveRepository(name)
+		}
 		return fmt.Errorf("registry validation failed after sync: %w", err)
 	}
 
-	// Update last sync time
-	c.registries[registryIndex].LastSync = time.Now()
-	return c.saveRegistries()
+	if previous != nil {
+		c.recordSyncChanges(name, previous)
+	}
+
+	return c.markSynced(name)
+}
+
+// recordSyncChanges diffs the modules of a synced registry against their
+// state before the sync and stores the result
+func (c *Client) recordSyncChanges(name string, previous map[string]Module) {
+	current, err := LoadModules(c.gitClient.GetRepositoryPath(name))
+	if err != nil {
+		logger.Warn("Failed to read modules of registry %s after sync: %v", name, err)
+		return
+	}
+
+	changes := DiffModules(name, previous, current)
+	if changes.IsEmpty() {
+		return
+	}
+	if err := c.recordChanges(changes); err != nil {
+		logger.Warn("Failed to record changes for registry %s: %v", name, err)
+	}
+}
+
+// markSynced records the sync time, reloading the registry list first so
+// registries changed by another process in the meantime are kept
+func (c *Client) markSynced(name string) error {
+	c.registries = nil
+	if err := c.loadRegistries(); err != nil {
+		return err
+	}
+
+	for i := range c.registries {
+		if c.registries[i].Name == name {
+			c.registries[i].LastSync = time.Now()
+			return c.saveRegistries()
+		}
+	}
+
+	return fmt.Errorf("registry not found: %s", name)
 }
 
 // loadRegistries loads registries from config file
@@ -0,0 +1,190 @@
+package registry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"net"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/griffin/go-shellify/internal/config"
+	"github.com/griffin/go-shellify/internal/logger"
+)
+
+// retryPolicy retries operations that fail with transient network errors
+type retryPolicy struct {
+	attempts       int
+	initialBackoff time.Duration
+	maxBackoff     time.Duration
+	jitter         float64
+}
+
+// newRetryPolicy creates a retry policy from configuration, applying defaults
+func newRetryPolicy(cfg config.RetryConfig) retryPolicy {
+	return retryPolicy{
+		attempts:       cfg.AttemptCount(),
+		initialBackoff: cfg.InitialBackoffDuration(),
+		maxBackoff:     cfg.MaxBackoffDuration(),
+		jitter:         cfg.JitterFraction(),
+	}
+}
+
+// permanentError marks an error that retrying can't fix
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string {
+	return e.err.Error()
+}
+
+func (e *permanentError) Unwrap() error {
+	return e.err
+}
+
+// permanent marks an error as not retryable
+func permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &permanentError{err: err}
+}
+
+// do runs fn until it succeeds, fails permanently, runs out of attempts or
+// the context is done
+func (p retryPolicy) do(ctx context.Context, operation string, fn func() error) error {
+	var err error
+	for attempt := 1; ; attempt++ {
+		err = fn()
+		if err == nil || !isRetryable(err) || ctx.Err() != nil {
+			return err
+		}
+		if attempt >= p.attempts {
+			return fmt.Errorf("%w (gave up after %d attempts)", err, attempt)
+		}
+
+		delay := p.backoff(attempt)
+		logger.Warn("%s failed (attempt %d/%d): %v, retrying in %s", operation, attempt, p.attempts, err, delay.Round(time.Millisecond))
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+	}
+}
+
+// backoff returns the delay before the given retry: exponential growth from
+// the initial backoff, capped at the maximum, with +/- jitter applied
+func (p retryPolicy) backoff(attempt int) time.Duration {
+	delay := p.initialBackoff
+	for i := 1; i < attempt && delay < p.maxBackoff; i++ {
+		delay *= 2
+	}
+	if delay > p.maxBackoff {
+		delay = p.maxBackoff
+	}
+
+	if p.jitter > 0 {
+		factor := 1 - p.jitter + rand.Float64()*2*p.jitter
+		delay = time.Duration(float64(delay) * factor)
+	}
+
+	return delay
+}
+
+// isRetryable classifies an error as transient. Errors marked permanent,
+// cancellations and anything unrecognized are not retried.
+func isRetryable(err error) bool {
+	var permanentErr *permanentError
+	if errors.As(err, &permanentErr) {
+		return false
+	}
+	if errors.Is(err, context.Canceled) {
+		return false
+	}
+	if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, errTransient) {
+		return true
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) {
+		return dnsErr.IsTimeout || dnsErr.IsTemporary
+	}
+
+	return errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, syscall.ECONNREFUSED) ||
+		errors.Is(err, syscall.ECONNABORTED) ||
+		errors.Is(err, syscall.EPIPE)
+}
+
+// errTransient marks errors classified as transient from their description
+var errTransient = errors.New("transient failure")
+
+// transientGitOutput lists git and curl messages that indicate a transient failure
+var transientGitOutput = []string{
+	"could not resolve host",
+	"temporary failure in name resolution",
+	"connection reset",
+	"connection timed out",
+	"operation timed out",
+	"connection refused",
+	"early eof",
+	"unexpected disconnect",
+	"rpc failed",
+	"the remote end hung up unexpectedly",
+	"returned error: 500",
+	"returned error: 502",
+	"returned error: 503",
+	"returned error: 504",
+	"returned error: 429",
+}
+
+// permanentGitOutput lists git messages that retrying can't fix. They take
+// precedence since git often reports a hang-up after an auth failure.
+var permanentGitOutput = []string{
+	"authentication failed",
+	"permission denied",
+	"repository not found",
+	"could not read username",
+	"returned error: 401",
+	"returned error: 403",
+	"returned error: 404",
+	"remote branch",
+	"couldn't find remote ref",
+}
+
+// classifyGitError marks a failed git command as transient when its output
+// matches a known network failure, and as permanent otherwise
+func classifyGitError(err error, output []byte) error {
+	if err == nil {
+		return nil
+	}
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return err
+	}
+
+	lower := strings.ToLower(string(output))
+	for _, pattern := range permanentGitOutput {
+		if strings.Contains(lower, pattern) {
+			return permanent(err)
+		}
+	}
+	for _, pattern := range transientGitOutput {
+		if strings.Contains(lower, pattern) {
+			return fmt.Errorf("%w: %w", errTransient, err)
+		}
+	}
+
+	return permanent(err)
+}
@@ -0,0 +1,182 @@
+package registry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/griffin/go-shellify/internal/config"
+)
+
+func TestRetryPolicy_Backoff(t *testing.T) {
+	policy := retryPolicy{
+		attempts:       5,
+		initialBackoff: 100 * time.Millisecond,
+		maxBackoff:     300 * time.Millisecond,
+	}
+
+	expected := []time.Duration{
+		100 * time.Millisecond,
+		200 * time.Millisecond,
+		300 * time.Millisecond,
+		300 * time.Millisecond,
+	}
+	for i, want := range expected {
+		if got := policy.backoff(i + 1); got != want {
+			t.Errorf("backoff(%d) = %s, want %s", i+1, got, want)
+		}
+	}
+
+	policy.jitter = 0.5
+	for i := 0; i < 100; i++ {
+		got := policy.backoff(1)
+		if got < 50*time.Millisecond || got > 150*time.Millisecond {
+			t.Fatalf("backoff(1) with jitter = %s, want within 50ms-150ms", got)
+		}
+	}
+}
+
+func TestIsRetryable(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		expected bool
+	}{
+		{"permanent error", permanent(errors.New("not found")), false},
+		{"transient error", fmt.Errorf("%w: status 503", errTransient), true},
+		{"cancelled", context.Canceled, false},
+		{"deadline exceeded", fmt.Errorf("git clone timed out: %w", context.DeadlineExceeded), true},
+		{"connection reset", fmt.Errorf("read: %w", syscall.ECONNRESET), true},
+		{"connection refused", fmt.Errorf("dial: %w", syscall.ECONNREFUSED), true},
+		{"temporary DNS failure", &net.DNSError{Err: "server misbehaving", IsTemporary: true}, true},
+		{"unknown host", &net.DNSError{Err: "no such host", IsNotFound: true}, false},
+		{"unclassified error", errors.New("something else"), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isRetryable(tt.err); got != tt.expected {
+				t.Errorf("isRetryable(%v) = %v, expected %v", tt.err, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestClassifyGitError(t *testing.T) {
+	exitErr := errors.New("exit status 128")
+
+	tests := []struct {
+		name     string
+		output   string
+		expected bool
+	}{
+		{"DNS failure", "fatal: unable to access 'https://github.com/x/y/': Could not resolve host: github.com", true},
+		{"server error", "fatal: unable to access 'https://git.example/x/y/': The requested URL returned error: 502", true},
+		{"connection reset", "error: RPC failed; curl 56 Recv failure: Connection reset by peer", true},
+		{"authentication failure", "fatal: Authentication failed for 'https://github.com/x/y/'", false},
+		{"auth failure followed by hang-up", "Permission denied (publickey).\nfatal: the remote end hung up unexpectedly", false},
+		{"missing repository", "remote: Repository not found.\nfatal: repository 'https://github.com/x/y/' not found", false},
+		{"missing branch", "fatal: Remote branch nope not found in upstream origin", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := classifyGitError(exitErr, []byte(tt.output))
+			if got := isRetryable(err); got != tt.expected {
+				t.Errorf("classifyGitError(%q) retryable = %v, expected %v", tt.output, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestRetryPolicy_Do(t *testing.T) {
+	policy := newRetryPolicy(config.RetryConfig{Attempts: 3, InitialBackoff: "1ms", MaxBackoff: "2ms"})
+
+	calls := 0
+	err := policy.do(context.Background(), "test", func() error {
+		calls++
+		if calls < 3 {
+			return fmt.Errorf("%w: flaky", errTransient)
+		}
+		return nil
+	})
+	if err != nil || calls != 3 {
+		t.Errorf("do() = %v after %d calls, want success after 3 calls", err, calls)
+	}
+
+	calls = 0
+	err = policy.do(context.Background(), "test", func() error {
+		calls++
+		return permanent(errors.New("not found"))
+	})
+	if err == nil || calls != 1 {
+		t.Errorf("do() = %v after %d calls, want permanent failure after 1 call", err, calls)
+	}
+
+	calls = 0
+	err = policy.do(context.Background(), "test", func() error {
+		calls++
+		return fmt.Errorf("%w: still down", errTransient)
+	})
+	if err == nil || calls != 3 {
+		t.Errorf("do() = %v after %d calls, want failure after 3 calls", err, calls)
+	}
+}
+
+func TestURLValidator_RetriesServerErrors(t *testing.T) {
+	advertisement := buildRefAdvertisement(t,
+		"1111111111111111111111111111111111111111 refs/heads/main\x00symref=HEAD:refs/heads/main",
+	)
+
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if requests < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("Content-Type", "application/x-git-upload-pack-advertisement")
+		w.Write([]byte(advertisement))
+	}))
+	defer server.Close()
+
+	validator := NewURLValidator()
+	validator.SetRetry(config.RetryConfig{Attempts: 3, InitialBackoff: "1ms"})
+
+	if _, err := validator.checkHTTPSAccessibility(context.Background(), server.URL+"/team/registry"); err != nil {
+		t.Fatalf("checkHTTPSAccessibility() error = %v, want success after retries", err)
+	}
+	if requests != 3 {
+		t.Errorf("server received %d requests, want 3", requests)
+	}
+}
+
+func TestURLValidator_StopsRetryingWhenCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		cancel()
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	validator := NewURLValidator()
+	validator.SetRetry(config.RetryConfig{Attempts: 5, InitialBackoff: "1h", MaxBackoff: "1h"})
+
+	_, err := validator.checkHTTPSAccessibility(ctx, server.URL+"/team/registry")
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("checkHTTPSAccessibility() error = %v, want context.Canceled", err)
+	}
+	if requests != 1 {
+		t.Errorf("server received %d requests, want 1", requests)
+	}
+}
@@ -0,0 +1,124 @@
+package registry
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// ErrShellVersionUnknown is returned when a module constrains the shell
+// version but the version of the shell couldn't be determined
+var ErrShellVersionUnknown = errors.New("shell version unknown")
+
+// shellVersionPattern matches constraints like "bash >= 4.0"
+var shellVersionPattern = regexp.MustCompile(`^\s*([a-z]+)\s*(>=|<=|==|!=|>|<|=)\s*(\d+(?:\.\d+)*)\s*$`)
+
+// ShellVersion is a minimum, maximum or exact version of a shell a module
+// needs, written like "bash >= 4.0"
+type ShellVersion struct {
+	Shell    string
+	Operator string
+	Version  string
+}
+
+// ParseShellVersion parses a shell version constraint
+func ParseShellVersion(constraint string) (ShellVersion, error) {
+	match := shellVersionPattern.FindStringSubmatch(strings.ToLower(constraint))
+	if match == nil {
+		return ShellVersion{}, fmt.Errorf("invalid shell version constraint %q, expected e.g. \"bash >= 4.0\"", constraint)
+	}
+	return ShellVersion{Shell: match[1], Operator: match[2], Version: match[3]}, nil
+}
+
+// String formats the constraint
+func (v ShellVersion) String() string {
+	return v.Shell + " " + v.Operator + " " + v.Version
+}
+
+// Allows reports whether a shell version satisfies the constraint. Only
+// the leading numeric components of version are compared, so "5.2.15(1)"
+// works as well.
+func (v ShellVersion) Allows(version string) bool {
+	result := compareVersions(version, v.Version)
+	switch v.Operator {
+	case ">=":
+		return result >= 0
+	case ">":
+		return result > 0
+	case "<=":
+		return result <= 0
+	case "<":
+		return result < 0
+	case "!=":
+		return result != 0
+	default:
+		return result == 0
+	}
+}
+
+// compareVersions compares dotted numeric versions, treating missing
+// components as 0
+func compareVersions(a, b string) int {
+	as, bs := versionParts(a), versionParts(b)
+	for i := 0; i < len(as) || i < len(bs); i++ {
+		var x, y int
+		if i < len(as) {
+			x = as[i]
+		}
+		if i < len(bs) {
+			y = bs[i]
+		}
+		if x != y {
+			if x < y {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
+
+// versionParts returns the leading numeric components of a version
+func versionParts(version string) []int {
+	var parts []int
+	for _, field := range strings.Split(version, ".") {
+		end := 0
+		for end < len(field) && field[end] >= '0' && field[end] <= '9' {
+			end++
+		}
+		if end == 0 {
+			break
+		}
+		n, _ := strconv.Atoi(field[:end])
+		parts = append(parts, n)
+		if end < len(field) {
+			break
+		}
+	}
+	return parts
+}
+
+// CheckShellVersion checks a module's shell version constraints for a shell.
+// Constraints for other shells are ignored. An empty version with
+// constraints for the shell returns ErrShellVersionUnknown.
+func CheckShellVersion(module Module, shell, version string) error {
+	for _, constraint := range module.ShellVersions {
+		parsed, err := ParseShellVersion(constraint)
+		if err != nil {
+			return err
+		}
+		if parsed.Shell != strings.ToLower(shell) && !(parsed.Shell == "pwsh" && strings.EqualFold(shell, "powershell")) {
+			continue
+		}
+
+		if version == "" {
+			return fmt.Errorf("%w: module %s needs %s", ErrShellVersionUnknown, module.Name, parsed)
+		}
+		if !parsed.Allows(version) {
+			return fmt.Errorf("module %s needs %s, found %s", module.Name, parsed, version)
+		}
+	}
+	return nil
+}
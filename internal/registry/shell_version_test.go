@@ -0,0 +1,74 @@
+package registry
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestShellVersionAllows(t *testing.T) {
+	tests := []struct {
+		constraint string
+		version    string
+		expected   bool
+	}{
+		{constraint: "bash >= 4.0", version: "3.2.57", expected: false},
+		{constraint: "bash >= 4.0", version: "4.0", expected: true},
+		{constraint: "bash >= 4.0", version: "5.2.15(1)-release", expected: true},
+		{constraint: "bash>=4", version: "4.0.0", expected: true},
+		{constraint: "zsh > 5.8", version: "5.8", expected: false},
+		{constraint: "zsh > 5.8", version: "5.8.1", expected: true},
+		{constraint: "fish < 4", version: "3.7.1", expected: true},
+		{constraint: "pwsh <= 7.2", version: "7.4.1", expected: false},
+		{constraint: "Bash = 5.1", version: "5.1", expected: true},
+		{constraint: "bash != 5.1", version: "5.1.0", expected: false},
+		{constraint: "bash >= 4.10", version: "4.9", expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.constraint+" "+tt.version, func(t *testing.T) {
+			constraint, err := ParseShellVersion(tt.constraint)
+			if err != nil {
+				t.Fatalf("ParseShellVersion() failed: %v", err)
+			}
+			if result := constraint.Allows(tt.version); result != tt.expected {
+				t.Errorf("Allows(%q) = %v, expected %v", tt.version, result, tt.expected)
+			}
+		})
+	}
+
+	for _, invalid := range []string{"", "bash", "bash 4.0", "bash >= four", ">= 4.0", "bash => 4.0"} {
+		if _, err := ParseShellVersion(invalid); err == nil {
+			t.Errorf("ParseShellVersion(%q) should fail", invalid)
+		}
+	}
+}
+
+func TestCheckShellVersion(t *testing.T) {
+	module := Module{Name: "assoc", ShellVersions: []string{"bash >= 4.0", "pwsh >= 7.0"}}
+
+	tests := []struct {
+		name     string
+		shell    string
+		version  string
+		err      bool
+		expected error
+	}{
+		{name: "new bash", shell: "bash", version: "5.2.15"},
+		{name: "old bash", shell: "bash", version: "3.2.57", err: true},
+		{name: "unknown bash", shell: "bash", err: true, expected: ErrShellVersionUnknown},
+		{name: "unconstrained shell", shell: "zsh", version: "5.0"},
+		{name: "powershell", shell: "powershell", version: "5.1", err: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := CheckShellVersion(module, tt.shell, tt.version)
+			if (err != nil) != tt.err {
+				t.Fatalf("CheckShellVersion() error = %v, expected error %v", err, tt.err)
+			}
+			if tt.expected != nil && !errors.Is(err, tt.expected) {
+				t.Errorf("CheckShellVersion() error = %v, expected %v", err, tt.expected)
+			}
+		})
+	}
+}
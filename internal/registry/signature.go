@@ -0,0 +1,306 @@
+package registry
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+const (
+	// ManifestFile lists the hash of every file in a signed registry
+	ManifestFile = "shellify.manifest.json"
+
+	// SignatureFile holds the detached ed25519 signature over the manifest
+	SignatureFile = "shellify.manifest.sig"
+
+	manifestVersion = 1
+)
+
+var (
+	// ErrUnsigned is returned when a registry that must be signed has no signature
+	ErrUnsigned = errors.New("registry is not signed")
+
+	// ErrSignatureMismatch is returned when the signature or file hashes don't verify
+	ErrSignatureMismatch = errors.New("registry signature verification failed")
+)
+
+// Manifest maps every file of a registry to its sha256 hash
+type Manifest struct {
+	Version int               `json:"version"`
+	Files   map[string]string `json:"files"` // Slash separated path -> hex sha256
+}
+
+// BuildManifest hashes every file of a registry checkout, skipping git
+// metadata and the manifest and signature themselves
+func BuildManifest(repoPath string) (*Manifest, error) {
+	manifest := &Manifest{
+		Version: manifestVersion,
+		Files:   make(map[string]string),
+	}
+
+	err := filepath.WalkDir(repoPath, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(repoPath, path)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+
+		if entry.IsDir() {
+			if entry.Name() == ".git" {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if rel == ManifestFile || rel == SignatureFile {
+			return nil
+		}
+
+		hash, err := hashEntry(path, entry)
+		if err != nil {
+			return err
+		}
+		manifest.Files[rel] = hash
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to build manifest: %w", err)
+	}
+
+	return manifest, nil
+}
+
+// hashEntry returns the sha256 of a file, or of the target of a symlink
+func hashEntry(path string, entry fs.DirEntry) (string, error) {
+	hasher := sha256.New()
+
+	if entry.Type()&fs.ModeSymlink != 0 {
+		target, err := os.Readlink(path)
+		if err != nil {
+			return "", err
+		}
+		hasher.Write([]byte("symlink:" + target))
+		return hex.EncodeToString(hasher.Sum(nil)), nil
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	if _, err := io.Copy(hasher, file); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hasher.Sum(nil)), nil
+}
+
+// SignRegistry writes the manifest and its signature into a registry checkout
+func SignRegistry(repoPath string, key ed25519.PrivateKey) error {
+	manifest, err := BuildManifest(repoPath)
+	if err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal manifest: %w", err)
+	}
+	data = append(data, '\n')
+
+	signature := base64.StdEncoding.EncodeToString(ed25519.Sign(key, data)) + "\n"
+
+	if err := os.WriteFile(filepath.Join(repoPath, ManifestFile), data, 0644); err != nil {
+		return fmt.Errorf("failed to write manifest: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(repoPath, SignatureFile), []byte(signature), 0644); err != nil {
+		return fmt.Errorf("failed to write signature: %w", err)
+	}
+
+	return nil
+}
+
+// VerifyRegistry checks that the manifest is signed by one of the trusted
+// keys and that every file in the checkout matches it exactly
+func VerifyRegistry(repoPath string, keys []ed25519.PublicKey) error {
+	if len(keys) == 0 {
+		return fmt.Errorf("%w: no trusted keys are pinned for this registry", ErrSignatureMismatch)
+	}
+
+	data, err := os.ReadFile(filepath.Join(repoPath, ManifestFile))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return fmt.Errorf("%w: %s not found", ErrUnsigned, ManifestFile)
+		}
+		return fmt.Errorf("failed to read manifest: %w", err)
+	}
+
+	encoded, err := os.ReadFile(filepath.Join(repoPath, SignatureFile))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return fmt.Errorf("%w: %s not found", ErrUnsigned, SignatureFile)
+		}
+		return fmt.Errorf("failed to read signature: %w", err)
+	}
+
+	signature, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(encoded)))
+	if err != nil {
+		return fmt.Errorf("%w: malformed signature: %v", ErrSignatureMismatch, err)
+	}
+
+	verified := false
+	for _, key := range keys {
+		if ed25519.Verify(key, data, signature) {
+			verified = true
+			break
+		}
+	}
+	if !verified {
+		return fmt.Errorf("%w: manifest is not signed by a trusted key", ErrSignatureMismatch)
+	}
+
+	var signed Manifest
+	if err := json.Unmarshal(data, &signed); err != nil {
+		return fmt.Errorf("%w: malformed manifest: %v", ErrSignatureMismatch, err)
+	}
+
+	actual, err := BuildManifest(repoPath)
+	if err != nil {
+		return err
+	}
+
+	return compareManifests(&signed, actual)
+}
+
+// compareManifests reports the first file that differs between the signed
+// manifest and the files on disk
+func compareManifests(signed, actual *Manifest) error {
+	paths := make([]string, 0, len(signed.Files)+len(actual.Files))
+	for path := range signed.Files {
+		paths = append(paths, path)
+	}
+	for path := range actual.Files {
+		if _, ok := signed.Files[path]; !ok {
+			paths = append(paths, path)
+		}
+	}
+	sort.Strings(paths)
+
+	for _, path := range paths {
+		expected, listed := signed.Files[path]
+		hash, present := actual.Files[path]
+		switch {
+		case !listed:
+			return fmt.Errorf("%w: %s is not listed in the manifest", ErrSignatureMismatch, path)
+		case !present:
+			return fmt.Errorf("%w: %s is missing", ErrSignatureMismatch, path)
+		case hash != expected:
+			return fmt.Errorf("%w: %s does not match its signed hash", ErrSignatureMismatch, path)
+		}
+	}
+
+	return nil
+}
+
+// ParsePublicKey decodes a base64 encoded ed25519 public key
+func ParsePublicKey(encoded string) (ed25519.PublicKey, error) {
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+	if err != nil {
+		return nil, fmt.Errorf("invalid public key: %w", err)
+	}
+	if len(key) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("invalid public key: expected %d bytes, got %d", ed25519.PublicKeySize, len(key))
+	}
+	return ed25519.PublicKey(key), nil
+}
+
+// ParsePublicKeys decodes a list of base64 encoded ed25519 public keys
+func ParsePublicKeys(encoded []string) ([]ed25519.PublicKey, error) {
+	keys := make([]ed25519.PublicKey, 0, len(encoded))
+	for _, e := range encoded {
+		key, err := ParsePublicKey(e)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+// EncodePublicKey encodes an ed25519 public key for pinning
+func EncodePublicKey(key ed25519.PublicKey) string {
+	return base64.StdEncoding.EncodeToString(key)
+}
+
+// GenerateSigningKey creates an ed25519 key pair, writing the private key as
+// PKCS#8 PEM to path and returning the encoded public key
+func GenerateSigningKey(path string) (string, error) {
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return "", fmt.Errorf("failed to generate key: %w", err)
+	}
+
+	der, err := x509.MarshalPKCS8PrivateKey(private)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode private key: %w", err)
+	}
+
+	var buf bytes.Buffer
+	if err := pem.Encode(&buf, &pem.Block{Type: "PRIVATE KEY", Bytes: der}); err != nil {
+		return "", fmt.Errorf("failed to encode private key: %w", err)
+	}
+
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return "", fmt.Errorf("failed to create key file: %w", err)
+	}
+	defer file.Close()
+
+	if _, err := file.Write(buf.Bytes()); err != nil {
+		return "", fmt.Errorf("failed to write key file: %w", err)
+	}
+
+	return EncodePublicKey(public), nil
+}
+
+// LoadSigningKey reads a PKCS#8 PEM encoded ed25519 private key, as written
+// by GenerateSigningKey or `openssl genpkey -algorithm ed25519`
+func LoadSigningKey(path string) (ed25519.PrivateKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read key file: %w", err)
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("key file %s is not PEM encoded", path)
+	}
+
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse private key: %w", err)
+	}
+
+	key, ok := parsed.(ed25519.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("key file %s does not contain an ed25519 key", path)
+	}
+	return key, nil
+}
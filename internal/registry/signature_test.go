@@ -0,0 +1,223 @@
+package registry
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func newTestKey(t *testing.T) (ed25519.PublicKey, ed25519.PrivateKey) {
+	t.Helper()
+
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return public, private
+}
+
+func TestVerifyRegistry(t *testing.T) {
+	public, private := newTestKey(t)
+	otherPublic, _ := newTestKey(t)
+
+	tests := []struct {
+		name     string
+		modify   func(t *testing.T, dir string)
+		keys     []ed25519.PublicKey
+		expected error
+	}{
+		{
+			name:   "signed registry",
+			modify: func(t *testing.T, dir string) {},
+			keys:   []ed25519.PublicKey{otherPublic, public},
+		},
+		{
+			name:     "untrusted key",
+			modify:   func(t *testing.T, dir string) {},
+			keys:     []ed25519.PublicKey{otherPublic},
+			expected: ErrSignatureMismatch,
+		},
+		{
+			name: "modified file",
+			modify: func(t *testing.T, dir string) {
+				writeModuleJSON(t, dir, "rm -rf ~")
+			},
+			keys:     []ed25519.PublicKey{public},
+			expected: ErrSignatureMismatch,
+		},
+		{
+			name: "unlisted file",
+			modify: func(t *testing.T, dir string) {
+				if err := os.WriteFile(filepath.Join(dir, "modules", "git-helpers", "extra.sh"), []byte("echo"), 0644); err != nil {
+					t.Fatal(err)
+				}
+			},
+			keys:     []ed25519.PublicKey{public},
+			expected: ErrSignatureMismatch,
+		},
+		{
+			name: "missing file",
+			modify: func(t *testing.T, dir string) {
+				if err := os.Remove(filepath.Join(dir, "modules", "git-helpers", "module.json")); err != nil {
+					t.Fatal(err)
+				}
+			},
+			keys:     []ed25519.PublicKey{public},
+			expected: ErrSignatureMismatch,
+		},
+		{
+			name: "unsigned registry",
+			modify: func(t *testing.T, dir string) {
+				if err := os.Remove(filepath.Join(dir, SignatureFile)); err != nil {
+					t.Fatal(err)
+				}
+			},
+			keys:     []ed25519.PublicKey{public},
+			expected: ErrUnsigned,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			if err := createValidRegistry(dir); err != nil {
+				t.Fatal(err)
+			}
+			if err := SignRegistry(dir, private); err != nil {
+				t.Fatalf("SignRegistry() failed: %v", err)
+			}
+			tt.modify(t, dir)
+
+			err := VerifyRegistry(dir, tt.keys)
+			if tt.expected == nil && err != nil {
+				t.Errorf("VerifyRegistry() failed: %v", err)
+			}
+			if tt.expected != nil && !errors.Is(err, tt.expected) {
+				t.Errorf("VerifyRegistry() error = %v, expected %v", err, tt.expected)
+			}
+		})
+	}
+}
+
+func TestStructureValidatorRequiresSignature(t *testing.T) {
+	public, _ := newTestKey(t)
+
+	dir := t.TempDir()
+	if err := createValidRegistry(dir); err != nil {
+		t.Fatal(err)
+	}
+
+	validator := NewStructureValidator(dir)
+	if err := validator.ValidateStructure(); err != nil {
+		t.Fatalf("ValidateStructure() without verification failed: %v", err)
+	}
+
+	validator.RequireSignature([]ed25519.PublicKey{public})
+	if err := validator.ValidateStructure(); !errors.Is(err, ErrUnsigned) {
+		t.Errorf("ValidateStructure() error = %v, expected ErrUnsigned", err)
+	}
+}
+
+func TestSigningKeyRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "registry.key")
+
+	encoded, err := GenerateSigningKey(path)
+	if err != nil {
+		t.Fatalf("GenerateSigningKey() failed: %v", err)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("key file mode = %v, expected 0600", info.Mode().Perm())
+	}
+
+	private, err := LoadSigningKey(path)
+	if err != nil {
+		t.Fatalf("LoadSigningKey() failed: %v", err)
+	}
+	public, err := ParsePublicKey(encoded)
+	if err != nil {
+		t.Fatalf("ParsePublicKey() failed: %v", err)
+	}
+	if !public.Equal(private.Public()) {
+		t.Error("loaded private key does not match the returned public key")
+	}
+
+	if _, err := GenerateSigningKey(path); err == nil {
+		t.Error("GenerateSigningKey() should not overwrite an existing key")
+	}
+	if _, err := ParsePublicKey("c2hvcnQ="); err == nil {
+		t.Error("ParsePublicKey() should reject keys of the wrong size")
+	}
+}
+
+func TestSyncRejectsUnsignedUpdate(t *testing.T) {
+	public, private := newTestKey(t)
+
+	sourceDir := createSourceRepository(t)
+	if err := createValidRegistry(sourceDir); err != nil {
+		t.Fatal(err)
+	}
+	writeModuleJSON(t, sourceDir, "git clean -n")
+	if err := SignRegistry(sourceDir, private); err != nil {
+		t.Fatal(err)
+	}
+	commitAll(t, sourceDir, "signed")
+
+	configDir := t.TempDir()
+	client := &Client{configDir: configDir, gitClient: NewGitClient(filepath.Join(configDir, "cache"))}
+	ctx := context.Background()
+
+	reg := Registry{URL: "file://" + sourceDir, Name: "test", TrustedKeys: []string{EncodePublicKey(public)}}
+	if err := client.AddRegistry(ctx, reg); err != nil {
+		t.Fatalf("AddRegistry() failed: %v", err)
+	}
+
+	// An update that wasn't re-signed is refused and the verified content kept
+	writeModuleJSON(t, sourceDir, "git clean -fdx")
+	commitAll(t, sourceDir, "unsigned update")
+
+	if err := client.SyncRegistry(ctx, "test"); !errors.Is(err, ErrSignatureMismatch) {
+		t.Fatalf("SyncRegistry() error = %v, expected ErrSignatureMismatch", err)
+	}
+
+	module, err := LoadModule(client.RepositoryPath("test"), Module{Name: "git-helpers", Path: "modules/git-helpers"})
+	if err != nil {
+		t.Fatalf("LoadModule() failed: %v", err)
+	}
+	if got := module.Functions[0].Commands[0]; got != "git clean -n" {
+		t.Errorf("cached function = %q, expected the verified body", got)
+	}
+
+	if err := SignRegistry(sourceDir, private); err != nil {
+		t.Fatal(err)
+	}
+	commitAll(t, sourceDir, "re-signed")
+
+	if err := client.SyncRegistry(ctx, "test"); err != nil {
+		t.Errorf("SyncRegistry() of a signed update failed: %v", err)
+	}
+}
+
+// writeModuleJSON writes the git-helpers module with a single function
+// without committing it
+func writeModuleJSON(t *testing.T, dir, body string) {
+	t.Helper()
+
+	moduleConfig := map[string]interface{}{
+		"name":        "git-helpers",
+		"description": "Git helper functions",
+		"type":        "functions",
+		"functions":   []map[string]interface{}{{"name": "gclean", "commands": []string{body}}},
+	}
+	if err := writeJSON(filepath.Join(dir, "modules", "git-helpers", "module.json"), moduleConfig); err != nil {
+		t.Fatal(err)
+	}
+}
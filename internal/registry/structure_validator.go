@@ -1,6 +1,7 @@
 package registry
 
 import (
+	"crypto/ed25519"
 	"encoding/json"
 	"fmt"
 	"os"
@@ -8,12 +9,15 @@ import (
 	"regexp"
 	"strings"
 
+	"github.com/griffin/go-shellify/internal/condition"
 	"github.com/griffin/go-shellify/internal/logger"
 )
 
 // StructureValidator validates registry structure and content
 type StructureValidator struct {
-	repoPath string
+	repoPath         string
+	requireSignature bool
+	trustedKeys      []ed25519.PublicKey
 }
 
 // NewStructureValidator creates a new structure validator
@@ -23,10 +27,24 @@ func NewStructureValidator(repoPath string) *StructureValidator {
 	}
 }
 
+// RequireSignature makes validation fail unless the registry is signed by
+// one of the trusted keys and its files match the signed manifest
+func (sv *StructureValidator) RequireSignature(keys []ed25519.PublicKey) {
+	sv.requireSignature = true
+	sv.trustedKeys = keys
+}
+
 // ValidateStructure performs comprehensive registry structure validation
 func (sv *StructureValidator) ValidateStructure() error {
 	logger.Debug("Starting comprehensive registry structure validation for: %s", sv.repoPath)
 
+	// Step 0: Verify the signature before trusting any content
+	if sv.requireSignature {
+		if err := VerifyRegistry(sv.repoPath, sv.trustedKeys); err != nil {
+			return err
+		}
+	}
+
 	// Step 1: Validate index.json structure and content
 	index, err := sv.validateIndexJSON()
 	if err != nil {
@@ -187,6 +205,11 @@ func (sv *StructureValidator) validateSingleModule(moduleKey string, module Modu
 		return fmt.Errorf("module path validation failed: %w", err)
 	}
 
+	// Validate module contents against the published checksum
+	if err := VerifyModuleChecksum(sv.repoPath, module); err != nil {
+		return err
+	}
+
 	logger.Debug("Module '%s' validation passed", module.Name)
 	return nil
 }
@@ -219,10 +242,16 @@ func (sv *StructureValidator) validateShell(shell string) error {
 		"fish":       true,
 		"powershell": true,
 		"sh":         true,
+		"nu":         true,
+		"elvish":     true,
+		"xonsh":      true,
+		"tcsh":       true,
+		"csh":        true,
+		"cmd":        true,
 	}
 
 	if !supportedShells[strings.ToLower(shell)] {
-		return fmt.Errorf("unsupported shell '%s', supported shells: bash, zsh, fish, powershell, sh", shell)
+		return fmt.Errorf("unsupported shell '%s', supported shells: bash, zsh, fish, powershell, sh, nu, elvish, xonsh, tcsh, csh, cmd", shell)
 	}
 
 	return nil
@@ -280,6 +309,34 @@ func (sv *StructureValidator) validateModuleJSON(moduleJsonPath string) error {
 		return fmt.Errorf("type field must be a string")
 	}
 
+	// Validate shell version constraints
+	if raw, exists := moduleConfig["shell_versions"]; exists {
+		constraints, ok := raw.([]interface{})
+		if !ok {
+			return fmt.Errorf("shell_versions field must be a list")
+		}
+		for _, constraint := range constraints {
+			text, ok := constraint.(string)
+			if !ok {
+				return fmt.Errorf("shell_versions entries must be strings")
+			}
+			if _, err := ParseShellVersion(text); err != nil {
+				return err
+			}
+		}
+	}
+
+	// Validate when conditions
+	var module Module
+	if err := json.Unmarshal(data, &module); err != nil {
+		return fmt.Errorf("invalid module fields: %w", err)
+	}
+	for _, when := range Conditions(module) {
+		if _, err := condition.Parse(when); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
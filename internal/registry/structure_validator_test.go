@@ -198,7 +198,7 @@ func TestStructureValidator_ValidateShell(t *testing.T) {
 		},
 		{
 			name:    "invalid shell - unsupported",
-			shell:   "tcsh",
+			shell:   "ksh",
 			wantErr: true,
 		},
 		{
@@ -315,6 +315,23 @@ func TestStructureValidator_ValidateStructure(t *testing.T) {
 			wantErr: true,
 			errMsg:  "version format",
 		},
+		{
+			name: "invalid when condition",
+			setupFunc: func(dir string) error {
+				if err := createValidRegistry(dir); err != nil {
+					return err
+				}
+				moduleConfig := map[string]interface{}{
+					"name":        "git-helpers",
+					"description": "Git helper functions",
+					"type":        "aliases",
+					"aliases":     []map[string]string{{"name": "k", "command": "kubectl", "when": `command("kubectl") &&`}},
+				}
+				return writeJSON(filepath.Join(dir, "modules", "git-helpers", "module.json"), moduleConfig)
+			},
+			wantErr: true,
+			errMsg:  "invalid condition",
+		},
 	}
 
 	for _, tt := range tests {
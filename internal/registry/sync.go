@@ -0,0 +1,76 @@
+package registry
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// ErrSyncInProgress is returned when another process is already syncing registries
+var ErrSyncInProgress = errors.New("another registry sync is in progress")
+
+// staleSyncLockAge is the age after which a sync lock is assumed to belong
+// to a process that died without releasing it
+const staleSyncLockAge = 30 * time.Minute
+
+// IsStale reports whether the registry was last synced longer ago than ttl
+func (r Registry) IsStale(ttl time.Duration) bool {
+	return r.CacheAge() > ttl
+}
+
+// StaleRegistries returns the registries last synced longer ago than ttl
+func (c *Client) StaleRegistries(ttl time.Duration) []Registry {
+	var stale []Registry
+	for _, reg := range c.registries {
+		if reg.IsStale(ttl) {
+			stale = append(stale, reg)
+		}
+	}
+	return stale
+}
+
+// LockSync takes the lock that serializes syncs between processes, so a
+// background sync and a foreground one never update the same clone at once.
+// The returned function releases the lock.
+func (c *Client) LockSync() (func(), error) {
+	lockPath := c.syncLockPath()
+
+	for attempt := 0; attempt < 2; attempt++ {
+		file, err := os.OpenFile(lockPath, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+		if err == nil {
+			fmt.Fprintf(file, "%d\n", os.Getpid())
+			file.Close()
+			return func() { os.Remove(lockPath) }, nil
+		}
+		if !os.IsExist(err) {
+			return nil, fmt.Errorf("failed to create sync lock: %w", err)
+		}
+
+		// Break locks left behind by processes that were killed
+		info, statErr := os.Stat(lockPath)
+		if statErr != nil || time.Since(info.ModTime()) < staleSyncLockAge {
+			break
+		}
+		os.Remove(lockPath)
+	}
+
+	return nil, ErrSyncInProgress
+}
+
+// SyncInProgress reports whether another process holds the sync lock
+func (c *Client) SyncInProgress() bool {
+	info, err := os.Stat(c.syncLockPath())
+	return err == nil && time.Since(info.ModTime()) < staleSyncLockAge
+}
+
+// SyncLogPath returns the file background syncs write their output to
+func (c *Client) SyncLogPath() string {
+	return filepath.Join(c.configDir, "sync.log")
+}
+
+// syncLockPath returns the path of the sync lock file
+func (c *Client) syncLockPath() string {
+	return filepath.Join(c.configDir, "sync.lock")
+}
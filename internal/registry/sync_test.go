@@ -0,0 +1,94 @@
+package registry
+
+import (
+	"errors"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestStaleRegistries(t *testing.T) {
+	now := time.Now()
+	client := &Client{
+		registries: []Registry{
+			{Name: "fresh", AddedAt: now.Add(-72 * time.Hour), LastSync: now.Add(-time.Hour)},
+			{Name: "stale", AddedAt: now.Add(-72 * time.Hour), LastSync: now.Add(-48 * time.Hour)},
+			{Name: "never-synced", AddedAt: now.Add(-72 * time.Hour)},
+		},
+	}
+
+	stale := client.StaleRegistries(24 * time.Hour)
+	if len(stale) != 2 {
+		t.Fatalf("StaleRegistries() returned %d registries, expected 2", len(stale))
+	}
+	if stale[0].Name != "stale" || stale[1].Name != "never-synced" {
+		t.Errorf("StaleRegistries() = %s, %s, expected stale, never-synced", stale[0].Name, stale[1].Name)
+	}
+}
+
+func TestLockSync(t *testing.T) {
+	client := &Client{configDir: t.TempDir()}
+
+	release, err := client.LockSync()
+	if err != nil {
+		t.Fatalf("LockSync() failed: %v", err)
+	}
+	if !client.SyncInProgress() {
+		t.Error("SyncInProgress() = false while the lock is held")
+	}
+
+	if _, err := client.LockSync(); !errors.Is(err, ErrSyncInProgress) {
+		t.Errorf("second LockSync() error = %v, expected ErrSyncInProgress", err)
+	}
+
+	release()
+	if client.SyncInProgress() {
+		t.Error("SyncInProgress() = true after release")
+	}
+
+	// Locks left behind by a killed process are broken once they are old enough
+	release, err = client.LockSync()
+	if err != nil {
+		t.Fatalf("LockSync() after release failed: %v", err)
+	}
+	old := time.Now().Add(-2 * staleSyncLockAge)
+	if err := os.Chtimes(client.syncLockPath(), old, old); err != nil {
+		t.Fatal(err)
+	}
+
+	release, err = client.LockSync()
+	if err != nil {
+		t.Fatalf("LockSync() with a stale lock failed: %v", err)
+	}
+	release()
+}
+
+func TestMarkSyncedKeepsConcurrentChanges(t *testing.T) {
+	configDir := t.TempDir()
+
+	first := &Client{configDir: configDir, registries: []Registry{{Name: "one"}}}
+	if err := first.saveRegistries(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Another process adds a registry after this client loaded the list
+	second := &Client{configDir: configDir, registries: []Registry{{Name: "one"}, {Name: "two"}}}
+	if err := second.saveRegistries(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := first.markSynced("one"); err != nil {
+		t.Fatalf("markSynced() failed: %v", err)
+	}
+
+	reloaded := &Client{configDir: configDir}
+	if err := reloaded.loadRegistries(); err != nil {
+		t.Fatal(err)
+	}
+	if len(reloaded.registries) != 2 {
+		t.Fatalf("expected 2 registries after markSynced, got %d", len(reloaded.registries))
+	}
+	if reloaded.registries[0].LastSync.IsZero() {
+		t.Error("expected LastSync to be recorded")
+	}
+}
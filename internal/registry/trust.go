@@ -0,0 +1,297 @@
+package registry
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+
+	"github.com/griffin/go-shellify/internal/logger"
+)
+
+// PendingApproval describes changes to an enabled module that are held back
+// until the user approves them
+type PendingApproval struct {
+	Registry  string       `json:"registry"`
+	Module    string       `json:"module"` // Index key of the module
+	Functions []ItemChange `json:"functions,omitempty"`
+	Aliases   []ItemChange `json:"aliases,omitempty"`
+	Files     []ItemChange `json:"files,omitempty"`
+	Checks    []ItemChange `json:"checks,omitempty"`
+}
+
+// IsEmpty reports whether the module has no changes awaiting approval
+func (p *PendingApproval) IsEmpty() bool {
+	return len(p.Functions) == 0 && len(p.Aliases) == 0 && len(p.Files) == 0 && len(p.Checks) == 0
+}
+
+// SetTrust enables trust mode for the modules selected by enabled. Changes
+// to the code those modules run are held back until approved. A nil
+// function disables trust mode.
+func (c *Client) SetTrust(enabled func(module string) bool) {
+	c.trusted = enabled
+}
+
+// ResolveModule returns the definition of a module that should be used,
+// which in trust mode is the last approved snapshot while newer content is
+// awaiting approval. The returned flag reports whether changes are pending.
+//
+// Snapshots are keyed by the index key of the module, as module.json can
+// give it another name. In trust mode the files of the module are read into
+// their content, so the script is generated from the approved bytes instead
+// of sourcing the cache.
+func (c *Client) ResolveModule(registryName, key string, entry Module) (Module, bool, error) {
+	repoPath := c.gitClient.GetRepositoryPath(registryName)
+	current, err := LoadModule(repoPath, entry)
+	if err != nil {
+		return current, false, err
+	}
+
+	if c.trusted == nil || !c.trusted(key) {
+		return current, false, nil
+	}
+
+	if current, err = withFileContents(repoPath, current); err != nil {
+		return current, false, err
+	}
+
+	approved, err := c.approvedModules(registryName)
+	if err != nil {
+		return current, false, err
+	}
+
+	snapshot, ok := approved[key]
+	if !ok {
+		// Enabling a module accepts the content it had at that point
+		approved[key] = current
+		if err := c.saveApprovedModules(registryName, approved); err != nil {
+			return current, false, err
+		}
+		logger.Info("Approved module '%s' from registry %s as first seen in trust mode", key, registryName)
+		return current, false, nil
+	}
+
+	if reviewableChanges(registryName, key, snapshot, current).IsEmpty() {
+		return current, false, nil
+	}
+
+	// Snapshots approved before file contents were recorded would source
+	// the changed file from the cache, so leave those files out
+	files := snapshot.Files[:0:0]
+	for _, file := range snapshot.Files {
+		if file.Content == "" && !filepath.IsAbs(file.Path) {
+			logger.Warn("Leaving out file %s of module '%s' until its changes are approved", file.Path, key)
+			continue
+		}
+		files = append(files, file)
+	}
+	snapshot.Files = files
+
+	return snapshot, true, nil
+}
+
+// PendingApprovals lists the enabled modules of a registry whose code
+// changed since it was last approved
+func (c *Client) PendingApprovals(registryName string) ([]PendingApproval, error) {
+	if c.trusted == nil {
+		return nil, nil
+	}
+
+	repoPath := c.gitClient.GetRepositoryPath(registryName)
+	current, err := LoadModules(repoPath)
+	if err != nil {
+		return nil, err
+	}
+
+	approved, err := c.approvedModules(registryName)
+	if err != nil {
+		return nil, err
+	}
+
+	var pending []PendingApproval
+	for _, name := range sortedModuleNames(current) {
+		snapshot, ok := approved[name]
+		if !ok || !c.trusted(name) {
+			continue
+		}
+		if current[name], err = withFileContents(repoPath, current[name]); err != nil {
+			return nil, err
+		}
+		if changes := reviewableChanges(registryName, name, snapshot, current[name]); !changes.IsEmpty() {
+			pending = append(pending, *changes)
+		}
+	}
+
+	return pending, nil
+}
+
+// Approve accepts the current content of modules with pending changes.
+// The changes must be the ones shown to the user: when a sync changed a
+// module since, nothing is approved and the module has to be reviewed again.
+func (c *Client) Approve(registryName string, changes []PendingApproval) error {
+	repoPath := c.gitClient.GetRepositoryPath(registryName)
+	current, err := LoadModules(repoPath)
+	if err != nil {
+		return err
+	}
+
+	approved, err := c.approvedModules(registryName)
+	if err != nil {
+		return err
+	}
+
+	for _, change := range changes {
+		module, ok := current[change.Module]
+		if !ok {
+			return fmt.Errorf("module not found in registry %s: %s", registryName, change.Module)
+		}
+		if module, err = withFileContents(repoPath, module); err != nil {
+			return err
+		}
+		if !reflect.DeepEqual(*reviewableChanges(registryName, change.Module, approved[change.Module], module), change) {
+			return fmt.Errorf("module %s changed since its changes were shown, review them again", change.Module)
+		}
+		approved[change.Module] = module
+	}
+
+	return c.saveApprovedModules(registryName, approved)
+}
+
+// snapshotEnabledModules records the pre-sync content of enabled modules
+// that have no approved snapshot yet, so a sync can't change code that was
+// in use before trust mode knew about it
+func (c *Client) snapshotEnabledModules(registryName string, previous map[string]Module) {
+	if c.trusted == nil {
+		return
+	}
+
+	approved, err := c.approvedModules(registryName)
+	if err != nil {
+		logger.Warn("Failed to read approved modules of registry %s: %v", registryName, err)
+		return
+	}
+
+	changed := false
+	for name, module := range previous {
+		if _, ok := approved[name]; !ok && c.trusted(name) {
+			module, err := withFileContents(c.gitClient.GetRepositoryPath(registryName), module)
+			if err != nil {
+				logger.Warn("Failed to snapshot module %s of registry %s: %v", name, registryName, err)
+				continue
+			}
+			approved[name] = module
+			changed = true
+		}
+	}
+
+	if changed {
+		if err := c.saveApprovedModules(registryName, approved); err != nil {
+			logger.Warn("Failed to record approved modules of registry %s: %v", registryName, err)
+		}
+	}
+}
+
+// reviewableChanges compares the parts of a module that run code
+func reviewableChanges(registryName, key string, approved, current Module) *PendingApproval {
+	return &PendingApproval{
+		Registry:  registryName,
+		Module:    key,
+		Functions: diffItems(functionBodies(approved.Functions), functionBodies(current.Functions)),
+		Aliases:   diffItems(aliasCommands(approved.Aliases), aliasCommands(current.Aliases)),
+		Files:     diffItems(fileBodies(approved.Files), fileBodies(current.Files)),
+		Checks:    diffItems(checkBodies(approved.Checks), checkBodies(current.Checks)),
+	}
+}
+
+// withFileContents returns a module whose files without inline content carry
+// the bytes of the file in the module directory instead
+func withFileContents(repoPath string, module Module) (Module, error) {
+	files := make([]File, len(module.Files))
+	for i, file := range module.Files {
+		if file.Content == "" && !filepath.IsAbs(file.Path) {
+			data, err := os.ReadFile(filepath.Join(repoPath, module.Path, file.Path))
+			if err != nil {
+				return module, fmt.Errorf("failed to read file %s of module %s: %w", file.Path, module.Name, err)
+			}
+			file.Content = string(data)
+			if file.Content == "" {
+				// Keep an empty file inline rather than sourcing it later
+				file.Content = "\n"
+			}
+		}
+		files[i] = file
+	}
+	module.Files = files
+	return module, nil
+}
+
+// fileBodies maps file paths to their attributes and content
+func fileBodies(files []File) map[string]string {
+	bodies := make(map[string]string, len(files))
+	for _, file := range files {
+		bodies[file.Path] = fmt.Sprintf("source=%t execute=%t mode=%s\n%s", file.Source, file.Execute, file.Mode, file.Content)
+	}
+	return bodies
+}
+
+// checkBodies maps check names to what they test and run
+func checkBodies(checks []Check) map[string]string {
+	bodies := make(map[string]string, len(checks))
+	for _, check := range checks {
+		lines := []string{
+			fmt.Sprintf("type=%s command=%s path=%s variable=%s expected=%s", check.Type, check.Command, check.Path, check.Variable, check.Expected),
+		}
+		for _, line := range check.OnSuccess {
+			lines = append(lines, "on_success: "+line)
+		}
+		for _, line := range check.OnFailure {
+			lines = append(lines, "on_failure: "+line)
+		}
+		bodies[check.Name] = strings.Join(lines, "\n")
+	}
+	return bodies
+}
+
+// approvedModules loads the approved module snapshots of a registry
+func (c *Client) approvedModules(registryName string) (map[string]Module, error) {
+	approved := make(map[string]Module)
+
+	data, err := os.ReadFile(c.approvedPath(registryName))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return approved, nil
+		}
+		return nil, fmt.Errorf("failed to read approved modules: %w", err)
+	}
+
+	if err := json.Unmarshal(data, &approved); err != nil {
+		return nil, fmt.Errorf("failed to parse approved modules: %w", err)
+	}
+
+	return approved, nil
+}
+
+// saveApprovedModules stores the approved module snapshots of a registry
+func (c *Client) saveApprovedModules(registryName string, approved map[string]Module) error {
+	if err := os.MkdirAll(filepath.Dir(c.approvedPath(registryName)), 0755); err != nil {
+		return fmt.Errorf("failed to create approved modules directory: %w", err)
+	}
+
+	data, err := json.MarshalIndent(approved, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal approved modules: %w", err)
+	}
+
+	if err := os.WriteFile(c.approvedPath(registryName), data, 0644); err != nil {
+		return fmt.Errorf("failed to write approved modules: %w", err)
+	}
+
+	return nil
+}
+
+// approvedPath returns the file a registry's approved snapshots are stored in
+func (c *Client) approvedPath(registryName string) string {
+	return filepath.Join(c.configDir, "approved", registryName+".json")
+}
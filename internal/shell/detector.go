@@ -1,20 +1,27 @@
 package shell
 
 import (
+	"context"
 	"fmt"
 	"os"
+	"os/exec"
 	"path/filepath"
 	"runtime"
 	"strings"
+	"time"
 )
 
-// Detect automatically detects the current shell
+// Detect automatically detects the current shell, see DetectShell
 func Detect() (string, error) {
-	// Check environment variables first
-	if shell := os.Getenv("SHELL"); shell != "" {
-		return detectFromPath(shell), nil
+	info, err := DetectShell()
+	if err != nil {
+		return "", err
 	}
+	return string(info.Type), nil
+}
 
+// defaultShell returns the usual shell of the platform
+func defaultShell() (string, error) {
 	// Windows-specific detection
 	if runtime.GOOS == "windows" {
 		if psModulePath := os.Getenv("PSModulePath"); psModulePath != "" {
@@ -23,11 +30,6 @@ func Detect() (string, error) {
 		return string(Cmd), nil
 	}
 
-	// Unix-like systems
-	if parent := getParentProcess(); parent != "" {
-		return detectFromPath(parent), nil
-	}
-
 	// Fallback to common shells based on OS
 	switch runtime.GOOS {
 	case "darwin":
@@ -53,6 +55,16 @@ func detectFromPath(path string) string {
 		return string(Fish)
 	case "powershell", "pwsh":
 		return string(PowerShell)
+	case "nu":
+		return string(Nu)
+	case "elvish":
+		return string(Elvish)
+	case "xonsh":
+		return string(Xonsh)
+	case "tcsh":
+		return string(Tcsh)
+	case "csh":
+		return string(Csh)
 	case "cmd":
 		return string(Cmd)
 	default:
@@ -71,27 +83,6 @@ func detectFromPath(path string) string {
 	}
 }
 
-// getParentProcess attempts to get the parent process name
-func getParentProcess() string {
-	// This is a simplified implementation
-	// In a production system, you might want to use more sophisticated process detection
-
-	if runtime.GOOS == "windows" {
-		return ""
-	}
-
-	// Try to read from /proc/self/stat on Linux
-	if data, err := os.ReadFile("/proc/self/stat"); err == nil {
-		fields := strings.Fields(string(data))
-		if len(fields) > 3 {
-			// This is a simplified parsing - in reality, you'd want more robust parsing
-			return fields[1]
-		}
-	}
-
-	return ""
-}
-
 // GetConfigPath returns the appropriate config path for the shell
 func GetConfigPath(shellType string) (string, error) {
 	homeDir, err := os.UserHomeDir()
@@ -136,6 +127,40 @@ func GetConfigPath(shellType string) (string, error) {
 		}
 		return filepath.Join(configDir, "Microsoft.PowerShell_profile.ps1"), nil
 
+	case Nu:
+		return nuConfigPath()
+
+	case Elvish:
+		return elvishConfigPath(homeDir)
+
+	case Xonsh:
+		// ~/.xonshrc is read along with the XDG rc.xsh, prefer an existing one
+		xonshrc := filepath.Join(homeDir, ".xonshrc")
+		if _, err := os.Stat(xonshrc); err == nil {
+			return xonshrc, nil
+		}
+		rcXsh := filepath.Join(xdgConfigHome(homeDir), "xonsh", "rc.xsh")
+		if _, err := os.Stat(rcXsh); err == nil {
+			return rcXsh, nil
+		}
+		return xonshrc, nil
+
+	case Tcsh:
+		// tcsh only reads ~/.cshrc when there is no ~/.tcshrc, so don't
+		// create one next to an existing ~/.cshrc
+		tcshrc := filepath.Join(homeDir, ".tcshrc")
+		if _, err := os.Stat(tcshrc); err == nil {
+			return tcshrc, nil
+		}
+		cshrc := filepath.Join(homeDir, ".cshrc")
+		if _, err := os.Stat(cshrc); err == nil {
+			return cshrc, nil
+		}
+		return tcshrc, nil
+
+	case Csh:
+		return filepath.Join(homeDir, ".cshrc"), nil
+
 	case Cmd:
 		// Windows Command Prompt doesn't have a standard config file
 		return "", fmt.Errorf("cmd shell doesn't support configuration files")
@@ -143,4 +168,60 @@ func GetConfigPath(shellType string) (string, error) {
 	default:
 		return "", fmt.Errorf("unsupported shell type: %s", shellType)
 	}
-}
\ No newline at end of file
+}
+
+// nuConfigPath returns Nushell's config.nu. nu itself is asked for
+// $nu.config-path when installed, as the location depends on its version and
+// XDG_CONFIG_HOME; otherwise the default under the user config directory is used.
+func nuConfigPath() (string, error) {
+	if nu := lookupShell(string(Nu)); nu != "" {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		output, err := exec.CommandContext(ctx, nu, "--no-config-file", "--commands", "$nu.config-path").Output()
+		if path := strings.TrimSpace(string(output)); err == nil && filepath.IsAbs(path) {
+			return path, nil
+		}
+	}
+
+	configDir, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("getting config directory: %w", err)
+	}
+	configDir = filepath.Join(configDir, "nushell")
+	if err := os.MkdirAll(configDir, 0755); err != nil {
+		return "", fmt.Errorf("creating Nushell config directory: %w", err)
+	}
+	return filepath.Join(configDir, "config.nu"), nil
+}
+
+// elvishConfigPath returns Elvish's rc.elv. Elvish before 0.17 read
+// ~/.elvish/rc.elv, which is kept when it exists.
+func elvishConfigPath(homeDir string) (string, error) {
+	legacy := filepath.Join(homeDir, ".elvish", "rc.elv")
+	if _, err := os.Stat(legacy); err == nil {
+		return legacy, nil
+	}
+
+	configDir := filepath.Join(xdgConfigHome(homeDir), "elvish")
+	if runtime.GOOS == "windows" {
+		appData, err := os.UserConfigDir()
+		if err != nil {
+			return "", fmt.Errorf("getting config directory: %w", err)
+		}
+		configDir = filepath.Join(appData, "elvish")
+	}
+	if err := os.MkdirAll(configDir, 0755); err != nil {
+		return "", fmt.Errorf("creating Elvish config directory: %w", err)
+	}
+	return filepath.Join(configDir, "rc.elv"), nil
+}
+
+// xdgConfigHome returns $XDG_CONFIG_HOME, or ~/.config when it isn't set.
+// Unlike os.UserConfigDir it is the same on macOS, as Elvish and xonsh expect.
+func xdgConfigHome(homeDir string) string {
+	if dir := os.Getenv("XDG_CONFIG_HOME"); filepath.IsAbs(dir) {
+		return dir
+	}
+	return filepath.Join(homeDir, ".config")
+}
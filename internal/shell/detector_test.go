@@ -2,6 +2,7 @@ package shell
 
 import (
 	"os"
+	"path/filepath"
 	"runtime"
 	"strings"
 	"testing"
@@ -21,6 +22,9 @@ func TestDetectFromPath(t *testing.T) {
 		{"/usr/bin/unknown", "bash"}, // fallback
 		{"powershell.exe", "powershell"},
 		{"pwsh", "powershell"},
+		{"/usr/bin/nu", "nu"},
+		{"/bin/tcsh", "tcsh"},
+		{"/bin/csh", "csh"},
 	}
 
 	for _, tt := range tests {
@@ -42,7 +46,12 @@ func TestIsSupported(t *testing.T) {
 		{"zsh", true},
 		{"fish", true},
 		{"powershell", true},
-		{"cmd", false},
+		{"nu", true},
+		{"elvish", true},
+		{"xonsh", true},
+		{"tcsh", true},
+		{"csh", true},
+		{"cmd", true},
 		{"unknown", false},
 		{"", false},
 	}
@@ -66,6 +75,11 @@ func TestGetFileExtension(t *testing.T) {
 		{"zsh", ".sh"},
 		{"fish", ".fish"},
 		{"powershell", ".ps1"},
+		{"nu", ".nu"},
+		{"elvish", ".elv"},
+		{"xonsh", ".xsh"},
+		{"tcsh", ".csh"},
+		{"cmd", ".cmd"},
 		{"unknown", ".sh"}, // fallback
 	}
 
@@ -84,7 +98,13 @@ func TestDetect(t *testing.T) {
 	originalShell := os.Getenv("SHELL")
 	originalPSModulePath := os.Getenv("PSModulePath")
 	
+	// Detect from the environment only, the test runner's process tree varies
+	originalProcRoot := procRoot
+	procRoot = t.TempDir()
+
 	defer func() {
+		procRoot = originalProcRoot
+
 		// Restore original environment
 		if originalShell != "" {
 			os.Setenv("SHELL", originalShell)
@@ -171,6 +191,8 @@ func TestGetConfigPath(t *testing.T) {
 		{"zsh", false, ".zshrc"},
 		{"fish", false, "config.fish"},
 		{"powershell", false, "PowerShell"},
+		{"csh", false, ".cshrc"},
+		{"xonsh", false, "xonsh"},
 		{"cmd", true, ""},
 		{"unknown", true, ""},
 	}
@@ -200,4 +222,28 @@ func TestGetConfigPath(t *testing.T) {
 			}
 		})
 	}
-}
\ No newline at end of file
+}
+func TestElvishConfigPath(t *testing.T) {
+	home := t.TempDir()
+	config := filepath.Join(home, "config")
+	t.Setenv("XDG_CONFIG_HOME", config)
+
+	path, err := elvishConfigPath(home)
+	if err != nil {
+		t.Fatalf("elvishConfigPath() failed: %v", err)
+	}
+	if expected := filepath.Join(config, "elvish", "rc.elv"); runtime.GOOS != "windows" && path != expected {
+		t.Errorf("elvishConfigPath() = %s, expected %s", path, expected)
+	}
+
+	legacy := filepath.Join(home, ".elvish", "rc.elv")
+	if err := os.MkdirAll(filepath.Dir(legacy), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(legacy, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if path, _ := elvishConfigPath(home); path != legacy {
+		t.Errorf("elvishConfigPath() = %s, expected the existing %s", path, legacy)
+	}
+}
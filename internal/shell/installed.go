@@ -0,0 +1,57 @@
+package shell
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// etcShells lists the login shells of the system
+var etcShells = "/etc/shells"
+
+// generationShells are the shells scripts can be generated for, in the
+// order they are reported. csh is usually tcsh under another name and is only
+// generated for when listed explicitly.
+var generationShells = []ShellType{Bash, Zsh, Fish, PowerShell, Nu, Elvish, Xonsh, Tcsh, Cmd}
+
+// Installed returns the supported shells installed on the system, from
+// /etc/shells first and then $PATH. Shells listed in /etc/shells but missing
+// from disk are left out.
+func Installed() []Info {
+	found := make(map[ShellType]string)
+
+	if file, err := os.Open(etcShells); err == nil {
+		scanner := bufio.NewScanner(file)
+		for scanner.Scan() {
+			path := strings.TrimSpace(scanner.Text())
+			if path == "" || strings.HasPrefix(path, "#") {
+				continue
+			}
+			shellType, ok := processShells[filepath.Base(path)]
+			if !ok || found[shellType] != "" || !isExecutable(path) {
+				continue
+			}
+			found[shellType] = path
+		}
+		file.Close()
+	}
+
+	var installed []Info
+	for _, shellType := range generationShells {
+		path := found[shellType]
+		if path == "" {
+			path = lookupShell(string(shellType))
+		}
+		if path != "" {
+			installed = append(installed, Info{Type: shellType, Path: path})
+		}
+	}
+	return installed
+}
+
+// isExecutable reports whether a path is an executable file
+func isExecutable(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && !info.IsDir() && info.Mode()&0111 != 0
+}
@@ -0,0 +1,50 @@
+package shell
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestInstalled(t *testing.T) {
+	dir := t.TempDir()
+	bin := filepath.Join(dir, "bin")
+	if err := os.MkdirAll(bin, 0755); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"zsh", "fish", "dash"} {
+		if err := os.WriteFile(filepath.Join(bin, name), []byte("#!/bin/sh\n"), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// On $PATH only, found after /etc/shells
+	pathDir := filepath.Join(dir, "path")
+	if err := os.MkdirAll(pathDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(pathDir, "pwsh"), []byte("#!/bin/sh\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	shells := fmt.Sprintf("# comment\n%s/zsh\n%s/dash\n/nonexistent/bash\n\n%s/fish\n", bin, bin, bin)
+	etc := filepath.Join(dir, "shells")
+	if err := os.WriteFile(etc, []byte(shells), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	original := etcShells
+	etcShells = etc
+	defer func() { etcShells = original }()
+	t.Setenv("PATH", pathDir)
+
+	expected := []Info{
+		{Type: Zsh, Path: filepath.Join(bin, "zsh")},
+		{Type: Fish, Path: filepath.Join(bin, "fish")},
+		{Type: PowerShell, Path: filepath.Join(pathDir, "pwsh")},
+	}
+	installed := Installed()
+	if fmt.Sprint(installed) != fmt.Sprint(expected) {
+		t.Errorf("Installed() = %v, expected %v", installed, expected)
+	}
+}
@@ -0,0 +1,194 @@
+package shell
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Info describes a detected shell
+type Info struct {
+	Type    ShellType
+	Path    string // Executable, empty when unknown
+	Version string // e.g. "5.2.15", empty when unknown
+}
+
+// procRoot is where the process tree is read from
+var procRoot = "/proc"
+
+// maxProcessDepth bounds the walk up the process tree
+const maxProcessDepth = 32
+
+// processShells maps executable names to the shells they run
+var processShells = map[string]ShellType{
+	"bash":           Bash,
+	"zsh":            Zsh,
+	"fish":           Fish,
+	"pwsh":           PowerShell,
+	"powershell":     PowerShell,
+	"pwsh-preview":   PowerShell,
+	"powershell.exe": PowerShell,
+	"nu":             Nu,
+	"nu.exe":         Nu,
+	"elvish":         Elvish,
+	"elvish.exe":     Elvish,
+	"xonsh":          Xonsh,
+	"tcsh":           Tcsh,
+	"csh":            Csh,
+}
+
+// processWrappers are programs commonly found between a shell and the
+// commands it runs. They are skipped when walking up the process tree. sh and
+// dash usually run scripts and `sh -c` glue rather than an interactive session.
+var processWrappers = map[string]bool{
+	"sudo": true, "su": true, "doas": true, "env": true, "nohup": true,
+	"nice": true, "time": true, "timeout": true, "script": true, "tmux": true,
+	"tmux: client": true, "screen": true, "strace": true, "ltrace": true,
+	"xargs": true, "watch": true, "sh": true, "dash": true,
+}
+
+var versionPattern = regexp.MustCompile(`\d+(\.\d+)+`)
+
+// DetectShell returns the shell go-shellify was started from. The process
+// tree is walked first, falling back to $SHELL, which is only the login
+// shell, and then to the platform default.
+func DetectShell() (Info, error) {
+	info, ok := findShell(procRoot, os.Getppid())
+	if !ok {
+		info = fallbackShell()
+	}
+	if info.Path == "" {
+		info.Path = lookupShell(string(info.Type))
+	}
+	if info.Path != "" {
+		info.Version = shellVersion(info.Path)
+	}
+	return info, nil
+}
+
+// Version returns the version of the shell of a type the user runs, or an
+// empty string when it isn't installed or doesn't report one
+func Version(shellType string) string {
+	path := shellPath(shellType)
+	if path == "" {
+		return ""
+	}
+	return shellVersion(path)
+}
+
+// shellPath returns the executable of a shell type: the shell go-shellify
+// was started from or the login shell when they are of that type, and the
+// one found on $PATH otherwise
+func shellPath(shellType string) string {
+	if info, ok := findShell(procRoot, os.Getppid()); ok && string(info.Type) == shellType && info.Path != "" {
+		return info.Path
+	}
+	if login := os.Getenv("SHELL"); login != "" && detectFromPath(login) == shellType {
+		return login
+	}
+	return lookupShell(shellType)
+}
+
+// lookupShell finds the executable of a shell type on $PATH
+func lookupShell(shellType string) string {
+	binaries := []string{shellType}
+	if ShellType(shellType) == PowerShell {
+		binaries = []string{"pwsh", "powershell"}
+	}
+	for _, binary := range binaries {
+		if path, err := exec.LookPath(binary); err == nil {
+			return path
+		}
+	}
+	return ""
+}
+
+// findShell walks up the process tree from pid to the nearest shell,
+// skipping wrappers. Any other process ends the walk, the shell that started
+// it isn't the one go-shellify runs in.
+func findShell(root string, pid int) (Info, bool) {
+	for depth := 0; depth < maxProcessDepth && pid > 1; depth++ {
+		name, parent, err := readStat(root, pid)
+		if err != nil {
+			return Info{}, false
+		}
+
+		// The executable can be unreadable, e.g. for sudo running as root
+		path, _ := os.Readlink(filepath.Join(root, strconv.Itoa(pid), "exe"))
+		path = strings.TrimSuffix(path, " (deleted)")
+
+		// Login shells are started as -bash
+		names := []string{strings.TrimPrefix(name, "-")}
+		if path != "" {
+			names = append([]string{filepath.Base(path)}, names...)
+		}
+
+		wrapper := false
+		for _, name := range names {
+			if shellType, ok := processShells[name]; ok {
+				return Info{Type: shellType, Path: path}, true
+			}
+			wrapper = wrapper || processWrappers[name]
+		}
+		if !wrapper {
+			return Info{}, false
+		}
+		pid = parent
+	}
+	return Info{}, false
+}
+
+// readStat returns the command name and parent pid of a process from
+// /proc/<pid>/stat. The name is in parentheses and can contain spaces and
+// parentheses itself, so the fields are read after the last ")".
+func readStat(root string, pid int) (string, int, error) {
+	data, err := os.ReadFile(filepath.Join(root, strconv.Itoa(pid), "stat"))
+	if err != nil {
+		return "", 0, err
+	}
+
+	stat := string(data)
+	open := strings.IndexByte(stat, '(')
+	end := strings.LastIndexByte(stat, ')')
+	if open < 0 || end < open {
+		return "", 0, strconv.ErrSyntax
+	}
+
+	// Fields after the name: state, ppid, ...
+	fields := strings.Fields(stat[end+1:])
+	if len(fields) < 2 {
+		return "", 0, strconv.ErrSyntax
+	}
+	parent, err := strconv.Atoi(fields[1])
+	if err != nil {
+		return "", 0, err
+	}
+	return stat[open+1 : end], parent, nil
+}
+
+// fallbackShell detects the shell without the process tree
+func fallbackShell() Info {
+	if shell := os.Getenv("SHELL"); shell != "" {
+		return Info{Type: ShellType(detectFromPath(shell)), Path: shell}
+	}
+
+	shellType, _ := defaultShell()
+	return Info{Type: ShellType(shellType)}
+}
+
+// shellVersion asks a shell for its version
+func shellVersion(path string) string {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	output, err := exec.CommandContext(ctx, path, "--version").Output()
+	if err != nil {
+		return ""
+	}
+	return versionPattern.FindString(string(output))
+}
@@ -0,0 +1,158 @@
+package shell
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"testing"
+)
+
+// process is an entry of a fake /proc tree
+type process struct {
+	pid    int
+	comm   string
+	parent int
+	exe    string
+}
+
+func writeProcTree(t *testing.T, processes []process) string {
+	t.Helper()
+
+	root := t.TempDir()
+	for _, p := range processes {
+		dir := filepath.Join(root, strconv.Itoa(p.pid))
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+		stat := fmt.Sprintf("%d (%s) S %d %d 0 0 -1 4194560", p.pid, p.comm, p.parent, p.parent)
+		if err := os.WriteFile(filepath.Join(dir, "stat"), []byte(stat), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if p.exe != "" {
+			if err := os.Symlink(p.exe, filepath.Join(dir, "exe")); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	return root
+}
+
+func TestFindShell(t *testing.T) {
+	tests := []struct {
+		name      string
+		processes []process
+		expected  Info
+		found     bool
+	}{
+		{
+			name:      "direct parent",
+			processes: []process{{pid: 100, comm: "zsh", parent: 1, exe: "/usr/bin/zsh"}},
+			expected:  Info{Type: Zsh, Path: "/usr/bin/zsh"},
+			found:     true,
+		},
+		{
+			name: "through sudo, env and tmux",
+			processes: []process{
+				{pid: 100, comm: "env", parent: 90, exe: "/usr/bin/env"},
+				{pid: 90, comm: "sudo", parent: 80},
+				{pid: 80, comm: "fish", parent: 70, exe: "/usr/bin/fish"},
+				{pid: 70, comm: "tmux: server", parent: 1, exe: "/usr/bin/tmux"},
+			},
+			expected: Info{Type: Fish, Path: "/usr/bin/fish"},
+			found:    true,
+		},
+		{
+			name: "sh glue and login shell name",
+			processes: []process{
+				{pid: 100, comm: "sh", parent: 90, exe: "/usr/bin/dash"},
+				{pid: 90, comm: "-bash", parent: 1},
+			},
+			expected: Info{Type: Bash},
+			found:    true,
+		},
+		{
+			name: "renamed executable",
+			processes: []process{
+				{pid: 100, comm: "pwsh", parent: 1, exe: "/opt/microsoft/powershell/7/pwsh-real (deleted)"},
+			},
+			expected: Info{Type: PowerShell, Path: "/opt/microsoft/powershell/7/pwsh-real"},
+			found:    true,
+		},
+		{
+			name: "name with spaces and parentheses",
+			processes: []process{
+				{pid: 100, comm: "my (odd) tool", parent: 90},
+				{pid: 90, comm: "bash", parent: 1},
+			},
+		},
+		{
+			name: "started by another program",
+			processes: []process{
+				{pid: 100, comm: "make", parent: 90, exe: "/usr/bin/make"},
+				{pid: 90, comm: "zsh", parent: 1, exe: "/usr/bin/zsh"},
+			},
+		},
+		{
+			name:      "missing process",
+			processes: []process{{pid: 100, comm: "script", parent: 90}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := writeProcTree(t, tt.processes)
+
+			info, found := findShell(root, 100)
+			if found != tt.found || info != tt.expected {
+				t.Errorf("findShell() = %+v, %v, expected %+v, %v", info, found, tt.expected, tt.found)
+			}
+		})
+	}
+}
+
+func TestReadStat(t *testing.T) {
+	root := writeProcTree(t, []process{{pid: 100, comm: "a) b (c", parent: 42}})
+
+	name, parent, err := readStat(root, 100)
+	if err != nil || name != "a) b (c" || parent != 42 {
+		t.Errorf("readStat() = %q, %d, %v", name, parent, err)
+	}
+}
+
+func TestShellVersion(t *testing.T) {
+	path, err := exec.LookPath("bash")
+	if err != nil {
+		t.Skip("bash not available")
+	}
+
+	if version := shellVersion(path); !versionPattern.MatchString(version) {
+		t.Errorf("shellVersion(bash) = %q", version)
+	}
+}
+
+func TestShellPath(t *testing.T) {
+	originalProcRoot := procRoot
+	defer func() { procRoot = originalProcRoot }()
+
+	procRoot = writeProcTree(t, []process{
+		{pid: os.Getppid(), comm: "bash", parent: 1, exe: "/opt/bash5/bin/bash"},
+	})
+	t.Setenv("SHELL", "/usr/local/bin/zsh")
+
+	if got := shellPath("bash"); got != "/opt/bash5/bin/bash" {
+		t.Errorf("shellPath(bash) = %q, expected the running shell", got)
+	}
+	if got := shellPath("zsh"); got != "/usr/local/bin/zsh" {
+		t.Errorf("shellPath(zsh) = %q, expected the login shell", got)
+	}
+
+	path, err := exec.LookPath("sh")
+	if err != nil {
+		t.Skip("sh not available")
+	}
+	if got := shellPath("sh"); got != path {
+		t.Errorf("shellPath(sh) = %q, expected %q from $PATH", got, path)
+	}
+}
@@ -8,13 +8,18 @@ const (
 	Zsh        ShellType = "zsh"
 	Fish       ShellType = "fish"
 	PowerShell ShellType = "powershell"
+	Nu         ShellType = "nu"
+	Elvish     ShellType = "elvish"
+	Xonsh      ShellType = "xonsh"
+	Tcsh       ShellType = "tcsh"
+	Csh        ShellType = "csh"
 	Cmd        ShellType = "cmd"
 )
 
 // IsSupported checks if the shell type is supported
 func IsSupported(shellType string) bool {
 	switch ShellType(shellType) {
-	case Bash, Zsh, Fish, PowerShell:
+	case Bash, Zsh, Fish, PowerShell, Nu, Elvish, Xonsh, Tcsh, Csh, Cmd:
 		return true
 	default:
 		return false
@@ -28,6 +33,16 @@ func GetFileExtension(shellType string) string {
 		return ".ps1"
 	case Fish:
 		return ".fish"
+	case Nu:
+		return ".nu"
+	case Elvish:
+		return ".elv"
+	case Xonsh:
+		return ".xsh"
+	case Tcsh, Csh:
+		return ".csh"
+	case Cmd:
+		return ".cmd"
 	default:
 		return ".sh"
 	}